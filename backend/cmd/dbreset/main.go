@@ -19,7 +19,7 @@ func main() {
 	}
 	defer db.Close()
 
-	tables := []string{"price_records", "processed_files", "products"}
+	tables := []string{"budget_crossings", "budgets", "product_group_members", "product_groups", "shopping_list_items", "shopping_lists", "alerts", "watchlist", "price_records", "tickets", "processed_files", "product_tags", "product_favourites", "products_fts", "products"}
 	for _, t := range tables {
 		if _, err := db.Exec("DELETE FROM " + t); err != nil {
 			log.Fatalf("delete from %s: %v", t, err)
@@ -0,0 +1,141 @@
+// Command digest renders the weekly or monthly shopping digest of every
+// household and writes it to files, emails it to the members, or both.
+//
+// Usage:
+//
+//	go run ./cmd/digest -db <path-to-db> [-period week|month] [-date YYYY-MM-DD] [-out dir] [-send]
+//
+// The digest covers the last complete period before -date (default today).
+// -out writes one .html and one .txt file per household; -send mails them
+// through the relay configured in SMTP_ADDR, SMTP_FROM and, optionally,
+// SMTP_USERNAME and SMTP_PASSWORD. Households without purchases or imports in
+// the period are skipped. Digests show a household's spending, so the files
+// are readable by their owner only. A household that fails is logged and the
+// rest are still processed; the command then exits with status 1.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"basket-cost/internal/database"
+	"basket-cost/internal/digest"
+	"basket-cost/internal/notify"
+	"basket-cost/internal/store"
+)
+
+func main() {
+	dbPath := flag.String("db", "basket-cost.db", "path to the SQLite database file")
+	period := flag.String("period", string(store.GranularityWeek), "digest period: week or month")
+	date := flag.String("date", "", "reference day (YYYY-MM-DD); the digest covers the last complete period before it")
+	outDir := flag.String("out", "", "directory to write the rendered digests to")
+	send := flag.Bool("send", false, "email the digests through the SMTP relay")
+	flag.Parse()
+
+	g := store.Granularity(*period)
+	if g != store.GranularityWeek && g != store.GranularityMonth {
+		log.Fatalf("-period must be week or month, got %q", *period)
+	}
+	if *outDir == "" && !*send {
+		log.Fatal("usage: digest -db <path> [-period week|month] [-date YYYY-MM-DD] (-out <dir> | -send)")
+	}
+	ref := time.Now().UTC()
+	if *date != "" {
+		var err error
+		if ref, err = time.Parse(time.DateOnly, *date); err != nil {
+			log.Fatalf("-date must be YYYY-MM-DD: %v", err)
+		}
+	}
+
+	var mailer *notify.SMTP
+	if *send {
+		var err error
+		mailer, err = notify.NewSMTP(notify.SMTPConfig{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			From:     os.Getenv("SMTP_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		})
+		if err != nil {
+			log.Fatalf("configure smtp: %v", err)
+		}
+	}
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o700); err != nil {
+			log.Fatalf("create %s: %v", *outDir, err)
+		}
+	}
+
+	db, err := database.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("open database %q: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	s := store.New(db)
+	groups, err := s.GetHouseholdGroups()
+	if err != nil {
+		log.Fatalf("list households: %v", err)
+	}
+
+	var written, sent, failed int
+	for _, members := range groups {
+		owner := members[0]
+		d, err := s.GetDigest(owner.ID, g, ref)
+		if err != nil {
+			log.Printf("digest for %s: %v", owner.Username, err)
+			failed++
+			continue
+		}
+		if d.TotalSpent == 0 && d.TicketsImported == 0 {
+			continue
+		}
+		rendered, err := digest.Render(d)
+		if err != nil {
+			log.Printf("render digest for %s: %v", owner.Username, err)
+			failed++
+			continue
+		}
+
+		if *outDir != "" {
+			base := filepath.Join(*outDir, fmt.Sprintf("digest-%s-%s-user%d", d.Period, d.From, owner.ID))
+			ok := true
+			for ext, body := range map[string]string{".html": rendered.HTML, ".txt": rendered.Text} {
+				if err := os.WriteFile(base+ext, []byte(body), 0o600); err != nil {
+					log.Printf("write %s: %v", base+ext, err)
+					ok = false
+				}
+			}
+			if ok {
+				written++
+			} else {
+				failed++
+			}
+		}
+		if mailer != nil {
+			for _, m := range members {
+				if m.Email == "" {
+					continue
+				}
+				err := mailer.SendMail(context.Background(), notify.Mail{
+					To: m.Email, Subject: rendered.Subject, Text: rendered.Text, HTML: rendered.HTML,
+				})
+				if err != nil {
+					log.Printf("send digest to %s: %v", m.Username, err)
+					failed++
+					continue
+				}
+				sent++
+			}
+		}
+	}
+	log.Printf("%s digests: %d households written, %d emails sent, %d failures", g, written, sent, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
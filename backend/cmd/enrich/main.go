@@ -1,5 +1,5 @@
-// Command enrich fetches product images from the Mercadona public API and
-// persists them in the local SQLite database.
+// Command enrich fetches product images and categories from the Mercadona
+// public API and persists them in the local SQLite database.
 //
 // Usage:
 //
@@ -40,6 +40,6 @@ func main() {
 		log.Fatalf("enricher run: %v", err)
 	}
 
-	log.Printf("enricher done — total: %d, updated: %d, skipped: %d",
-		result.Total, result.Updated, result.Skipped)
+	log.Printf("enricher done — total: %d, updated: %d, categorised: %d, skipped: %d",
+		result.Total, result.Updated, result.Categorised, result.Skipped)
 }
@@ -0,0 +1,61 @@
+// Command ipcimport loads official IPC CSV exports from INE or IDESCAT into
+// the local SQLite database and refreshes the yearly ipc_rates from the
+// Catalan headline series.
+//
+// Usage:
+//
+//	go run ./cmd/ipcimport -db <path-to-db> [-region CT] <export.csv>...
+//
+// Each file may hold the index, the annual rate or both, for any number of
+// regions and ECOICOP subindices. -region applies to exports without a region
+// column (e.g. IDESCAT's Catalan series).
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"basket-cost/internal/database"
+	"basket-cost/internal/ipc"
+	"basket-cost/internal/store"
+)
+
+func main() {
+	dbPath := flag.String("db", "basket-cost.db", "path to the SQLite database file")
+	region := flag.String("region", store.HeadlineIPC.Region, "region code for exports without a region column")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: ipcimport -db <path> [-region CT] <export.csv>...")
+	}
+
+	db, err := database.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("open database %q: %v", *dbPath, err)
+	}
+	defer db.Close()
+
+	s := store.New(db)
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("open %s: %v", path, err)
+		}
+		obs, err := ipc.Parse(f, *region)
+		f.Close()
+		if err != nil {
+			log.Fatalf("parse %s: %v", path, err)
+		}
+		if err := s.UpsertIPCObservations(obs); err != nil {
+			log.Fatalf("import %s: %v", path, err)
+		}
+		log.Printf("%s: %d monthly observations imported", path, len(obs))
+	}
+
+	years, err := s.SyncIPCRates()
+	if err != nil {
+		log.Fatalf("sync ipc_rates: %v", err)
+	}
+	log.Printf("ipc_rates updated for %d years", years)
+}
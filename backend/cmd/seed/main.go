@@ -83,7 +83,7 @@ func main() {
 	// PDF extraction and parsing are CPU-bound (pure Go, no cgo). Running them
 	// in parallel on multiple cores cuts wall-clock time proportionally.
 	// SQLite writes are serialised by MaxOpenConns(1) regardless, so
-	// UpsertPriceRecordBatch calls queue transparently behind the scenes.
+	// UpsertTicket calls queue transparently behind the scenes.
 
 	numWorkers := *workers
 	if numWorkers < 1 {
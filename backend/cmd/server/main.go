@@ -5,6 +5,8 @@ import (
 	"basket-cost/internal/database"
 	"basket-cost/internal/enricher"
 	"basket-cost/internal/handlers"
+	"basket-cost/internal/models"
+	"basket-cost/internal/notify"
 	"basket-cost/internal/ratelimit"
 	"basket-cost/internal/store"
 	"basket-cost/internal/ticket"
@@ -31,8 +33,9 @@ func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Vary", "Origin")
 		}
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Requested-With, Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Next-Cursor")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -75,6 +78,37 @@ func optionalAuthMiddleware(s store.Store) func(http.HandlerFunc) http.HandlerFu
 	}
 }
 
+// newNotifier builds the notification channels configured in the
+// environment: SMTP_ADDR/SMTP_FROM (plus optional SMTP_USERNAME and
+// SMTP_PASSWORD) for email and WEBHOOK_URL/WEBHOOK_SECRET for webhooks.
+// Returns nil when none is configured.
+func newNotifier(s store.Store) *notify.Notifier {
+	var channels []notify.Channel
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		ch, err := notify.NewSMTP(notify.SMTPConfig{
+			Addr:     addr,
+			From:     os.Getenv("SMTP_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		})
+		if err != nil {
+			log.Fatalf("configure smtp notifications: %v", err)
+		}
+		channels = append(channels, ch)
+	}
+	if url := os.Getenv("WEBHOOK_URL"); url != "" {
+		secret := os.Getenv("WEBHOOK_SECRET")
+		if secret == "" {
+			log.Fatal("WEBHOOK_SECRET is required when WEBHOOK_URL is set")
+		}
+		channels = append(channels, notify.NewWebhook(url, secret))
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+	return notify.New(s, channels...)
+}
+
 func main() {
 	// CRÍTICO: JWT_SECRET es obligatorio. Sin ella los tokens son trivialmente
 	// falsificables usando el secreto por defecto hardcoded.
@@ -107,6 +141,13 @@ func main() {
 	enr := enricher.New(s)
 	enr.Start(context.Background())
 	h := handlers.New(s, imp, enr)
+	h.SetQueryExpander(enricher.NewQueryExpander())
+	if n := newNotifier(s); n != nil {
+		h.SetNotifier(n)
+		s.SetAlertListener(func(userID int64, a models.Alert) {
+			n.Publish(notify.Event{Kind: notify.EventPriceAlertFired, UserID: userID, Data: a})
+		})
+	}
 
 	// chain applies the standard middleware stack to any handler.
 	authMiddleware := optionalAuthMiddleware(s)
@@ -121,6 +162,10 @@ func main() {
 		return chain(authLimiter.Middleware(handler))
 	}
 
+	// inviteLimiter restricts household invitations, each of which emails an
+	// address chosen by the caller, to 5 bursts, then 1 req / minute per IP.
+	inviteLimiter := ratelimit.New(rate.Every(time.Minute), 5)
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/api/auth/register", chainAuth(h.RegisterHandler))
@@ -131,8 +176,31 @@ func main() {
 	mux.HandleFunc("/api/products/", chain(h.ProductRouter))
 	mux.HandleFunc("/api/tickets", chain(h.TicketHandler))
 	mux.HandleFunc("/api/analytics", chain(h.AnalyticsHandler))
+	mux.HandleFunc("/api/analytics/tags", chain(h.TagAnalyticsHandler))
+	mux.HandleFunc("/api/analytics/spending", chain(h.SpendingHandler))
+	mux.HandleFunc("/api/analytics/breakdown", chain(h.BreakdownHandler))
+	mux.HandleFunc("/api/analytics/inflation", chain(h.InflationHandler))
+	mux.HandleFunc("/api/analytics/members", chain(h.MembersHandler))
+	mux.HandleFunc("/api/analytics/patterns", chain(h.PatternsHandler))
+	mux.HandleFunc("/api/price-changes", chain(h.PriceChangesHandler))
+	mux.HandleFunc("/api/digest", chain(h.DigestHandler))
+	mux.HandleFunc("/api/running-low", chain(h.RunningLowHandler))
+	mux.HandleFunc("/api/basket/replay", chain(h.BasketReplayHandler))
+	mux.HandleFunc("/api/budgets", chain(h.BudgetsHandler))
+	mux.HandleFunc("/api/budgets/", chain(h.BudgetsHandler))
+	mux.HandleFunc("/api/product-groups", chain(h.ProductGroupsHandler))
+	mux.HandleFunc("/api/product-groups/", chain(h.ProductGroupsHandler))
+	mux.HandleFunc("/api/stores/compare", chain(h.StoreComparisonHandler))
+	mux.HandleFunc("/api/lists", chain(h.ShoppingListsHandler))
+	mux.HandleFunc("/api/lists/", chain(h.ShoppingListsHandler))
+	mux.HandleFunc("/api/review", chain(h.ReviewHandler))
+	mux.HandleFunc("/api/review/", chain(h.ReviewHandler))
+	mux.HandleFunc("/api/watchlist", chain(h.WatchlistHandler))
+	mux.HandleFunc("/api/watchlist/", chain(h.WatchlistHandler))
+	mux.HandleFunc("/api/alerts", chain(h.AlertsHandler))
+	mux.HandleFunc("/api/alerts/", chain(h.AlertsHandler))
 	mux.HandleFunc("/api/household", chain(h.HouseholdHandler))
-	mux.HandleFunc("/api/household/invite", chain(h.HouseholdInviteHandler))
+	mux.HandleFunc("/api/household/invite", chain(inviteLimiter.Middleware(h.HouseholdInviteHandler)))
 	mux.HandleFunc("/api/household/accept", chain(h.HouseholdAcceptHandler))
 	mux.HandleFunc("/api/ipc", chain(h.IPCHandler))
 
@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
+
+	"basket-cost/internal/textnorm"
 
 	_ "modernc.org/sqlite"
 )
@@ -235,21 +238,410 @@ func migrate(db *sql.DB) error {
 		return fmt.Errorf("migrate m11: %w", err)
 	}
 
+	// m12: allow manual category pinning so the enricher won't overwrite it.
+	if err := addColumnIfMissing(db, "products", "category_locked",
+		`ALTER TABLE products ADD COLUMN category_locked INTEGER NOT NULL DEFAULT 0`); err != nil {
+		return fmt.Errorf("migrate m12 products.category_locked: %w", err)
+	}
+
+	// m13: user-defined tags and favourites. Rows belong to the user who
+	// created them and are shared with the rest of their household, the same
+	// way price_records are.
+	m13 := `
+		CREATE TABLE IF NOT EXISTS product_tags (
+			product_id TEXT    NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			user_id    INTEGER NOT NULL REFERENCES users(id)    ON DELETE CASCADE,
+			tag        TEXT    NOT NULL,
+			created_at TEXT    NOT NULL,  -- ISO-8601 timestamp
+			PRIMARY KEY (product_id, user_id, tag)
+		);
+		CREATE INDEX IF NOT EXISTS idx_product_tags_user_tag
+			ON product_tags(user_id, tag);
+
+		CREATE TABLE IF NOT EXISTS product_favourites (
+			product_id TEXT    NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			user_id    INTEGER NOT NULL REFERENCES users(id)    ON DELETE CASCADE,
+			created_at TEXT    NOT NULL,  -- ISO-8601 timestamp
+			PRIMARY KEY (product_id, user_id)
+		);
+	`
+	if _, err := db.Exec(m13); err != nil {
+		return fmt.Errorf("migrate m13: %w", err)
+	}
+
+	// m14: full-text search index over product names. Both columns hold text
+	// already passed through textnorm.Normalise, so matching is case- and
+	// accent-insensitive. aliases collects other raw spellings that slugified to
+	// the same product ID. The store keeps this table in sync on every upsert.
+	m14 := `
+		CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+			product_id UNINDEXED,
+			name,
+			aliases
+		);
+	`
+	if _, err := db.Exec(m14); err != nil {
+		return fmt.Errorf("migrate m14: %w", err)
+	}
+	if err := backfillProductsFTS(db); err != nil {
+		return fmt.Errorf("migrate m14 backfill: %w", err)
+	}
+
+	// m15: units bought per ticket line, so spend is price × quantity. Rows
+	// imported before this column existed count as a single unit.
+	if err := addColumnIfMissing(db, "price_records", "quantity",
+		`ALTER TABLE price_records ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1`); err != nil {
+		return fmt.Errorf("migrate m15 price_records.quantity: %w", err)
+	}
+	m15 := `
+		CREATE INDEX IF NOT EXISTS idx_price_records_user_date
+			ON price_records(user_id, date);
+	`
+	if _, err := db.Exec(m15); err != nil {
+		return fmt.Errorf("migrate m15: %w", err)
+	}
+
+	// m16: monthly IPC by region and ECOICOP subindex, loaded from the official
+	// INE/IDESCAT CSV exports by cmd/ipcimport. region is "ES" for Spain or an
+	// ISO 3166-2:ES community code ("CT" for Catalonia); subindex is "general"
+	// or an ECOICOP code ("01" food and non-alcoholic beverages, "011" food…).
+	// period is "YYYY-MM". ipc_rates stays as the yearly headline series.
+	m16 := `
+		CREATE TABLE IF NOT EXISTS ipc_monthly (
+			region      TEXT NOT NULL,
+			subindex    TEXT NOT NULL,
+			period      TEXT NOT NULL,
+			index_value REAL,  -- index level (base year = 100)
+			annual_rate REAL,  -- change vs the same month a year earlier, as decimal
+			PRIMARY KEY (region, subindex, period)
+		);
+	`
+	if _, err := db.Exec(m16); err != nil {
+		return fmt.Errorf("migrate m16: %w", err)
+	}
+
+	// m17: per-user watchlist rules and the alert inbox they fill. Rules are
+	// evaluated by the store whenever a ticket import inserts price records.
+	// alerts.kind names what raised the alert (for watchlist alerts, the rule);
+	// the price columns are NULL for kinds that carry no price.
+	m17 := `
+		CREATE TABLE IF NOT EXISTS watchlist (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id    INTEGER NOT NULL REFERENCES users(id)    ON DELETE CASCADE,
+			product_id TEXT    NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+			rule       TEXT    NOT NULL,  -- any_increase | price_above | price_below | increase_percent
+			threshold  REAL,              -- price (€) or percentage, depending on rule
+			created_at TEXT    NOT NULL,  -- ISO-8601 timestamp
+			UNIQUE (user_id, product_id, rule)
+		);
+		CREATE INDEX IF NOT EXISTS idx_watchlist_product ON watchlist(product_id);
+
+		CREATE TABLE IF NOT EXISTS alerts (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id    INTEGER NOT NULL REFERENCES users(id)    ON DELETE CASCADE,
+			kind       TEXT    NOT NULL,
+			product_id TEXT             REFERENCES products(id) ON DELETE CASCADE,
+			watch_id   INTEGER          REFERENCES watchlist(id) ON DELETE SET NULL,
+			old_price  REAL,
+			new_price  REAL,
+			threshold  REAL,
+			date       TEXT,              -- purchase date that raised the alert (YYYY-MM-DD)
+			created_at TEXT    NOT NULL,  -- ISO-8601 timestamp
+			read_at    TEXT               -- NULL while unread
+		);
+		CREATE INDEX IF NOT EXISTS idx_alerts_user ON alerts(user_id, id);
+	`
+	if _, err := db.Exec(m17); err != nil {
+		return fmt.Errorf("migrate m17: %w", err)
+	}
+
+	// m18: shopping lists, shared across the creator's household like tags.
+	// Each product appears at most once per list; checked marks it as bought.
+	m18 := `
+		CREATE TABLE IF NOT EXISTS shopping_lists (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name       TEXT    NOT NULL,
+			created_at TEXT    NOT NULL,  -- ISO-8601 timestamp
+			updated_at TEXT    NOT NULL   -- ISO-8601 timestamp
+		);
+		CREATE INDEX IF NOT EXISTS idx_shopping_lists_user ON shopping_lists(user_id);
+
+		CREATE TABLE IF NOT EXISTS shopping_list_items (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			list_id    INTEGER NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+			product_id TEXT    NOT NULL REFERENCES products(id)       ON DELETE CASCADE,
+			quantity   REAL    NOT NULL DEFAULT 1,
+			checked    INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT    NOT NULL,  -- ISO-8601 timestamp
+			UNIQUE (list_id, product_id)
+		);
+	`
+	if _, err := db.Exec(m18); err != nil {
+		return fmt.Errorf("migrate m18: %w", err)
+	}
+
+	// m19: groups of equivalent products sold under different names (usually
+	// by different retailers), used to compare stores. Groups are shared across
+	// the creator's household; the store keeps a product in at most one group
+	// per household.
+	m19 := `
+		CREATE TABLE IF NOT EXISTS product_groups (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name       TEXT    NOT NULL,
+			created_at TEXT    NOT NULL   -- ISO-8601 timestamp
+		);
+		CREATE INDEX IF NOT EXISTS idx_product_groups_user ON product_groups(user_id);
+
+		CREATE TABLE IF NOT EXISTS product_group_members (
+			group_id   INTEGER NOT NULL REFERENCES product_groups(id) ON DELETE CASCADE,
+			product_id TEXT    NOT NULL REFERENCES products(id)       ON DELETE CASCADE,
+			PRIMARY KEY (group_id, product_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_product_group_members_product
+			ON product_group_members(product_id);
+	`
+	if _, err := db.Exec(m19); err != nil {
+		return fmt.Errorf("migrate m19: %w", err)
+	}
+
+	// m20: monthly budgets, overall (category '') or per top-level category,
+	// shared across the creator's household. household_key names that
+	// household ("h:<household id>", or "u:<user id>" for a user without one)
+	// and is unique with the category, so concurrent writes cannot create two
+	// budgets for a category; the store re-keys a member's budgets when they
+	// join or leave a household. budget_crossings records the thresholds (80 and
+	// 100 percent) already alerted for a budget in a month ("YYYY-MM"), so that
+	// each one is raised once. Budget alerts reuse the alerts table.
+	m20 := `
+		CREATE TABLE IF NOT EXISTS budgets (
+			id            INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			category      TEXT    NOT NULL DEFAULT '',
+			monthly_limit REAL    NOT NULL,
+			created_at    TEXT    NOT NULL,  -- ISO-8601 timestamp
+			updated_at    TEXT    NOT NULL   -- ISO-8601 timestamp
+		);
+		CREATE INDEX IF NOT EXISTS idx_budgets_user ON budgets(user_id);
+
+		CREATE TABLE IF NOT EXISTS budget_crossings (
+			budget_id INTEGER NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+			month     TEXT    NOT NULL,
+			percent   INTEGER NOT NULL,
+			PRIMARY KEY (budget_id, month, percent)
+		);
+	`
+	if _, err := db.Exec(m20); err != nil {
+		return fmt.Errorf("migrate m20: %w", err)
+	}
+	keyed, err := columnExists(db, "budgets", "household_key")
+	if err != nil {
+		return fmt.Errorf("migrate m20 budgets.household_key: %w", err)
+	}
+	if !keyed {
+		if err := keyBudgets(db); err != nil {
+			return fmt.Errorf("migrate m20 budgets.household_key: %w", err)
+		}
+	}
+	if err := addColumnIfMissing(db, "alerts", "budget_id",
+		`ALTER TABLE alerts ADD COLUMN budget_id INTEGER REFERENCES budgets(id) ON DELETE SET NULL`); err != nil {
+		return fmt.Errorf("migrate m20 alerts.budget_id: %w", err)
+	}
+	if err := addColumnIfMissing(db, "alerts", "category",
+		`ALTER TABLE alerts ADD COLUMN category TEXT`); err != nil {
+		return fmt.Errorf("migrate m20 alerts.category: %w", err)
+	}
+	if err := addColumnIfMissing(db, "alerts", "spent",
+		`ALTER TABLE alerts ADD COLUMN spent REAL`); err != nil {
+		return fmt.Errorf("migrate m20 alerts.spent: %w", err)
+	}
+
+	// m21: review flag on price records. The importer sets anomaly ("outlier"
+	// or "line_total") on lines whose price looks like a parse or keying error;
+	// it stays set until a user confirms the record. Meanwhile flagged records
+	// are left out of price analytics: analytics rankings, the spending series
+	// and breakdown, spend per tag, price changes, current, latest and
+	// reference prices, the personal inflation index, the basket replay,
+	// inflation-adjusted prices and forecasts. Budgets, digests and per-member
+	// spend still count them. NULL means nothing to review.
+	if err := addColumnIfMissing(db, "price_records", "anomaly",
+		`ALTER TABLE price_records ADD COLUMN anomaly TEXT`); err != nil {
+		return fmt.Errorf("migrate m21 price_records.anomaly: %w", err)
+	}
+	if _, err := db.Exec(
+		`CREATE INDEX IF NOT EXISTS idx_price_records_anomaly ON price_records(user_id) WHERE anomaly IS NOT NULL`,
+	); err != nil {
+		return fmt.Errorf("migrate m21: %w", err)
+	}
+
+	// m22: imported tickets with their totals, so that shopping patterns
+	// (weekday, time of month, basket size) can be read per visit rather than
+	// per line. total is the amount printed on the receipt, or Σ price ×
+	// quantity over the ticket's lines when the receipt shows none.
+	// price_records.ticket_id links each imported line to its ticket; it is
+	// NULL for prices added by hand. The column is added together with a
+	// backfill that groups older imported records into one ticket per member,
+	// store and day (totals recomputed from the lines), in one transaction so
+	// that a failed backfill is retried on the next start. Records carried no
+	// source before m22, so they count as imported when their member has
+	// uploaded ticket files (processed_files).
+	m22 := `
+		CREATE TABLE IF NOT EXISTS tickets (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id        INTEGER REFERENCES users(id) ON DELETE CASCADE,  -- NULL for anonymous imports
+			invoice_number TEXT    NOT NULL DEFAULT '',
+			store          TEXT    NOT NULL DEFAULT '',
+			date           TEXT    NOT NULL,  -- ISO-8601: YYYY-MM-DD
+			total          REAL    NOT NULL,
+			lines          INTEGER NOT NULL,
+			imported_at    TEXT    NOT NULL   -- ISO-8601 timestamp
+		);
+		CREATE INDEX IF NOT EXISTS idx_tickets_user_date ON tickets(user_id, date);
+	`
+	if _, err := db.Exec(m22); err != nil {
+		return fmt.Errorf("migrate m22: %w", err)
+	}
+	linked, err := columnExists(db, "price_records", "ticket_id")
+	if err != nil {
+		return fmt.Errorf("migrate m22 price_records.ticket_id: %w", err)
+	}
+	if !linked {
+		if err := linkTickets(db); err != nil {
+			return fmt.Errorf("migrate m22 price_records.ticket_id: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// backfillProductsFTS indexes every product that has no products_fts row yet.
+// It is a no-op once the index is complete.
+func backfillProductsFTS(db *sql.DB) error {
+	rows, err := db.Query(
+		`SELECT id, name FROM products WHERE id NOT IN (SELECT product_id FROM products_fts)`,
+	)
+	if err != nil {
+		return err
+	}
+	type product struct{ id, name string }
+	var missing []product
+	for rows.Next() {
+		var p product
+		if err := rows.Scan(&p.id, &p.name); err != nil {
+			rows.Close()
+			return err
+		}
+		missing = append(missing, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	for _, p := range missing {
+		if _, err := tx.Exec(
+			`INSERT INTO products_fts (product_id, name, aliases) VALUES (?, ?, '')`,
+			p.id, textnorm.Normalise(p.name),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// linkTickets adds price_records.ticket_id and, in the same transaction,
+// groups the imported records into one ticket per member, store and day and
+// links them to it. A record is imported when its member has processed files.
+func linkTickets(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	if _, err := tx.Exec(
+		`ALTER TABLE price_records ADD COLUMN ticket_id INTEGER REFERENCES tickets(id) ON DELETE SET NULL`,
+	); err != nil {
+		return err
+	}
+	imported := `EXISTS (SELECT 1 FROM processed_files f WHERE f.user_id IS price_records.user_id)`
+	if _, err := tx.Exec(`
+		INSERT INTO tickets (user_id, store, date, total, lines, imported_at)
+		SELECT user_id, store, date, ROUND(SUM(price * quantity), 2), COUNT(*), ?
+		FROM price_records
+		WHERE `+imported+`
+		GROUP BY user_id, store, date
+	`, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE price_records SET ticket_id = (
+			SELECT t.id FROM tickets t
+			WHERE t.user_id IS price_records.user_id
+			  AND t.store = price_records.store
+			  AND t.date = price_records.date
+		)
+		WHERE ` + imported); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// keyBudgets adds budgets.household_key and, in the same transaction, fills
+// it from the household of each budget's creator, keeps only the most recently
+// updated budget per household and category, and adds the unique index.
+func keyBudgets(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	for _, stmt := range []string{
+		`ALTER TABLE budgets ADD COLUMN household_key TEXT NOT NULL DEFAULT ''`,
+		`UPDATE budgets SET household_key = (
+			SELECT CASE WHEN u.household_id IS NULL THEN 'u:' || u.id ELSE 'h:' || u.household_id END
+			FROM users u WHERE u.id = budgets.user_id
+		)`,
+		`DELETE FROM budgets WHERE EXISTS (
+			SELECT 1 FROM budgets b
+			WHERE b.household_key = budgets.household_key AND b.category = budgets.category
+			  AND (b.updated_at > budgets.updated_at OR (b.updated_at = budgets.updated_at AND b.id > budgets.id))
+		)`,
+		`CREATE UNIQUE INDEX idx_budgets_household_category ON budgets(household_key, category)`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 // addColumnIfMissing adds a column to a table only when it does not exist yet.
 // SQLite does not support IF NOT EXISTS on ALTER TABLE ADD COLUMN.
 func addColumnIfMissing(db *sql.DB, table, column, alterSQL string) error {
-	var count int
-	err := db.QueryRow(
-		`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name=?`, table, column,
-	).Scan(&count)
+	exists, err := columnExists(db, table, column)
 	if err != nil {
 		return err
 	}
-	if count == 0 {
+	if !exists {
 		_, err = db.Exec(alterSQL)
 	}
 	return err
 }
+
+// columnExists reports whether table has a column with the given name.
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	var count int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name=?`, table, column,
+	).Scan(&count)
+	return count > 0, err
+}
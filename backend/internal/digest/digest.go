@@ -0,0 +1,104 @@
+// Package digest renders household shopping digests as HTML and plain text
+// from embedded templates.
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// funcs are shared by the HTML and text templates. Numbers use the Spanish
+// decimal comma, like the rest of the user-facing text.
+var funcs = map[string]any{
+	"euros":  euros,
+	"pct":    pct,
+	"date":   formatDate,
+	"period": periodName,
+}
+
+var (
+	htmlTmpl = htmltemplate.Must(htmltemplate.New("digest.html.tmpl").Funcs(funcs).ParseFS(templateFS, "templates/digest.html.tmpl"))
+	textTmpl = texttemplate.Must(texttemplate.New("digest.txt.tmpl").Funcs(funcs).ParseFS(templateFS, "templates/digest.txt.tmpl"))
+)
+
+// Rendered is a digest ready to be saved or emailed.
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Render produces the subject, plain-text and HTML versions of d.
+func Render(d models.Digest) (Rendered, error) {
+	var text, html bytes.Buffer
+	if err := textTmpl.Execute(&text, d); err != nil {
+		return Rendered{}, fmt.Errorf("render text digest: %w", err)
+	}
+	if err := htmlTmpl.Execute(&html, d); err != nil {
+		return Rendered{}, fmt.Errorf("render html digest: %w", err)
+	}
+	subject := fmt.Sprintf("Tu resumen %s de basket-cost (%s – %s)", periodName(d.Period), formatDate(d.From), formatDate(d.To))
+	return Rendered{Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+// euros formats v as "1234,56 €".
+func euros(v float64) string {
+	return decimalComma(strconv.FormatFloat(v, 'f', 2, 64)) + " €"
+}
+
+// pct formats a float64 or *float64 percentage with its sign ("+3,2 %"); nil
+// pointers render as "–".
+func pct(v any) string {
+	var f float64
+	switch x := v.(type) {
+	case float64:
+		f = x
+	case *float64:
+		if x == nil {
+			return "–"
+		}
+		f = *x
+	default:
+		return fmt.Sprint(v)
+	}
+	s := strconv.FormatFloat(f, 'f', 1, 64)
+	if f > 0 {
+		s = "+" + s
+	}
+	return decimalComma(s) + " %"
+}
+
+func decimalComma(s string) string {
+	return strings.Replace(s, ".", ",", 1)
+}
+
+// formatDate turns "2025-03-09" into "09/03/2025"; other values pass through.
+func formatDate(s string) string {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return s
+	}
+	return t.Format("02/01/2006")
+}
+
+// periodName is the adjective for a digest period ("semanal", "mensual").
+func periodName(p string) string {
+	switch p {
+	case "week":
+		return "semanal"
+	case "month":
+		return "mensual"
+	}
+	return p
+}
@@ -0,0 +1,76 @@
+package digest_test
+
+import (
+	"strings"
+	"testing"
+
+	"basket-cost/internal/digest"
+	"basket-cost/internal/models"
+)
+
+func sampleDigest() models.Digest {
+	change, ipc := 12.5, 2.8
+	return models.Digest{
+		Period: "week", From: "2025-03-03", To: "2025-03-09",
+		TotalSpent: 45.3, PreviousTotalSpent: 40.27, SpendChangePercent: &change,
+		TicketsImported: 2, PriceIncreases: 1, PriceDecreases: 0,
+		PriceChanges: []models.PriceChangeEvent{
+			{Name: "LECHE <ENTERA>", OldPrice: 1, NewPrice: 1.1, ChangePercent: 10},
+		},
+		NewProducts: []models.DigestProduct{{Name: "PAN", Price: 1.5, Store: "Bonpreu", Date: "2025-03-06"}},
+		Inflation: models.DigestInflation{
+			From: "2024-04-01", To: "2025-03-09", PersonalChangePercent: 4.2, IPCChangePercent: &ipc,
+		},
+	}
+}
+
+func TestRender_Text(t *testing.T) {
+	r, err := digest.Render(sampleDigest())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if r.Subject != "Tu resumen semanal de basket-cost (03/03/2025 – 09/03/2025)" {
+		t.Errorf("subject: got %q", r.Subject)
+	}
+	for _, want := range []string{
+		"Gasto total: 45,30 € (+12,5 % respecto al periodo anterior, 40,27 €)",
+		"Tickets importados: 2",
+		"  - LECHE <ENTERA>: 1,00 € → 1,10 € (+10,0 %)",
+		"  - PAN: 1,50 € en Bonpreu (06/03/2025)",
+		"Tu inflación (01/04/2024 – 09/03/2025): +4,2 %",
+		"IPC en el mismo periodo: +2,8 %",
+	} {
+		if !strings.Contains(r.Text, want) {
+			t.Errorf("text digest lacks %q:\n%s", want, r.Text)
+		}
+	}
+}
+
+func TestRender_HTMLEscapesProductNames(t *testing.T) {
+	r, err := digest.Render(sampleDigest())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(r.HTML, "<ENTERA>") || !strings.Contains(r.HTML, "LECHE &lt;ENTERA&gt;") {
+		t.Errorf("product name not escaped in HTML:\n%s", r.HTML)
+	}
+	if !strings.Contains(r.HTML, "45,30 €") {
+		t.Errorf("HTML digest lacks the total spend")
+	}
+}
+
+func TestRender_MissingIPC(t *testing.T) {
+	d := sampleDigest()
+	d.Inflation.IPCChangePercent = nil
+	d.SpendChangePercent = nil
+	r, err := digest.Render(d)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(r.Text, "IPC en el mismo periodo: –") {
+		t.Errorf("want a dash for missing IPC:\n%s", r.Text)
+	}
+	if strings.Contains(r.Text, "respecto al periodo anterior") {
+		t.Errorf("no comparison expected without previous spend:\n%s", r.Text)
+	}
+}
@@ -43,7 +43,7 @@ func minMatched(localLen int) int {
 const indexTTL = 24 * time.Hour
 
 // Enricher downloads the Mercadona product catalogue and updates image URLs
-// for matching products in the local store.
+// and categories for matching products in the local store.
 //
 // The Mercadona product index is cached for indexTTL so that uploading
 // multiple tickets in quick succession does not trigger repeated full
@@ -101,7 +101,7 @@ func (e *Enricher) Start(ctx context.Context) {
 					log.Printf("enricher: background run failed: %v", err)
 					continue
 				}
-				log.Printf("enricher: updated %d/%d products", res.Updated, res.Total)
+				log.Printf("enricher: updated %d/%d products, categorised %d", res.Updated, res.Total, res.Categorised)
 			}
 		}
 	}()
@@ -143,44 +143,81 @@ func (e *Enricher) productIndex(ctx context.Context) (ProductIndex, error) {
 
 // EnrichResult summarises the outcome of a single enrichment run.
 type EnrichResult struct {
-	Total   int // products inspected
-	Updated int // products whose image URL was set
-	Skipped int // products with no match in the Mercadona index
+	Total       int // products inspected
+	Updated     int // products whose image URL was set
+	Categorised int // products whose category was set
+	Skipped     int // products with no match in the Mercadona index
 }
 
 // Run fetches the Mercadona catalogue (from cache when possible), matches it
-// against products that have no image URL yet, and updates image_url for
-// every match.  Products that already have an image are skipped.
+// against products that still lack an image URL or a category, and fills in
+// whichever of the two is missing for every match. Products whose image or
+// category has been set manually (locked) are never overwritten.
 func (e *Enricher) Run(ctx context.Context) (EnrichResult, error) {
 	index, err := e.productIndex(ctx)
 	if err != nil {
 		return EnrichResult{}, err
 	}
 
-	results, err := e.store.GetProductsWithoutImage()
+	withoutImage, err := e.store.GetProductsWithoutImage()
 	if err != nil {
 		return EnrichResult{}, fmt.Errorf("list products without image: %w", err)
 	}
+	withoutCategory, err := e.store.GetProductsWithoutCategory()
+	if err != nil {
+		return EnrichResult{}, fmt.Errorf("list products without category: %w", err)
+	}
+
+	// Merge both lists, remembering what each product is missing.
+	type candidate struct {
+		id, name             string
+		needsImage, needsCat bool
+	}
+	var candidates []*candidate
+	byID := make(map[string]*candidate)
+	add := func(id, name string) *candidate {
+		if c, ok := byID[id]; ok {
+			return c
+		}
+		c := &candidate{id: id, name: name}
+		byID[id] = c
+		candidates = append(candidates, c)
+		return c
+	}
+	for _, p := range withoutImage {
+		add(p.ID, p.Name).needsImage = true
+	}
+	for _, p := range withoutCategory {
+		add(p.ID, p.Name).needsCat = true
+	}
 
 	var res EnrichResult
-	res.Total = len(results)
+	res.Total = len(candidates)
 
-	for _, p := range results {
-		localKW := e.productKeywords(ctx, p.Name)
+	for _, c := range candidates {
+		localKW := e.productKeywords(ctx, c.name)
 		if len(localKW) == 0 {
 			res.Skipped++
 			continue
 		}
 
-		url, ok := bestMatch(localKW, index)
+		entry, ok := bestMatch(localKW, index)
 		if !ok {
 			res.Skipped++
 			continue
 		}
-		if err := e.store.UpdateProductImageURL(p.ID, url); err != nil {
-			return res, fmt.Errorf("update image for %s: %w", p.ID, err)
+		if c.needsImage {
+			if err := e.store.UpdateProductImageURL(c.id, entry.Thumbnail); err != nil {
+				return res, fmt.Errorf("update image for %s: %w", c.id, err)
+			}
+			res.Updated++
+		}
+		if c.needsCat && entry.Category != "" {
+			if err := e.store.UpdateProductCategory(c.id, entry.Category); err != nil {
+				return res, fmt.Errorf("update category for %s: %w", c.id, err)
+			}
+			res.Categorised++
 		}
-		res.Updated++
 	}
 
 	return res, nil
@@ -210,10 +247,10 @@ func (e *Enricher) productKeywords(ctx context.Context, name string) []string {
 // This metric penalises both missed local keywords (recall) and excess
 // catalogue keywords (precision), preventing a single shared token like
 // "patata" from matching an unrelated product with many extra keywords.
-// It returns the thumbnail URL and true if the best score ≥ minMatchScore.
-func bestMatch(localKW []string, index ProductIndex) (string, bool) {
+// It returns the best entry and true if the best score ≥ minMatchScore.
+func bestMatch(localKW []string, index ProductIndex) (ProductEntry, bool) {
 	if len(localKW) == 0 {
-		return "", false
+		return ProductEntry{}, false
 	}
 
 	localSet := make(map[string]bool, len(localKW))
@@ -222,7 +259,7 @@ func bestMatch(localKW []string, index ProductIndex) (string, bool) {
 	}
 
 	bestScore := 0.0
-	bestURL := ""
+	var best ProductEntry
 
 	for _, entry := range index {
 		if len(entry.Keywords) == 0 {
@@ -243,12 +280,12 @@ func bestMatch(localKW []string, index ProductIndex) (string, bool) {
 		score := 2.0 * float64(matched) / float64(len(localKW)+len(entry.Keywords))
 		if score > bestScore {
 			bestScore = score
-			bestURL = entry.Thumbnail
+			best = entry
 		}
 	}
 
 	if bestScore >= minMatchScore {
-		return bestURL, true
+		return best, true
 	}
-	return "", false
+	return ProductEntry{}, false
 }
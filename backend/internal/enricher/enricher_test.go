@@ -95,12 +95,12 @@ func TestBestMatch_ExactMatch(t *testing.T) {
 		{Thumbnail: "https://example.com/pan.jpg", Keywords: []string{"pan", "integral", "molde"}},
 	}
 	// local=[leche,entera], entry=[leche,entera] → matched=2, Dice=2·2/(2+2)=1.0 ≥ 0.5 ✓
-	url, ok := bestMatch([]string{"leche", "entera"}, index)
+	entry, ok := bestMatch([]string{"leche", "entera"}, index)
 	if !ok {
 		t.Fatal("bestMatch: expected match, got none")
 	}
-	if url != "https://example.com/leche.jpg" {
-		t.Errorf("bestMatch URL = %q, want leche.jpg", url)
+	if entry.Thumbnail != "https://example.com/leche.jpg" {
+		t.Errorf("bestMatch URL = %q, want leche.jpg", entry.Thumbnail)
 	}
 }
 
@@ -139,12 +139,12 @@ func TestBestMatch_PicksBestCandidate(t *testing.T) {
 		// local=[yogur,natural], entry2=[yogur,coco] → matched=1, Dice=2·1/(2+2)=0.5
 		{Thumbnail: "https://example.com/yogur-coco.jpg", Keywords: []string{"yogur", "coco"}},
 	}
-	url, ok := bestMatch([]string{"yogur", "natural"}, index)
+	entry, ok := bestMatch([]string{"yogur", "natural"}, index)
 	if !ok {
 		t.Fatal("bestMatch: expected match, got none")
 	}
-	if url != "https://example.com/yogur-natural.jpg" {
-		t.Errorf("bestMatch picked wrong candidate: %q", url)
+	if entry.Thumbnail != "https://example.com/yogur-natural.jpg" {
+		t.Errorf("bestMatch picked wrong candidate: %q", entry.Thumbnail)
 	}
 }
 
@@ -170,12 +170,12 @@ func TestBestMatch_DiceAcceptsCloseMatch(t *testing.T) {
 		{Thumbnail: "https://example.com/patata.jpg", Keywords: []string{"patata", "hacendado"}},
 		{Thumbnail: "https://example.com/pringles.jpg", Keywords: []string{"patatas", "fritas", "onduladas", "pringles"}},
 	}
-	url, ok := bestMatch([]string{"patata"}, index)
+	entry, ok := bestMatch([]string{"patata"}, index)
 	if !ok {
 		t.Fatal("bestMatch: expected match for patata vs patata-hacendado, got none")
 	}
-	if url != "https://example.com/patata.jpg" {
-		t.Errorf("bestMatch picked wrong candidate: %q", url)
+	if entry.Thumbnail != "https://example.com/patata.jpg" {
+		t.Errorf("bestMatch picked wrong candidate: %q", entry.Thumbnail)
 	}
 }
 
@@ -202,12 +202,12 @@ func TestBestMatch_MultiKeyword_TwoMatchesAccepted(t *testing.T) {
 	index := ProductIndex{
 		{Thumbnail: "https://example.com/patatas-cocidas.jpg", Keywords: []string{"patatas", "cocidas", "hacendado"}},
 	}
-	url, ok := bestMatch([]string{"patatas", "cocidas"}, index)
+	entry, ok := bestMatch([]string{"patatas", "cocidas"}, index)
 	if !ok {
 		t.Fatal("bestMatch: expected match when 2 of 2 local keywords are present")
 	}
-	if url != "https://example.com/patatas-cocidas.jpg" {
-		t.Errorf("bestMatch returned wrong URL: %q", url)
+	if entry.Thumbnail != "https://example.com/patatas-cocidas.jpg" {
+		t.Errorf("bestMatch returned wrong URL: %q", entry.Thumbnail)
 	}
 }
 
@@ -285,12 +285,12 @@ func TestBestMatch_PluralSingularMatch(t *testing.T) {
 	// Simulate what Run() computes for the local product.
 	localKW := keywords(translateCatalan(normalise("CACAHUET DESGREIXAT")))
 
-	url, ok := bestMatch(localKW, index)
+	entry, ok := bestMatch(localKW, index)
 	if !ok {
 		t.Fatalf("expected match for cacahuet desgreixat → Cacahuetes desgrasados; localKW=%v catalogueKW=%v", localKW, catalogueKW)
 	}
-	if url != "https://example.com/cacahuete.jpg" {
-		t.Errorf("bestMatch returned wrong URL: %q", url)
+	if entry.Thumbnail != "https://example.com/cacahuete.jpg" {
+		t.Errorf("bestMatch returned wrong URL: %q", entry.Thumbnail)
 	}
 }
 
@@ -10,7 +10,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
-	"unicode"
+
+	"basket-cost/internal/textnorm"
 )
 
 const (
@@ -133,11 +134,19 @@ func (c *MercadonaClient) FetchProductThumbnail(ctx context.Context, productID s
 	return resp.Thumbnail, nil
 }
 
-// ProductEntry holds the thumbnail URL and the keyword set for one Mercadona
-// product. The keyword set is used for fuzzy matching against local names.
+// CategorySeparator joins the levels of a Mercadona category path, e.g.
+// "Lácteos y huevos > Leche y bebidas vegetales".
+const CategorySeparator = " > "
+
+// ProductEntry holds the thumbnail URL, the category path and the keyword set
+// for one Mercadona product. The keyword set is used for fuzzy matching
+// against local names.
 type ProductEntry struct {
 	Thumbnail string
-	Keywords  []string
+	// Category is the catalogue path (top-level category and subcategory)
+	// joined with CategorySeparator.
+	Category string
+	Keywords []string
 }
 
 // ProductIndex is a list of all Mercadona products with their keyword sets.
@@ -146,23 +155,23 @@ type ProductEntry struct {
 type ProductIndex []ProductEntry
 
 // BuildProductIndex downloads all published subcategories from Mercadona,
-// collects every product's display_name and thumbnail, and returns an index
-// ready for keyword-based matching.
+// collects every product's display_name, thumbnail and category path, and
+// returns an index ready for keyword-based matching.
 //
 // Requests to subcategory endpoints are throttled to one every subcategoryDelay
 // to avoid triggering Mercadona's WAF rate-limiter, which blocks the IP for
 // ~2 minutes on bursts. The first categories request is unthrottled.
 func (c *MercadonaClient) BuildProductIndex(ctx context.Context) (ProductIndex, error) {
-	subcatIDs, err := c.fetchSubcategoryIDs(ctx)
+	subcats, err := c.fetchSubcategories(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("fetch subcategory ids: %w", err)
+		return nil, fmt.Errorf("fetch subcategories: %w", err)
 	}
 
 	ticker := time.NewTicker(subcategoryDelay)
 	defer ticker.Stop()
 
 	var index ProductIndex
-	for _, id := range subcatIDs {
+	for _, sub := range subcats {
 		// Wait for the next tick before each subcategory request.
 		select {
 		case <-ctx.Done():
@@ -170,10 +179,10 @@ func (c *MercadonaClient) BuildProductIndex(ctx context.Context) (ProductIndex,
 		case <-ticker.C:
 		}
 
-		products, err := c.fetchProductsInSubcategory(ctx, id)
+		products, err := c.fetchProductsInSubcategory(ctx, sub.ID)
 		if err != nil {
 			// Non-fatal: log and skip subcategory.
-			log.Printf("enricher: skip subcategory %d: %v", id, err)
+			log.Printf("enricher: skip subcategory %d: %v", sub.ID, err)
 			continue
 		}
 		for _, p := range products {
@@ -186,6 +195,7 @@ func (c *MercadonaClient) BuildProductIndex(ctx context.Context) (ProductIndex,
 			}
 			index = append(index, ProductEntry{
 				Thumbnail: p.Thumbnail,
+				Category:  sub.Path,
 				Keywords:  kw,
 			})
 		}
@@ -193,23 +203,46 @@ func (c *MercadonaClient) BuildProductIndex(ctx context.Context) (ProductIndex,
 	return index, nil
 }
 
-// fetchSubcategoryIDs returns the IDs of all published subcategories.
-func (c *MercadonaClient) fetchSubcategoryIDs(ctx context.Context) ([]int, error) {
+// subcategoryRef identifies a published subcategory together with its
+// human-readable path in the catalogue taxonomy.
+type subcategoryRef struct {
+	ID   int
+	Path string // "<top-level name> > <subcategory name>"
+}
+
+// fetchSubcategories returns the IDs and category paths of all published
+// subcategories.
+func (c *MercadonaClient) fetchSubcategories(ctx context.Context) ([]subcategoryRef, error) {
 	url := fmt.Sprintf("%s/categories/?lang=%s", c.baseURL, mercadonaLang)
 	var resp categoriesResponse
 	if err := c.getJSON(ctx, url, &resp); err != nil {
 		return nil, err
 	}
 
-	var ids []int
+	var subs []subcategoryRef
 	for _, top := range resp.Results {
 		for _, sub := range top.Categories {
 			if sub.Published {
-				ids = append(ids, sub.ID)
+				subs = append(subs, subcategoryRef{
+					ID:   sub.ID,
+					Path: categoryPath(top.Name, sub.Name),
+				})
 			}
 		}
 	}
-	return ids, nil
+	return subs, nil
+}
+
+// categoryPath joins the non-empty, trimmed levels of a category path with
+// CategorySeparator.
+func categoryPath(levels ...string) string {
+	parts := make([]string, 0, len(levels))
+	for _, l := range levels {
+		if l = strings.TrimSpace(l); l != "" {
+			parts = append(parts, l)
+		}
+	}
+	return strings.Join(parts, CategorySeparator)
 }
 
 // fetchProductsInSubcategory returns all products for the given subcategory ID.
@@ -252,55 +285,14 @@ func (c *MercadonaClient) getJSON(ctx context.Context, url string, v any) error
 }
 
 // normalise converts a product name to a lowercase, ASCII-only, whitespace-
-// collapsed string used as a lookup key in ProductIndex.
-// Accented characters are mapped to their base ASCII equivalent where possible.
-// Non-letter, non-digit characters (including punctuation and apostrophes) act
-// as word separators so that e.g. "d'Embolicar" → "d embolicar".
+// collapsed string used as a lookup key in ProductIndex. It shares its rules
+// with the store's full-text search index via textnorm.Normalise.
 func normalise(s string) string {
-	var b strings.Builder
-	b.Grow(len(s))
-	prevSpace := true // treat start-of-string as a boundary
-
-	for _, r := range s {
-		// Map accented → base ASCII first.
-		r = deaccent(r)
-
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			b.WriteRune(unicode.ToLower(r))
-			prevSpace = false
-		} else {
-			// Spaces, punctuation, apostrophes, etc. all become a single space separator.
-			if !prevSpace {
-				b.WriteByte(' ')
-				prevSpace = true
-			}
-		}
-	}
-
-	return strings.TrimRight(b.String(), " ")
+	return textnorm.Normalise(s)
 }
 
 // deaccent maps accented characters commonly found in Spanish/Catalan product
 // names to their unaccented ASCII equivalents.
 func deaccent(r rune) rune {
-	switch r {
-	case 'à', 'á', 'â', 'ã', 'ä', 'å', 'À', 'Á', 'Â', 'Ã', 'Ä', 'Å':
-		return 'a'
-	case 'è', 'é', 'ê', 'ë', 'È', 'É', 'Ê', 'Ë':
-		return 'e'
-	case 'ì', 'í', 'î', 'ï', 'Ì', 'Í', 'Î', 'Ï':
-		return 'i'
-	case 'ò', 'ó', 'ô', 'õ', 'ö', 'Ò', 'Ó', 'Ô', 'Õ', 'Ö':
-		return 'o'
-	case 'ù', 'ú', 'û', 'ü', 'Ù', 'Ú', 'Û', 'Ü':
-		return 'u'
-	case 'ñ', 'Ñ':
-		return 'n'
-	case 'ç', 'Ç':
-		return 'c'
-	case 'ł', 'Ł':
-		return 'l'
-	default:
-		return r
-	}
+	return textnorm.Deaccent(r)
 }
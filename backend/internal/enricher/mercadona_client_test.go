@@ -0,0 +1,67 @@
+package enricher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ---------- categoryPath ----------
+
+func TestCategoryPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels []string
+		want   string
+	}{
+		{"two levels", []string{"Lácteos y huevos", "Leche y bebidas vegetales"}, "Lácteos y huevos > Leche y bebidas vegetales"},
+		{"trims whitespace", []string{" Panadería ", " Pan de horno"}, "Panadería > Pan de horno"},
+		{"skips empty levels", []string{"Congelados", ""}, "Congelados"},
+		{"no levels", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := categoryPath(tt.levels...); got != tt.want {
+				t.Errorf("categoryPath(%q) = %q, want %q", tt.levels, got, tt.want)
+			}
+		})
+	}
+}
+
+// ---------- fetchSubcategories ----------
+
+func TestFetchSubcategories_CarriesCategoryPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results": [
+			{"id": 1, "name": "Lácteos y huevos", "categories": [
+				{"id": 71, "name": "Leche y bebidas vegetales", "published": true},
+				{"id": 72, "name": "Huevos", "published": false}
+			]},
+			{"id": 2, "name": "Panadería y pastelería", "categories": [
+				{"id": 59, "name": "Pan de horno", "published": true}
+			]}
+		]}`))
+	}))
+	defer srv.Close()
+
+	c := &MercadonaClient{http: srv.Client(), baseURL: srv.URL}
+	got, err := c.fetchSubcategories(context.Background())
+	if err != nil {
+		t.Fatalf("fetchSubcategories: %v", err)
+	}
+
+	want := []subcategoryRef{
+		{ID: 71, Path: "Lácteos y huevos > Leche y bebidas vegetales"},
+		{ID: 59, Path: "Panadería y pastelería > Pan de horno"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d subcategories, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("subcategory[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package enricher
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// spanishToCatalan is the reverse of catalanToSpanish: each normalised Spanish
+// token maps to every Catalan token that translates to it. Built once at init.
+var spanishToCatalan = buildSpanishToCatalan()
+
+func buildSpanishToCatalan() map[string][]string {
+	rev := make(map[string][]string)
+	for ca, es := range catalanToSpanish {
+		ca = normalise(ca)
+		// Only single-word pairs can be swapped token for token.
+		if es == "" || strings.Contains(es, " ") || strings.Contains(ca, " ") || ca == es {
+			continue
+		}
+		if !slices.Contains(rev[es], ca) {
+			rev[es] = append(rev[es], ca)
+		}
+	}
+	for es := range rev {
+		slices.Sort(rev[es])
+	}
+	return rev
+}
+
+// QueryExpander widens product searches across Catalan and Spanish, so that
+// "leche" finds "LLET SENCERA" and "llet" finds "LECHE ENTERA". It only uses
+// the built-in dictionary: query text never leaves the server and search never
+// waits on a translation API. Words the dictionary lacks still match through
+// product names, which the Enricher translates offline.
+// It is safe for concurrent use.
+type QueryExpander struct{}
+
+// NewQueryExpander returns a QueryExpander backed by the built-in dictionary.
+func NewQueryExpander() *QueryExpander {
+	return &QueryExpander{}
+}
+
+// Synonyms returns, for each normalised token of query, the tokens it may also
+// match in the other language. Tokens without a known equivalent are omitted;
+// the result is nil when no token has one.
+func (q *QueryExpander) Synonyms(_ context.Context, query string) map[string][]string {
+	var out map[string][]string
+	add := func(tok, alt string) {
+		if alt == "" || alt == tok {
+			return
+		}
+		if out == nil {
+			out = make(map[string][]string)
+		}
+		if !slices.Contains(out[tok], alt) {
+			out[tok] = append(out[tok], alt)
+		}
+	}
+
+	for _, tok := range strings.Fields(normalise(query)) {
+		es, isCatalan := catalanToSpanish[tok]
+		if isCatalan {
+			// Multi-word translations ("a la plancha") cannot stand in for a
+			// single token; keep only single-word ones.
+			if !strings.Contains(es, " ") {
+				add(tok, es)
+			}
+		}
+		for _, ca := range spanishToCatalan[tok] {
+			add(tok, ca)
+		}
+	}
+	return out
+}
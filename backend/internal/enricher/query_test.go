@@ -0,0 +1,35 @@
+package enricher
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// ---------- QueryExpander ----------
+
+func TestQueryExpander_CatalanToSpanish(t *testing.T) {
+	qe := NewQueryExpander()
+	got := qe.Synonyms(context.Background(), "Llet sencera")
+	if !slices.Contains(got["llet"], "leche") {
+		t.Errorf("want llet → leche, got %v", got)
+	}
+}
+
+func TestQueryExpander_SpanishToCatalan(t *testing.T) {
+	qe := NewQueryExpander()
+	got := qe.Synonyms(context.Background(), "pollo")
+	for _, want := range []string{"pollastre", "pollastr"} {
+		if !slices.Contains(got["pollo"], want) {
+			t.Errorf("want pollo → %s, got %v", want, got["pollo"])
+		}
+	}
+}
+
+func TestQueryExpander_UnknownToken_Omitted(t *testing.T) {
+	qe := NewQueryExpander()
+	got := qe.Synonyms(context.Background(), "ratafia llet")
+	if _, ok := got["ratafia"]; ok || !slices.Contains(got["llet"], "leche") {
+		t.Errorf("want only llet expanded, got %v", got)
+	}
+}
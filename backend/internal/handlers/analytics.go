@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"basket-cost/internal/store"
+)
+
+// maxSeriesPoints caps the length of a spending time series so that a daily
+// series over many years cannot produce an unbounded response.
+const maxSeriesPoints = 1000
+
+// defaultSeriesPoints is the number of periods returned when no 'from' date
+// is given: 30 days, 12 weeks or 12 months.
+var defaultSeriesPoints = map[store.Granularity]int{
+	store.GranularityDay:   30,
+	store.GranularityWeek:  12,
+	store.GranularityMonth: 12,
+}
+
+// parseDateRange reads the 'from' and 'to' query parameters (YYYY-MM-DD).
+// 'to' defaults to today; 'from' defaults to defaultFrom(to).
+// Returns an error message suitable for a 400 response.
+func parseDateRange(q url.Values, defaultFrom func(to time.Time) time.Time) (from, to time.Time, err error) {
+	to = time.Now().UTC().Truncate(24 * time.Hour)
+	if raw := q.Get("to"); raw != "" {
+		if to, err = time.Parse(time.DateOnly, raw); err != nil {
+			return from, to, fmt.Errorf("'to' must be a date in YYYY-MM-DD format")
+		}
+	}
+	from = defaultFrom(to)
+	if raw := q.Get("from"); raw != "" {
+		if from, err = time.Parse(time.DateOnly, raw); err != nil {
+			return from, to, fmt.Errorf("'from' must be a date in YYYY-MM-DD format")
+		}
+	}
+	if from.After(to) {
+		return from, to, fmt.Errorf("'from' must not be after 'to'")
+	}
+	return from, to, nil
+}
+
+// SpendingHandler handles GET /api/analytics/spending and returns the
+// household's spend per day, week or month, broken down by store.
+//
+//	from         first day of the range (YYYY-MM-DD); default depends on granularity
+//	to           last day of the range (YYYY-MM-DD); default today
+//	granularity  day | week | month (default month)
+func (h *Handlers) SpendingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	g := store.Granularity(q.Get("granularity"))
+	if g == "" {
+		g = store.GranularityMonth
+	}
+	points, ok := defaultSeriesPoints[g]
+	if !ok {
+		http.Error(w, "Bad request: granularity must be day, week or month", http.StatusBadRequest)
+		return
+	}
+	from, to, err := parseDateRange(q, func(to time.Time) time.Time {
+		// Go back whole periods so the first bucket is complete.
+		start := store.PeriodStart(to, g)
+		switch g {
+		case store.GranularityDay:
+			return start.AddDate(0, 0, 1-points)
+		case store.GranularityWeek:
+			return start.AddDate(0, 0, -7*(points-1))
+		default:
+			return start.AddDate(0, 1-points, 0)
+		}
+	})
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if g == store.GranularityDay && to.Sub(from) >= maxSeriesPoints*24*time.Hour {
+		http.Error(w, fmt.Sprintf("Bad request: a daily series is limited to %d days", maxSeriesPoints), http.StatusBadRequest)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	series, err := h.store.GetSpendingSeries(userID, from, to, g)
+	if err != nil {
+		log.Printf("handlers: get spending series: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(series); err != nil {
+		log.Printf("handlers: encode spending response: %v", err)
+	}
+}
+
+// breakdownDefaultDays is the length of the range used by BreakdownHandler
+// when no 'from' date is given.
+const breakdownDefaultDays = 30
+
+// BreakdownHandler handles GET /api/analytics/breakdown and returns the
+// household's spend by category (with subcategories) and by store for the
+// range [from, to], each compared with the previous period of the same length.
+// 'to' defaults to today and 'from' to 30 days earlier. When the household
+// has budgets, their status for the month of 'to' is included.
+func (h *Handlers) BreakdownHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, err := parseDateRange(r.URL.Query(), func(to time.Time) time.Time {
+		return to.AddDate(0, 0, 1-breakdownDefaultDays)
+	})
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	breakdown, err := h.store.GetSpendingBreakdown(userID, from, to)
+	if err != nil {
+		log.Printf("handlers: get spending breakdown: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if userID != 0 {
+		report, err := h.store.GetBudgetReport(userID, to)
+		if err != nil {
+			log.Printf("handlers: get budget report: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if len(report.Budgets) > 0 {
+			breakdown.Budget = &report
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(breakdown); err != nil {
+		log.Printf("handlers: encode breakdown response: %v", err)
+	}
+}
+
+// MembersHandler handles GET /api/analytics/members and returns the
+// household's spend and purchase counts for the range [from, to], split by
+// the member who bought. 'to' defaults to today and 'from' to 30 days earlier.
+func (h *Handlers) MembersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	from, to, err := parseDateRange(r.URL.Query(), func(to time.Time) time.Time {
+		return to.AddDate(0, 0, 1-breakdownDefaultDays)
+	})
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	spending, err := h.store.GetMemberSpending(userID, from, to)
+	if err != nil {
+		log.Printf("handlers: get member spending: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(spending); err != nil {
+		log.Printf("handlers: encode member spending response: %v", err)
+	}
+}
+
+// PatternsHandler handles GET /api/analytics/patterns and returns the
+// household's tickets in the range [from, to] aggregated by weekday, day of
+// month, part of the month and store. 'to' defaults to today and 'from' to
+// one year earlier, so that every day of the month is covered several times.
+func (h *Handlers) PatternsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	from, to, err := parseDateRange(r.URL.Query(), func(to time.Time) time.Time {
+		return to.AddDate(-1, 0, 1)
+	})
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	patterns, err := h.store.GetShoppingPatterns(userID, from, to)
+	if err != nil {
+		log.Printf("handlers: get shopping patterns: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(patterns); err != nil {
+		log.Printf("handlers: encode shopping patterns response: %v", err)
+	}
+}
+
+// reIPCRegion and reIPCSubindex validate the IPC series accepted by
+// InflationHandler.
+var (
+	reIPCRegion   = regexp.MustCompile(`^[A-Z]{2}$`)
+	reIPCSubindex = regexp.MustCompile(`^(general|\d{2,5})$`)
+)
+
+// InflationHandler handles GET /api/analytics/inflation and returns the
+// household's personal basket index next to the official IPC index.
+//
+//	from         first day of the range (YYYY-MM-DD); default the first purchase
+//	to           last day of the range (YYYY-MM-DD); default today
+//	granularity  month | year (default year)
+//	region       IPC region: "ES" or an ISO 3166-2:ES code (default CT)
+//	subindex     IPC subindex: "general" or an ECOICOP code (default 01, food)
+//
+// When the requested monthly IPC series has not been imported the comparison
+// falls back to the yearly headline rates; the response names the series used.
+func (h *Handlers) InflationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	g := store.Granularity(q.Get("granularity"))
+	if g == "" {
+		g = store.GranularityYear
+	}
+	if g != store.GranularityMonth && g != store.GranularityYear {
+		http.Error(w, "Bad request: granularity must be month or year", http.StatusBadRequest)
+		return
+	}
+	from, to, err := parseDateRange(q, func(time.Time) time.Time { return time.Time{} })
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	series := store.FoodIPC
+	if raw := q.Get("region"); raw != "" {
+		if !reIPCRegion.MatchString(raw) {
+			http.Error(w, "Bad request: region must be a two-letter code such as ES or CT", http.StatusBadRequest)
+			return
+		}
+		series.Region = raw
+	}
+	if raw := q.Get("subindex"); raw != "" {
+		if !reIPCSubindex.MatchString(raw) {
+			http.Error(w, "Bad request: subindex must be 'general' or an ECOICOP code such as 01", http.StatusBadRequest)
+			return
+		}
+		series.Subindex = raw
+	}
+
+	userID := UserIDFromContext(r)
+	inflation, err := h.store.GetPersonalInflation(userID, from, to, g, series)
+	if err != nil {
+		log.Printf("handlers: get personal inflation: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(inflation); err != nil {
+		log.Printf("handlers: encode inflation response: %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// --- SpendingHandler ---
+
+func TestSpendingHandler_MethodNotAllowed(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/analytics/spending", nil)
+	w := httptest.NewRecorder()
+	h.SpendingHandler(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestSpendingHandler_InvalidParams_ReturnBadRequest(t *testing.T) {
+	h := newHandlers(t)
+	for _, qs := range []string{
+		"granularity=year",
+		"from=2025-13-01",
+		"from=2025-06-01&to=2025-01-01",
+		"granularity=day&from=2020-01-01&to=2025-01-01",
+	} {
+		t.Run(qs, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/analytics/spending?"+qs, nil)
+			w := httptest.NewRecorder()
+			h.SpendingHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestSpendingHandler_ResponseShape(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t) // one 0.79 purchase on 2025-01-10
+	req := withUserID(httptest.NewRequest(http.MethodGet,
+		"/api/analytics/spending?from=2025-01-01&to=2025-02-28&granularity=month", nil), uid)
+	w := httptest.NewRecorder()
+	h.SpendingHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.SpendingSeries
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Granularity != "month" || len(resp.Points) != 2 {
+		t.Fatalf("want 2 monthly points, got %+v", resp)
+	}
+	if resp.Points[0].Total != 0.79 || resp.Total != 0.79 {
+		t.Errorf("want 0.79 in January, got %+v", resp)
+	}
+}
+
+func TestSpendingHandler_DefaultRange_TwelveMonths(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/analytics/spending", nil)
+	w := httptest.NewRecorder()
+	h.SpendingHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp models.SpendingSeries
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Points) != 12 {
+		t.Errorf("want 12 monthly points by default, got %d", len(resp.Points))
+	}
+}
+
+// --- BreakdownHandler ---
+
+func TestBreakdownHandler_InvalidRange_ReturnsBadRequest(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/analytics/breakdown?from=2025-06-01&to=2025-01-01", nil)
+	w := httptest.NewRecorder()
+	h.BreakdownHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestBreakdownHandler_ResponseShape(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t) // one 0.79 purchase at Mercadona on 2025-01-10
+	req := withUserID(httptest.NewRequest(http.MethodGet,
+		"/api/analytics/breakdown?from=2025-01-01&to=2025-01-31", nil), uid)
+	w := httptest.NewRecorder()
+	h.BreakdownHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.SpendingBreakdown
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.PreviousFrom != "2024-12-01" || resp.PreviousTo != "2024-12-31" {
+		t.Errorf("previous period: got %s..%s", resp.PreviousFrom, resp.PreviousTo)
+	}
+	if len(resp.ByStore) != 1 || resp.ByStore[0].Name != "Mercadona" || resp.ByStore[0].Total != 0.79 {
+		t.Errorf("unexpected byStore: %+v", resp.ByStore)
+	}
+}
+
+// --- InflationHandler ---
+
+func TestInflationHandler_InvalidParams_ReturnBadRequest(t *testing.T) {
+	h := newHandlers(t)
+	for _, qs := range []string{
+		"granularity=week",
+		"region=cataluna",
+		"subindex=food",
+	} {
+		t.Run(qs, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/analytics/inflation?"+qs, nil)
+			w := httptest.NewRecorder()
+			h.InflationHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestInflationHandler_DefaultsToYearlyFromFirstPurchase(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t) // one 0.79 purchase on 2025-01-10
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics/inflation?to=2025-12-31", nil), uid)
+	w := httptest.NewRecorder()
+	h.InflationHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.InflationComparison
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Granularity != "year" || resp.From != "2025-01-01" || len(resp.Points) != 1 {
+		t.Fatalf("want one yearly point for 2025, got %+v", resp)
+	}
+	if resp.Points[0].PersonalIndex != 100 {
+		t.Errorf("want base index 100, got %+v", resp.Points[0])
+	}
+}
+
+// --- MembersHandler ---
+
+func TestMembersHandler_RequiresAuth(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/analytics/members", nil)
+	w := httptest.NewRecorder()
+	h.MembersHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestMembersHandler_SplitsSpendByMember(t *testing.T) {
+	h, s, uid, _ := newHandlersWithUser(t) // 0.79 at Mercadona on 2025-01-10
+	partner, err := s.CreateUser("partner", "", "$2a$12$fakehashfortesting000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	hid, err := s.CreateHousehold(uid)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := s.AddUserToHousehold(partner, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics/members?from=2025-01-01&to=2025-01-31", nil), partner)
+	w := httptest.NewRecorder()
+	h.MembersHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got models.MemberSpending
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Total != 0.79 || len(got.Members) != 2 || got.Members[0].UserID != uid || got.Members[0].SharePercent != 100 ||
+		got.Members[1].Spent != 0 {
+		t.Errorf("unexpected member spending %+v", got)
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics/members?from=2025-02-01&to=2025-01-01", nil), uid)
+	w = httptest.NewRecorder()
+	h.MembersHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("inverted range: expected 400, got %d", w.Code)
+	}
+}
+
+// --- PatternsHandler ---
+
+func TestPatternsHandler_RequiresAuth(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/analytics/patterns", nil)
+	w := httptest.NewRecorder()
+	h.PatternsHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestPatternsHandler_AggregatesTickets(t *testing.T) {
+	h, s, uid, _ := newHandlersWithUser(t)
+	for _, day := range []time.Time{
+		time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),  // Monday
+		time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC), // Saturday
+	} {
+		header := models.TicketHeader{Store: "Mercadona", Date: day, Total: 12.5, Lines: 1}
+		entries := []models.PriceRecordEntry{{Name: "ACEITE", Record: models.PriceRecord{Date: day, Price: 12.5, Quantity: 1, Store: "Mercadona"}}}
+		if err := s.UpsertTicket(uid, header, entries); err != nil {
+			t.Fatalf("UpsertTicket: %v", err)
+		}
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics/patterns?from=2025-01-01&to=2025-01-31", nil), uid)
+	w := httptest.NewRecorder()
+	h.PatternsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got models.ShoppingPatterns
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Tickets != 2 || got.AverageBasket != 12.5 || got.ByWeekday[0].Tickets != 1 || got.ByWeekday[5].Tickets != 1 ||
+		got.ByMonthPart[0].Tickets != 1 || got.ByMonthPart[2].Tickets != 1 || len(got.ByStore) != 1 {
+		t.Errorf("unexpected patterns %+v", got)
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics/patterns?from=nope", nil), uid)
+	w = httptest.NewRecorder()
+	h.PatternsHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad date: expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// BasketReplayHandler handles GET /api/basket/replay and returns what a fixed
+// basket of products would have cost at the end of each period, with every
+// product at the household's last known price.
+//
+//	items        comma-separated productId[:quantity] (quantity defaults to 1)
+//	list         ID of a shopping list to use as the basket instead of items
+//	from         first day of the range (YYYY-MM-DD); default the basket's first price
+//	to           last day of the range (YYYY-MM-DD); default today
+//	granularity  day | week | month (default week)
+//
+// A daily series needs an explicit 'from' and is limited to 1000 days.
+func (h *Handlers) BasketReplayHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	g := store.Granularity(q.Get("granularity"))
+	if g == "" {
+		g = store.GranularityWeek
+	}
+	if _, ok := defaultSeriesPoints[g]; !ok {
+		http.Error(w, "Bad request: granularity must be day, week or month", http.StatusBadRequest)
+		return
+	}
+	from, to, err := parseDateRange(q, func(time.Time) time.Time { return time.Time{} })
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if g == store.GranularityDay && (from.IsZero() || to.Sub(from) >= maxSeriesPoints*24*time.Hour) {
+		http.Error(w, fmt.Sprintf("Bad request: a daily series needs 'from' and is limited to %d days", maxSeriesPoints), http.StatusBadRequest)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	var items []models.BasketItem
+	switch rawItems, rawList := q.Get("items"), q.Get("list"); {
+	case rawItems != "" && rawList != "":
+		http.Error(w, "Bad request: pass either items or list, not both", http.StatusBadRequest)
+		return
+	case rawList != "":
+		listID, err := strconv.ParseInt(rawList, 10, 64)
+		if err != nil || listID <= 0 {
+			http.Error(w, "Bad request: invalid list ID", http.StatusBadRequest)
+			return
+		}
+		if userID == 0 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		list, err := h.store.GetShoppingList(userID, listID)
+		if err != nil {
+			log.Printf("handlers: get shopping list %d: %v", listID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if list == nil {
+			http.Error(w, "Shopping list not found", http.StatusNotFound)
+			return
+		}
+		for _, it := range list.Items {
+			items = append(items, models.BasketItem{ProductID: it.ProductID, Quantity: it.Quantity})
+		}
+	case rawItems != "":
+		if items, err = parseBasketItems(rawItems); err != nil {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Bad request: items or list is required", http.StatusBadRequest)
+		return
+	}
+	if len(items) > maxAnalyticsLimit {
+		http.Error(w, fmt.Sprintf("Bad request: a basket holds at most %d products", maxAnalyticsLimit), http.StatusBadRequest)
+		return
+	}
+
+	replay, err := h.store.GetBasketReplay(userID, items, from, to, g)
+	if errors.Is(err, store.ErrInvalidBasket) {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("handlers: get basket replay: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(replay); err != nil {
+		log.Printf("handlers: encode basket replay response: %v", err)
+	}
+}
+
+// parseBasketItems parses "id[:qty],id[:qty],…" into basket items.
+func parseBasketItems(raw string) ([]models.BasketItem, error) {
+	var items []models.BasketItem
+	for _, part := range strings.Split(raw, ",") {
+		id, rawQty, hasQty := strings.Cut(strings.TrimSpace(part), ":")
+		if id == "" {
+			return nil, fmt.Errorf("items must be productId[:quantity] separated by commas")
+		}
+		item := models.BasketItem{ProductID: id, Quantity: 1}
+		if hasQty {
+			qty, err := strconv.ParseFloat(rawQty, 64)
+			if err != nil || qty <= 0 {
+				return nil, fmt.Errorf("quantity of %q must be a positive number", id)
+			}
+			item.Quantity = qty
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
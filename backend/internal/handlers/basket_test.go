@@ -0,0 +1,78 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"basket-cost/internal/models"
+)
+
+func TestBasketReplayHandler_InvalidParams_ReturnBadRequest(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	for _, qs := range []string{
+		"",
+		"items=" + productID + "&list=1",
+		"items=" + productID + ":0",
+		"items=" + productID + ":x",
+		"items=,",
+		"items=nope",
+		"items=" + productID + "&granularity=year",
+		"items=" + productID + "&granularity=day",
+		"list=abc",
+	} {
+		t.Run(qs, func(t *testing.T) {
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/basket/replay?"+qs, nil), uid)
+			w := httptest.NewRecorder()
+			h.BasketReplayHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBasketReplayHandler_FromItemsAndList(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t) // 0.79 on 2025-01-10
+
+	decode := func(qs string) models.BasketReplay {
+		t.Helper()
+		req := withUserID(httptest.NewRequest(http.MethodGet, "/api/basket/replay?"+qs, nil), uid)
+		w := httptest.NewRecorder()
+		h.BasketReplayHandler(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var replay models.BasketReplay
+		if err := json.NewDecoder(w.Body).Decode(&replay); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return replay
+	}
+
+	replay := decode("items=" + productID + ":2&to=2025-01-31&granularity=month")
+	if replay.Granularity != "month" || len(replay.Points) != 1 || replay.Points[0].Cost != 1.58 {
+		t.Errorf("unexpected replay %+v", replay)
+	}
+
+	listID, err := s.CreateShoppingList(uid, "Semana")
+	if err != nil {
+		t.Fatalf("CreateShoppingList: %v", err)
+	}
+	if err := s.SetShoppingListItem(uid, listID, productID, 3); err != nil {
+		t.Fatalf("SetShoppingListItem: %v", err)
+	}
+	replay = decode(fmt.Sprintf("list=%d&from=2025-01-06&to=2025-01-19", listID))
+	if replay.Granularity != "week" || len(replay.Points) != 2 || replay.Points[1].Cost != 2.37 {
+		t.Errorf("unexpected replay from list %+v", replay)
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/basket/replay?list=9999", nil), uid)
+	w := httptest.NewRecorder()
+	h.BasketReplayHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown list: expected 404, got %d", w.Code)
+	}
+}
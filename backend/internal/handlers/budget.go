@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"basket-cost/internal/store"
+)
+
+type budgetRequest struct {
+	Category     string   `json:"category"`
+	MonthlyLimit *float64 `json:"monthlyLimit"`
+}
+
+// BudgetsHandler handles the monthly budgets of the authenticated user's
+// household:
+//
+//	GET    /api/budgets       budgets with this month's spend and projection (?date=YYYY-MM-DD)
+//	PUT    /api/budgets       set {"category", "monthlyLimit"}; an empty category is the overall budget
+//	DELETE /api/budgets/{id}  delete a budget
+//
+// Imports that take a month's spend past 80% or 100% of a budget raise a
+// budget alert for every member of the household.
+func (h *Handlers) BudgetsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/budgets"), "/")
+	if rest != "" {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		budgetID, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil || budgetID <= 0 {
+			http.Error(w, "Bad request: invalid budget ID", http.StatusBadRequest)
+			return
+		}
+		err = h.store.DeleteBudget(userID, budgetID)
+		if errors.Is(err, store.ErrBudgetNotFound) {
+			http.Error(w, "Budget not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("handlers: delete budget %d: %v", budgetID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		asOf := time.Now().UTC()
+		if raw := r.URL.Query().Get("date"); raw != "" {
+			var err error
+			if asOf, err = time.Parse(time.DateOnly, raw); err != nil {
+				http.Error(w, "Bad request: 'date' must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+		}
+		report, err := h.store.GetBudgetReport(userID, asOf)
+		if err != nil {
+			log.Printf("handlers: get budget report: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			log.Printf("handlers: encode budget report response: %v", err)
+		}
+	case http.MethodPut:
+		var req budgetRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.MonthlyLimit == nil {
+			http.Error(w, "Bad request: monthlyLimit is required", http.StatusBadRequest)
+			return
+		}
+		budget, err := h.store.SetBudget(userID, strings.TrimSpace(req.Category), *req.MonthlyLimit)
+		if errors.Is(err, store.ErrInvalidBudget) {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("handlers: set budget: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(budget); err != nil {
+			log.Printf("handlers: encode budget response: %v", err)
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"basket-cost/internal/models"
+)
+
+func TestBudgetsHandler_RequiresAuth(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/budgets", nil)
+	w := httptest.NewRecorder()
+	h.BudgetsHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestBudgetsHandler_SetReportDelete(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t) // 0.79 at Mercadona on 2025-01-10
+
+	for _, body := range []map[string]any{{"category": ""}, {"monthlyLimit": -5}, {"category": "Lacteos", "monthlyLimit": 50}} {
+		req := withUserID(httptest.NewRequest(http.MethodPut, "/api/budgets", jsonBody(t, body)), uid)
+		w := httptest.NewRecorder()
+		h.BudgetsHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodPut, "/api/budgets", jsonBody(t, map[string]any{"monthlyLimit": 0.9})), uid)
+	w := httptest.NewRecorder()
+	h.BudgetsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("set: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var budget models.Budget
+	if err := json.NewDecoder(w.Body).Decode(&budget); err != nil {
+		t.Fatalf("decode budget: %v", err)
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/budgets?date=2025-01-10", nil), uid)
+	w = httptest.NewRecorder()
+	h.BudgetsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("report: expected 200, got %d", w.Code)
+	}
+	var report models.BudgetReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if len(report.Budgets) != 1 || report.Budgets[0].Spent != 0.79 || report.Budgets[0].Status != "warning" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	// The analytics breakdown carries the same report.
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics/breakdown?from=2025-01-01&to=2025-01-10", nil), uid)
+	w = httptest.NewRecorder()
+	h.BreakdownHandler(w, req)
+	var breakdown models.SpendingBreakdown
+	if err := json.NewDecoder(w.Body).Decode(&breakdown); err != nil {
+		t.Fatalf("decode breakdown: %v", err)
+	}
+	if breakdown.Budget == nil || len(breakdown.Budget.Budgets) != 1 || breakdown.Budget.Month != "2025-01" {
+		t.Errorf("unexpected breakdown budget %+v", breakdown.Budget)
+	}
+
+	// So do the analytics rankings, for the month of 'to'.
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics?to=2025-01-10", nil), uid)
+	w = httptest.NewRecorder()
+	h.AnalyticsHandler(w, req)
+	var analytics struct {
+		Budget *models.BudgetReport `json:"budget"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&analytics); err != nil {
+		t.Fatalf("decode analytics: %v", err)
+	}
+	if analytics.Budget == nil || len(analytics.Budget.Budgets) != 1 || analytics.Budget.Month != "2025-01" {
+		t.Errorf("unexpected analytics budget %+v", analytics.Budget)
+	}
+
+	for _, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req = withUserID(httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/budgets/%d", budget.ID), nil), uid)
+		w = httptest.NewRecorder()
+		h.BudgetsHandler(w, req)
+		if w.Code != want {
+			t.Errorf("delete: expected %d, got %d", want, w.Code)
+		}
+	}
+}
+
+func TestBudgetsHandler_InvalidDate_ReturnsBadRequest(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t)
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/budgets?date=march", nil), uid)
+	w := httptest.NewRecorder()
+	h.BudgetsHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"basket-cost/internal/store"
+)
+
+type productGroupRequest struct {
+	Name       string   `json:"name"`
+	ProductIDs []string `json:"productIds"`
+	ProductID  string   `json:"productId"`
+}
+
+// ProductGroupsHandler handles the groups of equivalent products shared by
+// the authenticated user's household:
+//
+//	GET    /api/product-groups                          all groups with their products
+//	POST   /api/product-groups                          link {"name", "productIds"} (two or more)
+//	GET    /api/product-groups/{id}                     one group
+//	DELETE /api/product-groups/{id}                     unlink every product
+//	POST   /api/product-groups/{id}/products            link {"productId"}
+//	DELETE /api/product-groups/{id}/products/{product}  unlink a product
+//
+// A product belongs to at most one group per household. Groups feed the
+// cross-store comparison at /api/stores/compare.
+func (h *Handlers) ProductGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/product-groups"), "/")
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			groups, err := h.store.GetProductGroups(userID)
+			if err != nil {
+				log.Printf("handlers: get product groups: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(groups); err != nil {
+				log.Printf("handlers: encode product groups response: %v", err)
+			}
+		case http.MethodPost:
+			var req productGroupRequest
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+			name, ok := validName(w, req.Name)
+			if !ok {
+				return
+			}
+			groupID, err := h.store.CreateProductGroup(userID, name, req.ProductIDs)
+			if errors.Is(err, store.ErrInvalidProductGroup) {
+				http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				log.Printf("handlers: create product group: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			h.writeProductGroup(w, userID, groupID, http.StatusCreated)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	rawID, sub, _ := strings.Cut(rest, "/")
+	groupID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || groupID <= 0 {
+		http.Error(w, "Bad request: invalid group ID", http.StatusBadRequest)
+		return
+	}
+	section, productID, hasProduct := strings.Cut(sub, "/")
+
+	var opErr error
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		// Nothing to change; the group is written below.
+	case sub == "" && r.Method == http.MethodDelete:
+		opErr = h.store.DeleteProductGroup(userID, groupID)
+	case sub == "products" && r.Method == http.MethodPost:
+		var req productGroupRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.ProductID == "" {
+			http.Error(w, "Bad request: productId is required", http.StatusBadRequest)
+			return
+		}
+		opErr = h.store.AddProductGroupMember(userID, groupID, req.ProductID)
+	case section == "products" && hasProduct && productID != "" && r.Method == http.MethodDelete:
+		opErr = h.store.RemoveProductGroupMember(userID, groupID, productID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case errors.Is(opErr, store.ErrProductGroupNotFound):
+		http.Error(w, "Product group not found", http.StatusNotFound)
+		return
+	case errors.Is(opErr, store.ErrInvalidProductGroup):
+		http.Error(w, "Bad request: "+opErr.Error(), http.StatusBadRequest)
+		return
+	case opErr != nil:
+		log.Printf("handlers: %s product group %d: %v", r.Method, groupID, opErr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.writeProductGroup(w, userID, groupID, http.StatusOK)
+}
+
+// writeProductGroup responds with groupID as JSON, or 404 if it is gone.
+func (h *Handlers) writeProductGroup(w http.ResponseWriter, userID, groupID int64, status int) {
+	group, err := h.store.GetProductGroup(userID, groupID)
+	if err != nil {
+		log.Printf("handlers: get product group %d: %v", groupID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if group == nil {
+		http.Error(w, "Product group not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(group); err != nil {
+		log.Printf("handlers: encode product group response: %v", err)
+	}
+}
+
+// StoreComparisonHandler handles GET /api/stores/compare. For each product
+// group it returns the latest price at every store, the cheapest store and
+// the saving per weekly basket; it also ranks stores by what the household's
+// typical weekly basket (the last 90 days of purchases) would cost there.
+//
+//	date  day to compare on (YYYY-MM-DD, default today)
+func (h *Handlers) StoreComparisonHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	asOf := time.Now().UTC()
+	if raw := r.URL.Query().Get("date"); raw != "" {
+		var err error
+		if asOf, err = time.Parse(time.DateOnly, raw); err != nil {
+			http.Error(w, "Bad request: 'date' must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID := UserIDFromContext(r)
+	comparison, err := h.store.GetStoreComparison(userID, asOf)
+	if err != nil {
+		log.Printf("handlers: get store comparison: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(comparison); err != nil {
+		log.Printf("handlers: encode store comparison response: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+func TestProductGroupsHandler_RequiresAuth(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/product-groups", nil)
+	w := httptest.NewRecorder()
+	h.ProductGroupsHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestProductGroupsHandler_LinkCompareUnlink(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t) // 0.79 at Mercadona on 2025-01-10
+	entries := []models.PriceRecordEntry{{
+		Name:   "LECHE ENTERA LIDL 1L",
+		Record: models.PriceRecord{Date: time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC), Price: 0.69, Store: "Lidl"},
+	}}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	body := jsonBody(t, map[string]any{"name": "Leche", "productIds": []string{productID}})
+	req := withUserID(httptest.NewRequest(http.MethodPost, "/api/product-groups", body), uid)
+	w := httptest.NewRecorder()
+	h.ProductGroupsHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("single product: expected 400, got %d", w.Code)
+	}
+
+	body = jsonBody(t, map[string]any{"name": "Leche", "productIds": []string{productID, "leche-entera-lidl-1l"}})
+	req = withUserID(httptest.NewRequest(http.MethodPost, "/api/product-groups", body), uid)
+	w = httptest.NewRecorder()
+	h.ProductGroupsHandler(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var group models.ProductGroup
+	if err := json.NewDecoder(w.Body).Decode(&group); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(group.Products) != 2 {
+		t.Fatalf("want 2 linked products, got %+v", group)
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/stores/compare?date=2025-01-31", nil), uid)
+	w = httptest.NewRecorder()
+	h.StoreComparisonHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("compare: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var comparison models.StoreComparison
+	if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(comparison.Groups) != 1 || comparison.Groups[0].CheapestStore != "Lidl" || comparison.Groups[0].UnitSaving != 0.10 {
+		t.Errorf("unexpected comparison %+v", comparison)
+	}
+	if len(comparison.Stores) != 2 || comparison.Stores[0].Store != "Lidl" {
+		t.Errorf("want Lidl ranked first, got %+v", comparison.Stores)
+	}
+
+	path := fmt.Sprintf("/api/product-groups/%d/products/%s", group.ID, productID)
+	req = withUserID(httptest.NewRequest(http.MethodDelete, path, nil), uid)
+	w = httptest.NewRecorder()
+	h.ProductGroupsHandler(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unlink: expected 204, got %d", w.Code)
+	}
+	req = withUserID(httptest.NewRequest(http.MethodDelete, path, nil), uid)
+	w = httptest.NewRecorder()
+	h.ProductGroupsHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second unlink: expected 404, got %d", w.Code)
+	}
+}
+
+func TestStoreComparisonHandler_InvalidDate_ReturnsBadRequest(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/stores/compare?date=yesterday", nil)
+	w := httptest.NewRecorder()
+	h.StoreComparisonHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"basket-cost/internal/digest"
+	"basket-cost/internal/store"
+)
+
+// DigestHandler handles GET /api/digest and returns the household's summary
+// of the last complete week or month.
+//
+//	period  week | month (default week)
+//	date    reference day (YYYY-MM-DD); the digest covers the last complete
+//	        period before it (default today)
+//	format  json | html | text (default json)
+func (h *Handlers) DigestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	g := store.Granularity(q.Get("period"))
+	switch g {
+	case "":
+		g = store.GranularityWeek
+	case store.GranularityWeek, store.GranularityMonth:
+	default:
+		http.Error(w, "Bad request: period must be week or month", http.StatusBadRequest)
+		return
+	}
+	ref := time.Now().UTC()
+	if raw := q.Get("date"); raw != "" {
+		var err error
+		if ref, err = time.Parse(time.DateOnly, raw); err != nil {
+			http.Error(w, "Bad request: 'date' must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+	format := q.Get("format")
+	switch format {
+	case "", "json", "html", "text":
+	default:
+		http.Error(w, "Bad request: format must be json, html or text", http.StatusBadRequest)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	d, err := h.store.GetDigest(userID, g, ref)
+	if err != nil {
+		log.Printf("handlers: get digest: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "" || format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(d); err != nil {
+			log.Printf("handlers: encode digest response: %v", err)
+		}
+		return
+	}
+
+	rendered, err := digest.Render(d)
+	if err != nil {
+		log.Printf("handlers: render digest: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	body := rendered.Text
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if format == "html" {
+		body = rendered.HTML
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if _, err := io.WriteString(w, body); err != nil {
+		log.Printf("handlers: write digest response: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"basket-cost/internal/models"
+)
+
+func TestDigestHandler_MethodNotAllowed(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/digest", nil)
+	w := httptest.NewRecorder()
+	h.DigestHandler(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestDigestHandler_InvalidParams_ReturnBadRequest(t *testing.T) {
+	h := newHandlers(t)
+	for _, qs := range []string{"period=day", "date=12/03/2025", "format=pdf"} {
+		t.Run(qs, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/digest?"+qs, nil)
+			w := httptest.NewRecorder()
+			h.DigestHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestDigestHandler_MonthlyJSON(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t) // 0.79 on 2025-01-10
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/digest?period=month&date=2025-02-15", nil), uid)
+	w := httptest.NewRecorder()
+	h.DigestHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var d models.Digest
+	if err := json.NewDecoder(w.Body).Decode(&d); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if d.From != "2025-01-01" || d.To != "2025-01-31" || d.TotalSpent != 0.79 || len(d.NewProducts) != 1 {
+		t.Errorf("unexpected digest %+v", d)
+	}
+}
+
+func TestDigestHandler_HTMLAndText(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t)
+	for format, contentType := range map[string]string{"html": "text/html", "text": "text/plain"} {
+		t.Run(format, func(t *testing.T) {
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/digest?period=month&date=2025-02-15&format="+format, nil), uid)
+			w := httptest.NewRecorder()
+			h.DigestHandler(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, contentType) {
+				t.Errorf("Content-Type: want %s, got %s", contentType, got)
+			}
+			if !strings.Contains(w.Body.String(), "0,79 €") {
+				t.Errorf("body lacks the month's spend:\n%s", w.Body.String())
+			}
+		})
+	}
+}
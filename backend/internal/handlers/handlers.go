@@ -4,11 +4,13 @@ package handlers
 import (
 	"basket-cost/internal/auth"
 	"basket-cost/internal/models"
+	"basket-cost/internal/notify"
 	"basket-cost/internal/store"
 	"basket-cost/internal/ticket"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -110,10 +112,24 @@ type EnrichScheduler interface {
 	FetchProductThumbnail(ctx context.Context, productID string) (string, error)
 }
 
+// QueryExpander supplies cross-language alternatives for search query tokens.
+// *enricher.QueryExpander satisfies it.
+type QueryExpander interface {
+	Synonyms(ctx context.Context, query string) map[string][]string
+}
+
+// Notifier publishes domain events to users and external systems.
+// *notify.Notifier satisfies it.
+type Notifier interface {
+	Publish(e notify.Event)
+}
+
 type Handlers struct {
 	store    store.Store
 	importer *ticket.Importer
 	enricher EnrichScheduler
+	expander QueryExpander
+	notifier Notifier
 }
 
 // New returns a Handlers instance. enr may be nil to skip post-import enrichment.
@@ -121,6 +137,25 @@ func New(s store.Store, imp *ticket.Importer, enr EnrichScheduler) *Handlers {
 	return &Handlers{store: s, importer: imp, enricher: enr}
 }
 
+// SetQueryExpander enables Catalan/Spanish query expansion in SearchHandler.
+// Without one, queries match product names only as typed.
+func (h *Handlers) SetQueryExpander(qe QueryExpander) {
+	h.expander = qe
+}
+
+// SetNotifier enables notifications for ticket imports and household
+// invitations. Without one, no events are published.
+func (h *Handlers) SetNotifier(n Notifier) {
+	h.notifier = n
+}
+
+// publish forwards e to the notifier, if any.
+func (h *Handlers) publish(e notify.Event) {
+	if h.notifier != nil {
+		h.notifier.Publish(e)
+	}
+}
+
 // --- Auth handlers ---
 
 type registerRequest struct {
@@ -306,8 +341,10 @@ func (h *Handlers) ChangePasswordHandler(w http.ResponseWriter, r *http.Request)
 
 // --- Product handlers ---
 
-// ProductRouter dispatches /api/products/{id}, /api/products/{id}/image, and
-// /api/products/{id}/prices/{recordID} to the appropriate handler.
+// ProductRouter dispatches /api/products/{id}, /api/products/{id}/image,
+// /api/products/{id}/category, /api/products/{id}/tags[/{tag}],
+// /api/products/{id}/favourite and /api/products/{id}/prices/{recordID} to the
+// appropriate handler.
 func (h *Handlers) ProductRouter(w http.ResponseWriter, r *http.Request) {
 	if strings.Contains(r.URL.Path, "/prices/") {
 		h.DeletePriceRecordHandler(w, r)
@@ -317,28 +354,30 @@ func (h *Handlers) ProductRouter(w http.ResponseWriter, r *http.Request) {
 		h.ProductImageHandler(w, r)
 		return
 	}
-	h.ProductHandler(w, r)
-}
-
-func (h *Handlers) SearchHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if strings.HasSuffix(r.URL.Path, "/category") {
+		h.ProductCategoryHandler(w, r)
 		return
 	}
-
-	userID := UserIDFromContext(r)
-	results, err := h.store.SearchProducts(userID, r.URL.Query().Get("q"))
-	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	if strings.HasSuffix(r.URL.Path, "/tags") || strings.Contains(r.URL.Path, "/tags/") {
+		h.ProductTagsHandler(w, r)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(results); err != nil {
-		log.Printf("handlers: encode search response: %v", err)
+	if strings.HasSuffix(r.URL.Path, "/favourite") {
+		h.ProductFavouriteHandler(w, r)
+		return
 	}
+	h.ProductHandler(w, r)
 }
 
+// defaultForecastMonths is the forecast horizon used by ProductHandler when
+// no 'forecastMonths' is given.
+const defaultForecastMonths = 6
+
+// ProductHandler handles GET /api/products/{id} and returns the product with
+// its price history, deflated to constant euros of the optional 'baseYear'
+// query parameter (default: the latest year with IPC data), and its price
+// forecast for the next 'forecastMonths' months (3 to 12, default 6). With
+// 'mine=true' the history holds only the caller's own purchases.
 func (h *Handlers) ProductHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -351,8 +390,31 @@ func (h *Handlers) ProductHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var baseYear int
+	if raw := r.URL.Query().Get("baseYear"); raw != "" {
+		y, err := strconv.Atoi(raw)
+		if err != nil || y <= 0 {
+			http.Error(w, "Bad request: 'baseYear' must be a year", http.StatusBadRequest)
+			return
+		}
+		baseYear = y
+	}
+	forecastMonths := defaultForecastMonths
+	if raw := r.URL.Query().Get("forecastMonths"); raw != "" {
+		m, err := strconv.Atoi(raw)
+		if err != nil || m < store.MinForecastMonths || m > store.MaxForecastMonths {
+			http.Error(w, "Bad request: 'forecastMonths' must be a number between 3 and 12", http.StatusBadRequest)
+			return
+		}
+		forecastMonths = m
+	}
+
 	userID := UserIDFromContext(r)
-	product, err := h.store.GetProductByID(userID, id)
+	getProduct := h.store.GetProductByID
+	if r.URL.Query().Get("mine") == "true" {
+		getProduct = h.store.GetOwnProductByID
+	}
+	product, err := getProduct(userID, id)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -362,6 +424,23 @@ func (h *Handlers) ProductHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	product.InflationAdjusted, err = h.store.DeflatePriceHistory(product.PriceHistory, baseYear)
+	if errors.Is(err, store.ErrInvalidBaseYear) {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("handlers: deflate price history for %s: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	product.Forecast, err = h.store.ForecastPriceHistory(product.PriceHistory, forecastMonths, time.Now().UTC())
+	if err != nil {
+		log.Printf("handlers: forecast price history for %s: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(product); err != nil {
 		log.Printf("handlers: encode product response: %v", err)
@@ -448,6 +527,72 @@ func (h *Handlers) ProductImageHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// maxCategoryLength caps manually entered category paths.
+const maxCategoryLength = 120
+
+type productCategoryRequest struct {
+	Category string `json:"category"`
+}
+
+// ProductCategoryHandler handles PATCH /api/products/{id}/category.
+// It sets a manually chosen category and locks the product so the enricher
+// will not overwrite it in future runs.
+func (h *Handlers) ProductCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Path: /api/products/{id}/category — strip prefix and suffix.
+	trimmed := strings.TrimPrefix(r.URL.Path, "/api/products/")
+	id := strings.TrimSuffix(trimmed, "/category")
+	if id == "" {
+		http.Error(w, "Product ID required", http.StatusBadRequest)
+		return
+	}
+
+	var req productCategoryRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.Category = strings.TrimSpace(req.Category)
+	if req.Category == "" {
+		http.Error(w, "Bad request: category is required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(req.Category) > maxCategoryLength {
+		http.Error(w, "Bad request: category is too long", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.store.GetProductByID(userID, id)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if product == nil {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.SetProductCategoryManual(id, req.Category); err != nil {
+		log.Printf("handlers: set manual category for %s: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"id": id, "category": req.Category}); err != nil {
+		log.Printf("handlers: encode category response: %v", err)
+	}
+}
+
 // DeletePriceRecordHandler handles DELETE /api/products/{id}/prices/{recordID}.
 // Removes a single price record that belongs to the authenticated user's household.
 func (h *Handlers) DeletePriceRecordHandler(w http.ResponseWriter, r *http.Request) {
@@ -525,11 +670,19 @@ func (h *Handlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 type ticketResponse struct {
 	InvoiceNumber string `json:"invoiceNumber"`
 	LinesImported int    `json:"linesImported"`
+	// LinesFlagged counts the lines held for review at /api/review.
+	LinesFlagged int `json:"linesFlagged"`
+	// Total is the ticket total persisted for shopping-pattern analytics.
+	Total float64 `json:"total"`
 }
 
 type analyticsResponse struct {
 	MostPurchased    []models.MostPurchasedProduct `json:"mostPurchased"`
 	BiggestIncreases []models.PriceIncreaseProduct `json:"biggestIncreases"`
+	BiggestDecreases []models.PriceDecreaseProduct `json:"biggestDecreases"`
+	MostVolatile     []models.VolatileProduct      `json:"mostVolatile"`
+	LongestStable    []models.StablePriceProduct   `json:"longestStable"`
+	Budget           *models.BudgetReport          `json:"budget,omitempty"`
 }
 
 func (h *Handlers) TicketHandler(w http.ResponseWriter, r *http.Request) {
@@ -594,6 +747,8 @@ func (h *Handlers) TicketHandler(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewEncoder(w).Encode(ticketResponse{
 		InvoiceNumber: result.InvoiceNumber,
 		LinesImported: result.LinesImported,
+		LinesFlagged:  result.LinesFlagged,
+		Total:         result.Total,
 	}); err != nil {
 		log.Printf("handlers: encode ticket response: %v", err)
 	}
@@ -603,35 +758,116 @@ func (h *Handlers) TicketHandler(w http.ResponseWriter, r *http.Request) {
 	if h.enricher != nil {
 		h.enricher.Schedule()
 	}
+	if userID != 0 {
+		h.publish(notify.Event{
+			Kind:   notify.EventTicketImported,
+			UserID: userID,
+			Data:   notify.TicketImported{InvoiceNumber: result.InvoiceNumber, LinesImported: result.LinesImported},
+		})
+	}
 }
 
-const analyticsLimit = 10
+// analyticsLimit is the default length of each AnalyticsHandler ranking;
+// maxAnalyticsLimit caps the 'limit' query parameter.
+const (
+	analyticsLimit    = 10
+	maxAnalyticsLimit = 100
+)
+
+// parseAnalyticsFilter reads the optional 'from', 'to' (YYYY-MM-DD), 'store'
+// and 'limit' query parameters. Without dates the whole history is used.
+// Returns an error message suitable for a 400 response.
+func parseAnalyticsFilter(q url.Values) (store.AnalyticsFilter, error) {
+	f := store.AnalyticsFilter{
+		Store: strings.TrimSpace(q.Get("store")),
+		Limit: analyticsLimit,
+	}
+	from, to, err := parseDateRange(q, func(time.Time) time.Time { return time.Time{} })
+	if err != nil {
+		return f, err
+	}
+	f.From = from
+	if q.Get("to") != "" {
+		f.To = to
+	}
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxAnalyticsLimit {
+			return f, fmt.Errorf("limit must be between 1 and %d", maxAnalyticsLimit)
+		}
+		f.Limit = limit
+	}
+	return f, nil
+}
 
+// AnalyticsHandler handles GET /api/analytics and returns the household's
+// product rankings: most purchased, biggest price increases and drops, most
+// volatile prices and longest-stable prices. When the household has budgets,
+// their status for the month of 'to' (default today) is included, as in
+// BreakdownHandler.
+//
+//	from   only count purchases on or after this day (YYYY-MM-DD)
+//	to     only count purchases on or before this day (YYYY-MM-DD)
+//	store  only count purchases in this store (case-insensitive)
+//	limit  length of each ranking (1–100, default 10)
 func (h *Handlers) AnalyticsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	filter, err := parseAnalyticsFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	userID := UserIDFromContext(r)
 
-	mostPurchased, err := h.store.GetMostPurchased(userID, analyticsLimit)
+	mostPurchased, err := h.store.GetMostPurchased(userID, filter)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	biggestIncreases, err := h.store.GetBiggestPriceIncreases(userID, analyticsLimit)
+	biggestIncreases, err := h.store.GetBiggestPriceIncreases(userID, filter)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(analyticsResponse{
+	rankings, err := h.store.GetPriceRankings(userID, filter)
+	if err != nil {
+		log.Printf("handlers: get price rankings: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := analyticsResponse{
 		MostPurchased:    mostPurchased,
 		BiggestIncreases: biggestIncreases,
-	}); err != nil {
+		BiggestDecreases: rankings.BiggestDecreases,
+		MostVolatile:     rankings.MostVolatile,
+		LongestStable:    rankings.LongestStable,
+	}
+	if userID != 0 {
+		asOf := filter.To
+		if asOf.IsZero() {
+			asOf = time.Now().UTC()
+		}
+		report, err := h.store.GetBudgetReport(userID, asOf)
+		if err != nil {
+			log.Printf("handlers: get budget report: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if len(report.Budgets) > 0 {
+			resp.Budget = &report
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		log.Printf("handlers: encode analytics response: %v", err)
 	}
 }
@@ -694,8 +930,14 @@ func (h *Handlers) leaveHousehold(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type householdInviteRequest struct {
+	Email string `json:"email"`
+}
+
 // HouseholdInviteHandler handles POST /api/household/invite.
 // Creates a 24-hour invitation token. If the caller has no household, one is created.
+// With an optional {"email": "..."} body the invitation is also sent to that
+// address through the notifier.
 func (h *Handlers) HouseholdInviteHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -706,18 +948,56 @@ func (h *Handlers) HouseholdInviteHandler(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	var req householdInviteRequest
+	if r.ContentLength != 0 && !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email != "" && !emailRegex.MatchString(req.Email) {
+		http.Error(w, "Bad request: invalid email", http.StatusBadRequest)
+		return
+	}
 	token, err := h.store.CreateHouseholdInvitation(userID)
 	if err != nil {
 		log.Printf("handlers: create invitation: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	if req.Email != "" {
+		if err := h.sendInvitation(userID, token, req.Email); err != nil {
+			log.Printf("handlers: send invitation: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]string{"token": token}); err != nil {
 		log.Printf("handlers: encode invite response: %v", err)
 	}
 }
 
+// sendInvitation publishes the invitation identified by token to email.
+func (h *Handlers) sendInvitation(inviterID int64, token, email string) error {
+	inviter, err := h.store.GetUserByID(inviterID)
+	if err != nil {
+		return err
+	}
+	inv, err := h.store.GetHouseholdInvitation(token)
+	if err != nil {
+		return err
+	}
+	if inviter == nil || inv == nil {
+		return fmt.Errorf("invitation %d/%s vanished", inviterID, token)
+	}
+	h.publish(notify.Event{
+		Kind:   notify.EventHouseholdInvitation,
+		UserID: inviterID,
+		Email:  email,
+		Data:   notify.HouseholdInvitation{Token: token, InvitedBy: inviter.Username, ExpiresAt: inv.ExpiresAt},
+	})
+	return nil
+}
+
 // HouseholdAcceptHandler handles POST /api/household/accept?token=<tok>.
 // The authenticated user joins the household identified by the invitation token.
 func (h *Handlers) HouseholdAcceptHandler(w http.ResponseWriter, r *http.Request) {
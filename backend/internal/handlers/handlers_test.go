@@ -17,6 +17,7 @@ import (
 	"basket-cost/internal/database"
 	"basket-cost/internal/handlers"
 	"basket-cost/internal/models"
+	"basket-cost/internal/notify"
 	"basket-cost/internal/store"
 	"basket-cost/internal/ticket"
 )
@@ -203,6 +204,70 @@ func TestProductHandler_ContentTypeJSON(t *testing.T) {
 	}
 }
 
+func TestProductHandler_IncludesInflationAdjustment(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products/"+productID+"?baseYear=2024", nil), uid)
+	w := httptest.NewRecorder()
+	h.ProductHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var product models.Product
+	if err := json.NewDecoder(w.Body).Decode(&product); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+	adj := product.InflationAdjusted
+	if adj == nil || adj.BaseYear != 2024 || len(adj.History) != len(product.PriceHistory) {
+		t.Fatalf("want adjustment to 2024 euros for every record, got %+v", adj)
+	}
+}
+
+func TestProductHandler_InvalidBaseYear_ReturnsBadRequest(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	for _, year := range []string{"abc", "1990"} {
+		t.Run(year, func(t *testing.T) {
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products/"+productID+"?baseYear="+year, nil), uid)
+			w := httptest.NewRecorder()
+			h.ProductHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestProductHandler_IncludesForecast(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products/"+productID+"?forecastMonths=12", nil), uid)
+	w := httptest.NewRecorder()
+	h.ProductHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var product models.Product
+	if err := json.NewDecoder(w.Body).Decode(&product); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+	fc := product.Forecast
+	if fc == nil || fc.BasePrice != 0.79 || len(fc.Points) != 12 {
+		t.Fatalf("want a 12-month forecast from 0.79, got %+v", fc)
+	}
+}
+
+func TestProductHandler_InvalidForecastMonths_ReturnsBadRequest(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	for _, months := range []string{"abc", "2", "13"} {
+		t.Run(months, func(t *testing.T) {
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products/"+productID+"?forecastMonths="+months, nil), uid)
+			w := httptest.NewRecorder()
+			h.ProductHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
 // --- TicketHandler fakes ---
 
 // fakeExtractor and fakeParser are defined here so tests stay self-contained.
@@ -556,17 +621,29 @@ func TestAnalyticsHandler_ResponseShape(t *testing.T) {
 	var resp struct {
 		MostPurchased    []json.RawMessage `json:"mostPurchased"`
 		BiggestIncreases []json.RawMessage `json:"biggestIncreases"`
+		BiggestDecreases []json.RawMessage `json:"biggestDecreases"`
+		MostVolatile     []json.RawMessage `json:"mostVolatile"`
+		LongestStable    []json.RawMessage `json:"longestStable"`
 	}
 	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 		t.Fatalf("decode analytics response: %v", err)
 	}
-	// Both arrays must be non-nil (may be empty, but not null).
+	// All arrays must be non-nil (may be empty, but not null).
 	if resp.MostPurchased == nil {
 		t.Error("mostPurchased must not be null")
 	}
 	if resp.BiggestIncreases == nil {
 		t.Error("biggestIncreases must not be null")
 	}
+	if resp.BiggestDecreases == nil {
+		t.Error("biggestDecreases must not be null")
+	}
+	if resp.MostVolatile == nil {
+		t.Error("mostVolatile must not be null")
+	}
+	if resp.LongestStable == nil {
+		t.Error("longestStable must not be null")
+	}
 }
 
 func TestAnalyticsHandler_MostPurchasedPopulated(t *testing.T) {
@@ -597,6 +674,48 @@ func TestAnalyticsHandler_MostPurchasedPopulated(t *testing.T) {
 	}
 }
 
+func TestAnalyticsHandler_InvalidFilter_ReturnsBadRequest(t *testing.T) {
+	h := newHandlers(t)
+	for _, qs := range []string{"limit=0", "limit=101", "limit=abc", "from=2025-13-01", "from=2025-06-01&to=2025-01-01"} {
+		t.Run(qs, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/analytics?"+qs, nil)
+			w := httptest.NewRecorder()
+			h.AnalyticsHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestAnalyticsHandler_StoreAndDateFilters(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t) // one Mercadona purchase on 2025-01-10
+	for qs, want := range map[string]int{
+		"store=MERCADONA":                       1,
+		"store=Bonpreu":                         0,
+		"from=2025-01-11":                       0,
+		"from=2025-01-01&to=2025-01-31":         1,
+		"to=2024-12-31&store=Mercadona":         0,
+		"limit=1&from=2025-01-10&to=2025-01-10": 1,
+	} {
+		t.Run(qs, func(t *testing.T) {
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics?"+qs, nil), uid)
+			w := httptest.NewRecorder()
+			h.AnalyticsHandler(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp models.AnalyticsResult
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(resp.MostPurchased) != want {
+				t.Errorf("want %d products, got %+v", want, resp.MostPurchased)
+			}
+		})
+	}
+}
+
 // --- RegisterHandler ---
 
 func newAuthHandlers(t *testing.T) *handlers.Handlers {
@@ -886,6 +1005,60 @@ func TestProductImageHandler_ValidRequest_ReturnsOK(t *testing.T) {
 	}
 }
 
+// --- ProductCategoryHandler ---
+
+func TestProductCategoryHandler_Unauthenticated_Returns401(t *testing.T) {
+	h, _, _, productID := newHandlersWithUser(t)
+	body := jsonBody(t, map[string]string{"category": "Desayuno"})
+	req := httptest.NewRequest(http.MethodPatch, "/api/products/"+productID+"/category", body)
+	w := httptest.NewRecorder()
+	h.ProductCategoryHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestProductCategoryHandler_MissingCategory_ReturnsBadRequest(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	body := jsonBody(t, map[string]string{"category": "  "})
+	req := withUserID(httptest.NewRequest(http.MethodPatch, "/api/products/"+productID+"/category", body), uid)
+	w := httptest.NewRecorder()
+	h.ProductCategoryHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestProductCategoryHandler_ProductNotFound_Returns404(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t)
+	body := jsonBody(t, map[string]string{"category": "Desayuno"})
+	req := withUserID(httptest.NewRequest(http.MethodPatch, "/api/products/nonexistent-product-9999/category", body), uid)
+	w := httptest.NewRecorder()
+	h.ProductCategoryHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestProductRouter_DispatchesPatchCategory_LocksCategory(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t)
+	body := jsonBody(t, map[string]string{"category": "Desayuno"})
+	req := withUserID(httptest.NewRequest(http.MethodPatch, "/api/products/"+productID+"/category", body), uid)
+	w := httptest.NewRecorder()
+	h.ProductRouter(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	p, err := s.GetProductByID(uid, productID)
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if p.Category != "Desayuno" || !p.CategoryLocked {
+		t.Errorf("want locked category %q, got %q (locked=%v)", "Desayuno", p.Category, p.CategoryLocked)
+	}
+}
+
 func TestLoginHandler_Success_ReturnsTokenAndUserID(t *testing.T) {
 	h := newAuthHandlers(t)
 
@@ -1087,6 +1260,62 @@ func TestHouseholdInviteHandler_ReturnsToken(t *testing.T) {
 	}
 }
 
+// recordingNotifier captures published events.
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (n *recordingNotifier) Publish(e notify.Event) { n.events = append(n.events, e) }
+
+func TestHouseholdInviteHandler_WithEmail_PublishesInvitation(t *testing.T) {
+	h, _ := newHouseholdHandlers(t)
+	n := &recordingNotifier{}
+	h.SetNotifier(n)
+	uid := registerAndGetID(t, h, "alice", "Password123")
+
+	req := withUserID(httptest.NewRequest(http.MethodPost, "/api/household/invite",
+		jsonBody(t, map[string]string{"email": " bob@example.com "})), uid)
+	w := httptest.NewRecorder()
+	h.HouseholdInviteHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if len(n.events) != 1 {
+		t.Fatalf("want 1 published event, got %+v", n.events)
+	}
+	e := n.events[0]
+	inv, ok := e.Data.(notify.HouseholdInvitation)
+	if e.Kind != notify.EventHouseholdInvitation || e.Email != "bob@example.com" || !ok ||
+		inv.Token != resp.Token || inv.InvitedBy != "alice" || inv.ExpiresAt.IsZero() {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
+
+func TestHouseholdInviteHandler_InvalidEmail_Returns400(t *testing.T) {
+	h, _ := newHouseholdHandlers(t)
+	n := &recordingNotifier{}
+	h.SetNotifier(n)
+	uid := registerAndGetID(t, h, "alice", "Password123")
+
+	req := withUserID(httptest.NewRequest(http.MethodPost, "/api/household/invite",
+		jsonBody(t, map[string]string{"email": "bob"})), uid)
+	w := httptest.NewRecorder()
+	h.HouseholdInviteHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if len(n.events) != 0 {
+		t.Errorf("no event should be published, got %+v", n.events)
+	}
+}
+
 func TestHouseholdAcceptHandler_ValidToken_JoinsHousehold(t *testing.T) {
 	h, s := newHouseholdHandlers(t)
 	uid1 := registerAndGetID(t, h, "alice", "Password123")
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"basket-cost/internal/store"
+)
+
+// priceChangesLimit is the default number of events returned by
+// PriceChangesHandler.
+const priceChangesLimit = 50
+
+// parsePriceChangeFilter reads the PriceChangesHandler query parameters on top
+// of the common analytics filter. Returns an error message suitable for a 400
+// response.
+func parsePriceChangeFilter(q url.Values) (store.PriceChangeFilter, error) {
+	var f store.PriceChangeFilter
+	var err error
+	if f.AnalyticsFilter, err = parseAnalyticsFilter(q); err != nil {
+		return f, err
+	}
+	if q.Get("limit") == "" {
+		f.Limit = priceChangesLimit
+	}
+	switch dir := store.ChangeDirection(q.Get("direction")); dir {
+	case store.DirectionAny, store.DirectionUp, store.DirectionDown:
+		f.Direction = dir
+	default:
+		return f, fmt.Errorf("direction must be up or down")
+	}
+	if raw := q.Get("minPercent"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			return f, fmt.Errorf("minPercent must be a non-negative number")
+		}
+		f.MinPercent = v
+	}
+	if raw := q.Get("lastShop"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return f, fmt.Errorf("lastShop must be true or false")
+		}
+		f.SinceLastShop = v
+	}
+	return f, nil
+}
+
+// PriceChangesHandler handles GET /api/price-changes and returns the
+// household's price change events between consecutive purchases, newest first.
+//
+//	from, to    only events seen within this range (YYYY-MM-DD)
+//	store       only compare purchases made in this store
+//	direction   up | down (default both)
+//	minPercent  minimum absolute change, in percent
+//	lastShop    true to keep only the changes seen on the latest shopping day
+//	limit       number of events (1–100, default 50)
+func (h *Handlers) PriceChangesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parsePriceChangeFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	events, err := h.store.GetPriceChanges(userID, filter)
+	if err != nil {
+		log.Printf("handlers: get price changes: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		log.Printf("handlers: encode price changes response: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+func TestPriceChangesHandler_MethodNotAllowed(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/price-changes", nil)
+	w := httptest.NewRecorder()
+	h.PriceChangesHandler(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestPriceChangesHandler_InvalidParams_ReturnBadRequest(t *testing.T) {
+	h := newHandlers(t)
+	for _, qs := range []string{"direction=sideways", "minPercent=-1", "minPercent=x", "lastShop=maybe", "limit=0"} {
+		t.Run(qs, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/price-changes?"+qs, nil)
+			w := httptest.NewRecorder()
+			h.PriceChangesHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestPriceChangesHandler_ReturnsRisesSinceLastShop(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t) // 0.79 on 2025-01-10
+	rec := models.PriceRecord{Date: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC), Price: 0.85, Store: "Mercadona"}
+	if err := s.UpsertPriceRecord(uid, "LECHE ENTERA HACENDADO 1L", rec); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/price-changes?lastShop=true&direction=up", nil), uid)
+	w := httptest.NewRecorder()
+	h.PriceChangesHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var events []models.PriceChangeEvent
+	if err := json.NewDecoder(w.Body).Decode(&events); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(events) != 1 || events[0].ProductID != productID || events[0].OldPrice != 0.79 || events[0].NewPrice != 0.85 {
+		t.Errorf("want one 0.79 → 0.85 event, got %+v", events)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// RunningLowHandler handles GET /api/running-low and returns the products the
+// household probably needs to buy again: those overdue or due soon according
+// to their typical repurchase interval, most urgent first.
+//
+//	date   day to predict for (YYYY-MM-DD, default today)
+//	all    true to include every predicted product, not only the urgent ones
+//	limit  number of products (1–100, default all)
+func (h *Handlers) RunningLowHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	asOf := time.Now().UTC()
+	if raw := q.Get("date"); raw != "" {
+		var err error
+		if asOf, err = time.Parse(time.DateOnly, raw); err != nil {
+			http.Error(w, "Bad request: 'date' must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+	var all bool
+	if raw := q.Get("all"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "Bad request: all must be true or false", http.StatusBadRequest)
+			return
+		}
+		all = v
+	}
+	var limit int
+	if raw := q.Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > maxAnalyticsLimit {
+			http.Error(w, "Bad request: limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = v
+	}
+
+	userID := UserIDFromContext(r)
+	predictions, err := h.store.GetRepurchasePredictions(userID, asOf)
+	if err != nil {
+		log.Printf("handlers: get repurchase predictions: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !all {
+		urgent := []models.RepurchasePrediction{}
+		for _, p := range predictions {
+			if p.Status != store.RepurchaseOK {
+				urgent = append(urgent, p)
+			}
+		}
+		predictions = urgent
+	}
+	if limit > 0 && len(predictions) > limit {
+		predictions = predictions[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(predictions); err != nil {
+		log.Printf("handlers: encode running low response: %v", err)
+	}
+}
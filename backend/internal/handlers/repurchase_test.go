@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+func TestRunningLowHandler_MethodNotAllowed(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/running-low", nil)
+	w := httptest.NewRecorder()
+	h.RunningLowHandler(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestRunningLowHandler_InvalidParams_ReturnBadRequest(t *testing.T) {
+	h := newHandlers(t)
+	for _, qs := range []string{"date=tomorrow", "all=maybe", "limit=0", "limit=101"} {
+		t.Run(qs, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/running-low?"+qs, nil)
+			w := httptest.NewRecorder()
+			h.RunningLowHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestRunningLowHandler_OnlyUrgentUnlessAll(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t) // 2025-01-10
+	for _, d := range []int{17, 24} {
+		rec := models.PriceRecord{Date: time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC), Price: 0.79, Store: "Mercadona"}
+		if err := s.UpsertPriceRecord(uid, "LECHE ENTERA HACENDADO 1L", rec); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	get := func(qs string) []models.RepurchasePrediction {
+		t.Helper()
+		req := withUserID(httptest.NewRequest(http.MethodGet, "/api/running-low?"+qs, nil), uid)
+		w := httptest.NewRecorder()
+		h.RunningLowHandler(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var got []models.RepurchasePrediction
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return got
+	}
+
+	// Weekly milk, next due 2025-01-31.
+	if got := get("date=2025-01-26"); len(got) != 0 {
+		t.Errorf("five days early: want nothing running low, got %+v", got)
+	}
+	if got := get("date=2025-01-26&all=true"); len(got) != 1 || got[0].Status != "ok" {
+		t.Errorf("all=true: want the ok prediction, got %+v", got)
+	}
+	got := get("date=2025-02-02")
+	if len(got) != 1 || got[0].ProductID != productID || got[0].Status != "overdue" || got[0].DaysUntil != -2 {
+		t.Errorf("want milk overdue by 2 days, got %+v", got)
+	}
+}
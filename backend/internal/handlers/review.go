@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"basket-cost/internal/store"
+)
+
+// ReviewHandler handles the price records the importer flagged as likely
+// parse or keying errors (a price far from the household's median, or a line
+// total read as a unit price) for the authenticated user's household:
+//
+//	GET    /api/review                flagged records, newest first, with the product's median price
+//	POST   /api/review/{id}/confirm   keep the record as imported
+//	DELETE /api/review/{id}           delete the record
+//
+// Flagged records are left out of price analytics until they are confirmed.
+func (h *Handlers) ReviewHandler(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/review"), "/")
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		records, err := h.store.GetFlaggedRecords(userID)
+		if err != nil {
+			log.Printf("handlers: get flagged records: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(records); err != nil {
+			log.Printf("handlers: encode flagged records response: %v", err)
+		}
+		return
+	}
+
+	rawID, action, _ := strings.Cut(rest, "/")
+	recordID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || recordID <= 0 {
+		http.Error(w, "Bad request: invalid record ID", http.StatusBadRequest)
+		return
+	}
+	switch {
+	case action == "confirm" && r.Method == http.MethodPost:
+		err = h.store.ConfirmFlaggedRecord(userID, recordID)
+	case action == "" && r.Method == http.MethodDelete:
+		err = h.store.DeleteFlaggedRecord(userID, recordID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if errors.Is(err, store.ErrFlaggedRecordNotFound) {
+		http.Error(w, "Flagged record not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("handlers: %s flagged record %d: %v", r.Method, recordID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
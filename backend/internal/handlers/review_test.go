@@ -0,0 +1,78 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+func TestReviewHandler_RequiresAuth(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/review", nil)
+	w := httptest.NewRecorder()
+	h.ReviewHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestReviewHandler_ListConfirmDelete(t *testing.T) {
+	h, s, uid, _ := newHandlersWithUser(t) // 0.79 at Mercadona on 2025-01-10
+	entries := []models.PriceRecordEntry{
+		{Name: "LECHE ENTERA HACENDADO 1L", Record: models.PriceRecord{
+			Date: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), Price: 7.90, Store: "Mercadona", Anomaly: "outlier",
+		}},
+		{Name: "LECHE ENTERA HACENDADO 1L", Record: models.PriceRecord{
+			Date: time.Date(2025, 2, 17, 0, 0, 0, 0, time.UTC), Price: 0.08, Store: "Mercadona", Anomaly: "outlier",
+		}},
+	}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/review", nil), uid)
+	w := httptest.NewRecorder()
+	h.ReviewHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d", w.Code)
+	}
+	var flagged []models.FlaggedRecord
+	if err := json.NewDecoder(w.Body).Decode(&flagged); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(flagged) != 2 || flagged[0].Price != 0.08 || *flagged[0].MedianPrice != 0.79 {
+		t.Fatalf("unexpected flagged records %+v", flagged)
+	}
+
+	steps := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, fmt.Sprintf("/api/review/%d/confirm", flagged[1].RecordID), http.StatusNoContent},
+		{http.MethodDelete, fmt.Sprintf("/api/review/%d", flagged[0].RecordID), http.StatusNoContent},
+		{http.MethodDelete, fmt.Sprintf("/api/review/%d", flagged[1].RecordID), http.StatusNotFound},
+		{http.MethodGet, fmt.Sprintf("/api/review/%d", flagged[1].RecordID), http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/review/abc/confirm", http.StatusBadRequest},
+	}
+	for _, st := range steps {
+		req := withUserID(httptest.NewRequest(st.method, st.path, nil), uid)
+		w := httptest.NewRecorder()
+		h.ReviewHandler(w, req)
+		if w.Code != st.want {
+			t.Errorf("%s %s: expected %d, got %d", st.method, st.path, st.want, w.Code)
+		}
+	}
+
+	product, err := s.GetProductByID(uid, "leche-entera-hacendado-1l")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if len(product.PriceHistory) != 2 || product.PriceHistory[1].Price != 7.90 || product.PriceHistory[1].Anomaly != "" {
+		t.Errorf("want the confirmed record kept and the other deleted, got %+v", product.PriceHistory)
+	}
+}
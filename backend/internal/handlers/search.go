@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"basket-cost/internal/store"
+)
+
+// defaultSearchLimit is the page size SearchHandler uses when the request
+// has no limit; maxSearchLimit caps the page size it accepts.
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// parseSearchOptions reads the SearchHandler query parameters into
+// store.SearchOptions. Returns an error message suitable for a 400 response.
+func parseSearchOptions(q url.Values) (store.SearchOptions, error) {
+	opts := store.SearchOptions{
+		Limit:          defaultSearchLimit,
+		Query:          q.Get("q"),
+		FavouritesOnly: q.Get("favourites") == "true",
+		MineOnly:       q.Get("mine") == "true",
+		Category:       strings.TrimSpace(q.Get("category")),
+		Store:          strings.TrimSpace(q.Get("store")),
+		Sort:           store.SearchSort(q.Get("sort")),
+		Order:          store.SortOrder(q.Get("order")),
+		Cursor:         q.Get("cursor"),
+	}
+	if raw := q.Get("tag"); raw != "" {
+		tag, err := normaliseTag(raw)
+		if err != nil {
+			return opts, err
+		}
+		opts.Tag = tag
+	}
+	for _, p := range []struct {
+		name string
+		dst  *float64
+	}{{"minPrice", &opts.MinPrice}, {"maxPrice", &opts.MaxPrice}} {
+		raw := q.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			return opts, fmt.Errorf("%s must be a non-negative number", p.name)
+		}
+		*p.dst = v
+	}
+	if opts.MaxPrice > 0 && opts.MinPrice > opts.MaxPrice {
+		return opts, fmt.Errorf("minPrice must not exceed maxPrice")
+	}
+	if raw := q.Get("since"); raw != "" {
+		since, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return opts, fmt.Errorf("since must be a date in YYYY-MM-DD format")
+		}
+		opts.Since = since
+	}
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			return opts, fmt.Errorf("limit must be between 1 and %d", maxSearchLimit)
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
+
+// SearchHandler handles GET /api/products. It returns a JSON array of
+// search results and sets X-Total-Count to the number of matches across all
+// pages. Query parameters:
+//
+//	q            free-text query (accent-insensitive, prefix matching)
+//	tag          household tag; favourites=true for starred products only
+//	mine         true to consider only the caller's own purchases, not the household's
+//	category     category path, including its subcategories
+//	store        store name
+//	minPrice     lower bound on the current price
+//	maxPrice     upper bound on the current price
+//	since        last purchased on or after this date (YYYY-MM-DD)
+//	sort         relevance | last | name | price | change | count
+//	order        asc | desc, overriding the sort's natural direction
+//	limit        page size (1–200, default 50)
+//	cursor       value of X-Next-Cursor from the previous page
+//
+// When more results follow, X-Next-Cursor carries the cursor for the next page.
+func (h *Handlers) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	opts, err := parseSearchOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if h.expander != nil && strings.TrimSpace(opts.Query) != "" {
+		opts.Synonyms = h.expander.Synonyms(r.Context(), opts.Query)
+	}
+
+	page, err := h.store.SearchProductsPage(userID, opts)
+	if errors.Is(err, store.ErrInvalidSearch) {
+		http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("handlers: search products: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	if err := json.NewEncoder(w).Encode(page.Results); err != nil {
+		log.Printf("handlers: encode search response: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// fakeExpander returns a fixed synonym map for every query.
+type fakeExpander struct {
+	synonyms map[string][]string
+	queries  []string
+}
+
+func (f *fakeExpander) Synonyms(_ context.Context, query string) map[string][]string {
+	f.queries = append(f.queries, query)
+	return f.synonyms
+}
+
+func TestSearchHandler_QueryExpander_MatchesOtherLanguage(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t) // "LECHE ENTERA HACENDADO 1L"
+	fe := &fakeExpander{synonyms: map[string][]string{"llet": {"leche"}}}
+	h.SetQueryExpander(fe)
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products?q=llet", nil), uid)
+	w := httptest.NewRecorder()
+	h.SearchHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var results []models.SearchResult
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != productID {
+		t.Errorf("want [%s], got %+v", productID, results)
+	}
+	if len(fe.queries) != 1 || fe.queries[0] != "llet" {
+		t.Errorf("expander called with %v", fe.queries)
+	}
+}
+
+func TestSearchHandler_EmptyQuery_SkipsExpander(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t)
+	fe := &fakeExpander{}
+	h.SetQueryExpander(fe)
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products", nil), uid)
+	w := httptest.NewRecorder()
+	h.SearchHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if len(fe.queries) != 0 {
+		t.Errorf("expander should not be called for an empty query, got %v", fe.queries)
+	}
+}
+
+func TestSearchHandler_Limit_SetsPagingHeaders(t *testing.T) {
+	h, s, uid, _ := newHandlersWithUser(t)
+	rec := models.PriceRecord{Date: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Price: 1.10, Store: "Mercadona"}
+	if err := s.UpsertPriceRecord(uid, "PAN DE MOLDE", rec); err != nil {
+		t.Fatalf("seed product: %v", err)
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products?sort=name&limit=1", nil), uid)
+	w := httptest.NewRecorder()
+	h.SearchHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "2" {
+		t.Errorf("X-Total-Count: want 2, got %q", got)
+	}
+	cursor := w.Header().Get("X-Next-Cursor")
+	if cursor == "" {
+		t.Fatal("expected X-Next-Cursor on first page")
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/products?sort=name&limit=1&cursor="+cursor, nil), uid)
+	w = httptest.NewRecorder()
+	h.SearchHandler(w, req)
+	var results []models.SearchResult
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "pan-de-molde" {
+		t.Errorf("second page: want [pan-de-molde], got %+v", results)
+	}
+	if w.Header().Get("X-Next-Cursor") != "" {
+		t.Error("expected no X-Next-Cursor on last page")
+	}
+}
+
+func TestSearchHandler_NoLimit_ReturnsDefaultPage(t *testing.T) {
+	h, s, uid, _ := newHandlersWithUser(t)
+	rec := models.PriceRecord{Date: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Price: 1.10, Store: "Mercadona"}
+	for i := range 60 {
+		if err := s.UpsertPriceRecord(uid, fmt.Sprintf("PRODUCTO %02d", i), rec); err != nil {
+			t.Fatalf("seed product: %v", err)
+		}
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products", nil), uid)
+	w := httptest.NewRecorder()
+	h.SearchHandler(w, req)
+	var results []models.SearchResult
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(results) != 50 {
+		t.Errorf("want a default page of 50, got %d", len(results))
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "61" {
+		t.Errorf("X-Total-Count: want 61, got %q", got)
+	}
+	if w.Header().Get("X-Next-Cursor") == "" {
+		t.Error("expected X-Next-Cursor after the default page")
+	}
+}
+
+func TestSearchHandler_InvalidParams_ReturnBadRequest(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t)
+	for _, qs := range []string{
+		"sort=colour",
+		"order=sideways",
+		"limit=0",
+		"limit=500",
+		"minPrice=-1",
+		"minPrice=5&maxPrice=1",
+		"since=yesterday",
+		"cursor=not-a-cursor",
+	} {
+		t.Run(qs, func(t *testing.T) {
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products?"+qs, nil), uid)
+			w := httptest.NewRecorder()
+			h.SearchHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestSearchAndProductHandlers_MineOnly(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t) // bought by uid
+	partner, err := s.CreateUser("partner", "", "$2a$12$fakehashfortesting000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	hid, err := s.CreateHousehold(uid)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := s.AddUserToHousehold(partner, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{{"", 1}, {"?mine=true", 0}} {
+		req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products"+tt.query, nil), partner)
+		w := httptest.NewRecorder()
+		h.SearchHandler(w, req)
+		var results []models.SearchResult
+		if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(results) != tt.want {
+			t.Errorf("search %q: want %d results, got %+v", tt.query, tt.want, results)
+		}
+
+		req = withUserID(httptest.NewRequest(http.MethodGet, "/api/products/"+productID+tt.query, nil), partner)
+		w = httptest.NewRecorder()
+		h.ProductHandler(w, req)
+		var product models.Product
+		if err := json.NewDecoder(w.Body).Decode(&product); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(product.PriceHistory) != tt.want {
+			t.Errorf("product %q: want %d records, got %+v", tt.query, tt.want, product.PriceHistory)
+		}
+	}
+}
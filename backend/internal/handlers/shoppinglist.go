@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"basket-cost/internal/store"
+)
+
+// maxNameLen caps the length of a list or group name, in characters.
+const maxNameLen = 100
+
+type shoppingListRequest struct {
+	Name string `json:"name"`
+	// Generate pre-fills a new list with the staples due for repurchase.
+	Generate bool `json:"generate"`
+}
+
+type shoppingListItemRequest struct {
+	ProductID string   `json:"productId"`
+	Quantity  *float64 `json:"quantity"`
+	Checked   *bool    `json:"checked"`
+}
+
+// ShoppingListsHandler handles the shopping lists shared by the
+// authenticated user's household:
+//
+//	GET    /api/lists                      all lists with items and totals
+//	POST   /api/lists                      create {"name", "generate"}
+//	GET    /api/lists/{id}                 one list
+//	PATCH  /api/lists/{id}                 rename {"name"}
+//	DELETE /api/lists/{id}                 delete a list
+//	POST   /api/lists/{id}/items           add {"productId", "quantity"} (default 1)
+//	PATCH  /api/lists/{id}/items/{itemId}  update {"quantity", "checked"}
+//	DELETE /api/lists/{id}/items/{itemId}  remove an item
+//	POST   /api/lists/{id}/generate        add the staples due for repurchase
+//
+// Items are priced at the household's latest purchase of the product; the
+// list carries the estimated total and what remains once checked items are
+// left out. Every request except DELETE answers with the resulting list.
+func (h *Handlers) ShoppingListsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/lists"), "/")
+	if rest == "" {
+		switch r.Method {
+		case http.MethodGet:
+			lists, err := h.store.GetShoppingLists(userID)
+			if err != nil {
+				log.Printf("handlers: get shopping lists: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(lists); err != nil {
+				log.Printf("handlers: encode shopping lists response: %v", err)
+			}
+		case http.MethodPost:
+			h.createShoppingList(w, r, userID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	rawID, sub, _ := strings.Cut(rest, "/")
+	listID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || listID <= 0 {
+		http.Error(w, "Bad request: invalid list ID", http.StatusBadRequest)
+		return
+	}
+	section, rawItemID, hasItem := strings.Cut(sub, "/")
+
+	var opErr error
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		// Nothing to change; the list is written below.
+	case sub == "" && r.Method == http.MethodPatch:
+		var req shoppingListRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		name, ok := validName(w, req.Name)
+		if !ok {
+			return
+		}
+		opErr = h.store.RenameShoppingList(userID, listID, name)
+	case sub == "" && r.Method == http.MethodDelete:
+		opErr = h.store.DeleteShoppingList(userID, listID)
+	case sub == "items" && r.Method == http.MethodPost:
+		var req shoppingListItemRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.ProductID == "" {
+			http.Error(w, "Bad request: productId is required", http.StatusBadRequest)
+			return
+		}
+		quantity := 1.0
+		if req.Quantity != nil {
+			quantity = *req.Quantity
+		}
+		if quantity <= 0 {
+			http.Error(w, "Bad request: quantity must be positive", http.StatusBadRequest)
+			return
+		}
+		product, err := h.store.GetProductByID(userID, req.ProductID)
+		if err != nil {
+			log.Printf("handlers: get product %s: %v", req.ProductID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if product == nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		opErr = h.store.SetShoppingListItem(userID, listID, req.ProductID, quantity)
+	case section == "items" && hasItem && (r.Method == http.MethodPatch || r.Method == http.MethodDelete):
+		itemID, err := strconv.ParseInt(rawItemID, 10, 64)
+		if err != nil || itemID <= 0 {
+			http.Error(w, "Bad request: invalid item ID", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			opErr = h.store.DeleteShoppingListItem(userID, listID, itemID)
+			break
+		}
+		var req shoppingListItemRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.Quantity == nil && req.Checked == nil {
+			http.Error(w, "Bad request: quantity or checked is required", http.StatusBadRequest)
+			return
+		}
+		if req.Quantity != nil && *req.Quantity <= 0 {
+			http.Error(w, "Bad request: quantity must be positive", http.StatusBadRequest)
+			return
+		}
+		opErr = h.store.UpdateShoppingListItem(userID, listID, itemID, req.Quantity, req.Checked)
+	case sub == "generate" && r.Method == http.MethodPost:
+		_, opErr = h.store.FillShoppingList(userID, listID, time.Now().UTC())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if errors.Is(opErr, store.ErrShoppingListNotFound) {
+		http.Error(w, "Shopping list not found", http.StatusNotFound)
+		return
+	}
+	if opErr != nil {
+		log.Printf("handlers: %s shopping list %d: %v", r.Method, listID, opErr)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.writeShoppingList(w, userID, listID, http.StatusOK)
+}
+
+// createShoppingList handles POST /api/lists.
+func (h *Handlers) createShoppingList(w http.ResponseWriter, r *http.Request, userID int64) {
+	var req shoppingListRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	name, ok := validName(w, req.Name)
+	if !ok {
+		return
+	}
+	listID, err := h.store.CreateShoppingList(userID, name)
+	if err != nil {
+		log.Printf("handlers: create shopping list: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if req.Generate {
+		if _, err := h.store.FillShoppingList(userID, listID, time.Now().UTC()); err != nil {
+			log.Printf("handlers: fill shopping list %d: %v", listID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+	h.writeShoppingList(w, userID, listID, http.StatusCreated)
+}
+
+// writeShoppingList responds with listID as JSON, or 404 if it is gone.
+func (h *Handlers) writeShoppingList(w http.ResponseWriter, userID, listID int64, status int) {
+	list, err := h.store.GetShoppingList(userID, listID)
+	if err != nil {
+		log.Printf("handlers: get shopping list %d: %v", listID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		http.Error(w, "Shopping list not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		log.Printf("handlers: encode shopping list response: %v", err)
+	}
+}
+
+// validName validates a list or group name, writing a 400 response when it
+// is empty or too long.
+func validName(w http.ResponseWriter, raw string) (string, bool) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		http.Error(w, "Bad request: name is required", http.StatusBadRequest)
+		return "", false
+	}
+	if utf8.RuneCountInString(name) > maxNameLen {
+		http.Error(w, "Bad request: name must be at most 100 characters", http.StatusBadRequest)
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,109 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"basket-cost/internal/models"
+)
+
+func TestShoppingListsHandler_RequiresAuth(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/lists", nil)
+	w := httptest.NewRecorder()
+	h.ShoppingListsHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestShoppingListsHandler_InvalidRequests(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t)
+	listID, err := s.CreateShoppingList(uid, "Semana")
+	if err != nil {
+		t.Fatalf("CreateShoppingList: %v", err)
+	}
+	items := fmt.Sprintf("/api/lists/%d/items", listID)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   map[string]any
+		want   int
+	}{
+		{"missing name", http.MethodPost, "/api/lists", map[string]any{"name": "  "}, http.StatusBadRequest},
+		{"invalid list ID", http.MethodGet, "/api/lists/abc", nil, http.StatusBadRequest},
+		{"unknown list", http.MethodGet, "/api/lists/9999", nil, http.StatusNotFound},
+		{"unknown product", http.MethodPost, items, map[string]any{"productId": "nope"}, http.StatusNotFound},
+		{"zero quantity", http.MethodPost, items, map[string]any{"productId": productID, "quantity": 0}, http.StatusBadRequest},
+		{"empty item update", http.MethodPatch, items + "/1", map[string]any{}, http.StatusBadRequest},
+		{"unknown item", http.MethodDelete, items + "/9999", nil, http.StatusNotFound},
+		{"method not allowed", http.MethodPut, "/api/lists", nil, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.body != nil {
+				req = httptest.NewRequest(tt.method, tt.path, jsonBody(t, tt.body))
+			}
+			w := httptest.NewRecorder()
+			h.ShoppingListsHandler(w, withUserID(req, uid))
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestShoppingListsHandler_CreateAddCheckDelete(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t) // 0.79 at Mercadona
+
+	do := func(method, path string, body map[string]any, want int) models.ShoppingList {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		if body != nil {
+			req = httptest.NewRequest(method, path, jsonBody(t, body))
+		}
+		w := httptest.NewRecorder()
+		h.ShoppingListsHandler(w, withUserID(req, uid))
+		if w.Code != want {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, want, w.Code, w.Body.String())
+		}
+		var list models.ShoppingList
+		if want == http.StatusOK || want == http.StatusCreated {
+			if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+		}
+		return list
+	}
+
+	list := do(http.MethodPost, "/api/lists", map[string]any{"name": "Semana"}, http.StatusCreated)
+	if list.Name != "Semana" || list.Items == nil || len(list.Items) != 0 {
+		t.Fatalf("unexpected new list %+v", list)
+	}
+	path := fmt.Sprintf("/api/lists/%d", list.ID)
+
+	list = do(http.MethodPost, path+"/items", map[string]any{"productId": productID, "quantity": 3}, http.StatusOK)
+	if len(list.Items) != 1 || list.Items[0].CurrentPrice != 0.79 || list.EstimatedTotal != 2.37 {
+		t.Fatalf("unexpected list after adding %+v", list)
+	}
+
+	itemPath := fmt.Sprintf("%s/items/%d", path, list.Items[0].ID)
+	list = do(http.MethodPatch, itemPath, map[string]any{"checked": true}, http.StatusOK)
+	if !list.Items[0].Checked || list.RemainingTotal != 0 || list.EstimatedTotal != 2.37 {
+		t.Errorf("unexpected list after checking %+v", list)
+	}
+
+	list = do(http.MethodPatch, path, map[string]any{"name": "Finde"}, http.StatusOK)
+	if list.Name != "Finde" {
+		t.Errorf("want renamed list, got %q", list.Name)
+	}
+
+	do(http.MethodDelete, itemPath, nil, http.StatusNoContent)
+	do(http.MethodDelete, path, nil, http.StatusNoContent)
+	do(http.MethodGet, path, nil, http.StatusNotFound)
+}
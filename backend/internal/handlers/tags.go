@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTagLength caps the length of a user-defined tag.
+const maxTagLength = 32
+
+// reTag restricts tags to letters, digits, spaces, hyphens and underscores.
+// Commas are excluded because the store joins tags with GROUP_CONCAT.
+var reTag = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} _\-]*$`)
+
+// normaliseTag lowercases raw, collapses inner whitespace and validates the
+// result. Returns an error message suitable for a 400 response.
+func normaliseTag(raw string) (string, error) {
+	tag := strings.ToLower(strings.Join(strings.Fields(raw), " "))
+	if tag == "" {
+		return "", fmt.Errorf("tag is required")
+	}
+	if utf8.RuneCountInString(tag) > maxTagLength {
+		return "", fmt.Errorf("tag must be at most %d characters", maxTagLength)
+	}
+	if !reTag.MatchString(tag) {
+		return "", fmt.Errorf("tag may only contain letters, digits, spaces, '-' and '_'")
+	}
+	return tag, nil
+}
+
+type productTagRequest struct {
+	Tag string `json:"tag"`
+}
+
+type productTagsResponse struct {
+	ID   string   `json:"id"`
+	Tags []string `json:"tags"`
+}
+
+// ProductTagsHandler handles the household tags of a product:
+//
+//	GET    /api/products/{id}/tags        list tags
+//	POST   /api/products/{id}/tags        add {"tag": "..."}
+//	DELETE /api/products/{id}/tags/{tag}  remove tag
+//
+// All methods require authentication and respond with the resulting tag list.
+func (h *Handlers) ProductTagsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Path: /api/products/{id}/tags[/{tag}]
+	trimmed := strings.TrimPrefix(r.URL.Path, "/api/products/")
+	id, rest, _ := strings.Cut(trimmed, "/tags")
+	rest = strings.TrimPrefix(rest, "/")
+	if id == "" {
+		http.Error(w, "Product ID required", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.store.GetProductByID(userID, id)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if product == nil {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	status := http.StatusOK
+	switch r.Method {
+	case http.MethodPost:
+		if rest != "" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req productTagRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		tag, err := normaliseTag(req.Tag)
+		if err != nil {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.store.AddProductTag(userID, id, tag); err != nil {
+			log.Printf("handlers: add tag for %s: %v", id, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		status = http.StatusCreated
+	case http.MethodDelete:
+		tag, err := normaliseTag(rest)
+		if err != nil {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.store.RemoveProductTag(userID, id, tag); err != nil {
+			log.Printf("handlers: remove tag for %s: %v", id, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		if rest != "" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+
+	tags, err := h.store.GetProductTags(userID, id)
+	if err != nil {
+		log.Printf("handlers: get tags for %s: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(productTagsResponse{ID: id, Tags: tags}); err != nil {
+		log.Printf("handlers: encode tags response: %v", err)
+	}
+}
+
+// ProductFavouriteHandler handles PUT (star) and DELETE (unstar) on
+// /api/products/{id}/favourite for the authenticated user's household.
+func (h *Handlers) ProductFavouriteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	trimmed := strings.TrimPrefix(r.URL.Path, "/api/products/")
+	id := strings.TrimSuffix(trimmed, "/favourite")
+	if id == "" {
+		http.Error(w, "Product ID required", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.store.GetProductByID(userID, id)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if product == nil {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	favourite := r.Method == http.MethodPut
+	if err := h.store.SetProductFavourite(userID, id, favourite); err != nil {
+		log.Printf("handlers: set favourite for %s: %v", id, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"id": id, "favourite": favourite}); err != nil {
+		log.Printf("handlers: encode favourite response: %v", err)
+	}
+}
+
+// TagAnalyticsHandler handles GET /api/analytics/tags and returns the
+// household's purchase counts and spend grouped by tag.
+func (h *Handlers) TagAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	byTag, err := h.store.GetSpendByTag(userID)
+	if err != nil {
+		log.Printf("handlers: get spend by tag: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"byTag": byTag}); err != nil {
+		log.Printf("handlers: encode tag analytics response: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// --- ProductTagsHandler ---
+
+func TestProductTagsHandler_Unauthenticated_Returns401(t *testing.T) {
+	h, _, _, productID := newHandlersWithUser(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/products/"+productID+"/tags", nil)
+	w := httptest.NewRecorder()
+	h.ProductTagsHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestProductTagsHandler_InvalidTag_ReturnsBadRequest(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	body := jsonBody(t, map[string]string{"tag": "a,b"})
+	req := withUserID(httptest.NewRequest(http.MethodPost, "/api/products/"+productID+"/tags", body), uid)
+	w := httptest.NewRecorder()
+	h.ProductTagsHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestProductTagsHandler_ProductNotFound_Returns404(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t)
+	body := jsonBody(t, map[string]string{"tag": "desayuno"})
+	req := withUserID(httptest.NewRequest(http.MethodPost, "/api/products/nonexistent-product-9999/tags", body), uid)
+	w := httptest.NewRecorder()
+	h.ProductTagsHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestProductRouter_AddAndRemoveTag(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+
+	body := jsonBody(t, map[string]string{"tag": "  Desayuno  "})
+	req := withUserID(httptest.NewRequest(http.MethodPost, "/api/products/"+productID+"/tags", body), uid)
+	w := httptest.NewRecorder()
+	h.ProductRouter(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Tags) != 1 || resp.Tags[0] != "desayuno" {
+		t.Fatalf("want normalised tag [desayuno], got %v", resp.Tags)
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodDelete, "/api/products/"+productID+"/tags/desayuno", nil), uid)
+	w = httptest.NewRecorder()
+	h.ProductRouter(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("DELETE: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	resp.Tags = nil
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Tags) != 0 {
+		t.Errorf("want no tags after DELETE, got %v", resp.Tags)
+	}
+}
+
+// --- ProductFavouriteHandler ---
+
+func TestProductFavouriteHandler_MethodNotAllowed(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/products/"+productID+"/favourite", nil), uid)
+	w := httptest.NewRecorder()
+	h.ProductFavouriteHandler(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestProductRouter_PutFavourite_FiltersSearch(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+
+	req := withUserID(httptest.NewRequest(http.MethodPut, "/api/products/"+productID+"/favourite", nil), uid)
+	w := httptest.NewRecorder()
+	h.ProductRouter(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/products?favourites=true", nil), uid)
+	w = httptest.NewRecorder()
+	h.SearchHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("search: expected 200, got %d", w.Code)
+	}
+	var results []struct {
+		ID        string `json:"id"`
+		Favourite bool   `json:"favourite"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != productID || !results[0].Favourite {
+		t.Errorf("want only favourite %q, got %+v", productID, results)
+	}
+}
+
+// --- TagAnalyticsHandler ---
+
+func TestTagAnalyticsHandler_ResponseShape(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t)
+	if err := s.AddProductTag(uid, productID, "desayuno"); err != nil {
+		t.Fatalf("AddProductTag: %v", err)
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/analytics/tags", nil), uid)
+	w := httptest.NewRecorder()
+	h.TagAnalyticsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp struct {
+		ByTag []struct {
+			Tag           string  `json:"tag"`
+			PurchaseCount int     `json:"purchaseCount"`
+			TotalSpent    float64 `json:"totalSpent"`
+		} `json:"byTag"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.ByTag) != 1 || resp.ByTag[0].Tag != "desayuno" || resp.ByTag[0].PurchaseCount != 1 {
+		t.Errorf("unexpected byTag: %+v", resp.ByTag)
+	}
+}
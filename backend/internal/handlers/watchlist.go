@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"basket-cost/internal/store"
+)
+
+// alertsLimit is the default number of alerts returned by AlertsHandler.
+const alertsLimit = 50
+
+type watchRequest struct {
+	ProductID string   `json:"productId"`
+	Rule      string   `json:"rule"`
+	Threshold *float64 `json:"threshold"`
+}
+
+// WatchlistHandler handles the authenticated user's watchlist:
+//
+//	GET    /api/watchlist       list rules with the current price
+//	POST   /api/watchlist       add {"productId", "rule", "threshold"}
+//	DELETE /api/watchlist/{id}  remove a rule
+//
+// Rules are any_increase, price_above and price_below (threshold in euros)
+// and increase_percent (threshold in percent). They are evaluated whenever a
+// ticket import adds a purchase of the product; alerts land in /api/alerts.
+func (h *Handlers) WatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/watchlist"), "/")
+	if (r.Method == http.MethodDelete) != (rest != "") {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var req watchRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.ProductID == "" {
+			http.Error(w, "Bad request: productId is required", http.StatusBadRequest)
+			return
+		}
+		product, err := h.store.GetProductByID(userID, req.ProductID)
+		if err != nil {
+			log.Printf("handlers: get product %s: %v", req.ProductID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if product == nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		watch, err := h.store.AddWatch(userID, req.ProductID, store.WatchRule(req.Rule), req.Threshold)
+		if errors.Is(err, store.ErrInvalidWatch) {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("handlers: add watch on %s: %v", req.ProductID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(watch); err != nil {
+			log.Printf("handlers: encode watch response: %v", err)
+		}
+
+	case http.MethodDelete:
+		watchID, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil || watchID <= 0 {
+			http.Error(w, "Bad request: invalid watch ID", http.StatusBadRequest)
+			return
+		}
+		deleted, err := h.store.DeleteWatch(userID, watchID)
+		if err != nil {
+			log.Printf("handlers: delete watch %d: %v", watchID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !deleted {
+			http.Error(w, "Watch not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		watches, err := h.store.GetWatchlist(userID)
+		if err != nil {
+			log.Printf("handlers: get watchlist: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(watches); err != nil {
+			log.Printf("handlers: encode watchlist response: %v", err)
+		}
+	}
+}
+
+type markAlertsReadRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// AlertsHandler handles the authenticated user's alert inbox:
+//
+//	GET  /api/alerts       list alerts, newest first, with the unread count
+//	                       (unread=true to hide read ones; limit 1–100, default 50)
+//	POST /api/alerts/read  mark {"ids": [...]} as read, or all when ids is empty
+func (h *Handlers) AlertsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := UserIDFromContext(r)
+	if userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && strings.TrimSuffix(r.URL.Path, "/") == "/api/alerts/read":
+		var req markAlertsReadRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if err := h.store.MarkAlertsRead(userID, req.IDs); err != nil {
+			log.Printf("handlers: mark alerts read: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodGet && strings.TrimSuffix(r.URL.Path, "/") == "/api/alerts":
+		q := r.URL.Query()
+		limit := alertsLimit
+		if raw := q.Get("limit"); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil || v < 1 || v > maxAnalyticsLimit {
+				http.Error(w, "Bad request: limit must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+			limit = v
+		}
+		var unreadOnly bool
+		if raw := q.Get("unread"); raw != "" {
+			v, err := strconv.ParseBool(raw)
+			if err != nil {
+				http.Error(w, "Bad request: unread must be true or false", http.StatusBadRequest)
+				return
+			}
+			unreadOnly = v
+		}
+		inbox, err := h.store.GetAlerts(userID, unreadOnly, limit)
+		if err != nil {
+			log.Printf("handlers: get alerts: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(inbox); err != nil {
+			log.Printf("handlers: encode alerts response: %v", err)
+		}
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
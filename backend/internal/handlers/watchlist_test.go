@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+func TestWatchlistHandler_RequiresAuth(t *testing.T) {
+	h := newHandlers(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/watchlist", nil)
+	w := httptest.NewRecorder()
+	h.WatchlistHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestWatchlistHandler_InvalidRequests(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+	tests := []struct {
+		name string
+		body map[string]any
+		want int
+	}{
+		{"missing product", map[string]any{"rule": "any_increase"}, http.StatusBadRequest},
+		{"unknown product", map[string]any{"productId": "nope", "rule": "any_increase"}, http.StatusNotFound},
+		{"unknown rule", map[string]any{"productId": productID, "rule": "cheaper"}, http.StatusBadRequest},
+		{"missing threshold", map[string]any{"productId": productID, "rule": "price_above"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withUserID(httptest.NewRequest(http.MethodPost, "/api/watchlist", jsonBody(t, tt.body)), uid)
+			w := httptest.NewRecorder()
+			h.WatchlistHandler(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWatchlistHandler_AddListDelete(t *testing.T) {
+	h, _, uid, productID := newHandlersWithUser(t)
+
+	body := jsonBody(t, map[string]any{"productId": productID, "rule": "increase_percent", "threshold": 5})
+	req := withUserID(httptest.NewRequest(http.MethodPost, "/api/watchlist", body), uid)
+	w := httptest.NewRecorder()
+	h.WatchlistHandler(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.Watch
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ProductID != productID || created.CurrentPrice != 0.79 || created.Threshold == nil || *created.Threshold != 5 {
+		t.Errorf("unexpected watch %+v", created)
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodGet, "/api/watchlist", nil), uid)
+	w = httptest.NewRecorder()
+	h.WatchlistHandler(w, req)
+	var list []models.Watch
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("want the created watch, got %+v", list)
+	}
+
+	path := fmt.Sprintf("/api/watchlist/%d", created.ID)
+	req = withUserID(httptest.NewRequest(http.MethodDelete, path, nil), uid)
+	w = httptest.NewRecorder()
+	h.WatchlistHandler(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	req = withUserID(httptest.NewRequest(http.MethodDelete, path, nil), uid)
+	w = httptest.NewRecorder()
+	h.WatchlistHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second delete: expected 404, got %d", w.Code)
+	}
+}
+
+func TestAlertsHandler_ListAndMarkRead(t *testing.T) {
+	h, s, uid, productID := newHandlersWithUser(t) // 0.79 on 2025-01-10
+	if _, err := s.AddWatch(uid, productID, "any_increase", nil); err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+	entries := []models.PriceRecordEntry{{
+		Name:   "LECHE ENTERA HACENDADO 1L",
+		Record: models.PriceRecord{Date: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC), Price: 0.85, Store: "Mercadona"},
+	}}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/alerts?unread=true", nil), uid)
+	w := httptest.NewRecorder()
+	h.AlertsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var inbox models.AlertInbox
+	if err := json.NewDecoder(w.Body).Decode(&inbox); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if inbox.Unread != 1 || len(inbox.Alerts) != 1 || *inbox.Alerts[0].NewPrice != 0.85 {
+		t.Fatalf("want one unread 0.85 alert, got %+v", inbox)
+	}
+
+	req = withUserID(httptest.NewRequest(http.MethodPost, "/api/alerts/read", jsonBody(t, map[string]any{"ids": []int64{}})), uid)
+	w = httptest.NewRecorder()
+	h.AlertsHandler(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	inbox, _ = s.GetAlerts(uid, false, 0)
+	if inbox.Unread != 0 || !inbox.Alerts[0].Read {
+		t.Errorf("want all read, got %+v", inbox)
+	}
+}
+
+func TestAlertsHandler_InvalidParams_ReturnBadRequest(t *testing.T) {
+	h, _, uid, _ := newHandlersWithUser(t)
+	for _, qs := range []string{"limit=0", "limit=x", "unread=maybe"} {
+		t.Run(qs, func(t *testing.T) {
+			req := withUserID(httptest.NewRequest(http.MethodGet, "/api/alerts?"+qs, nil), uid)
+			w := httptest.NewRecorder()
+			h.AlertsHandler(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+// Package ipc parses the official Spanish consumer price index (IPC) CSV
+// exports published by INE and IDESCAT into monthly observations.
+package ipc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/textnorm"
+)
+
+// SubindexGeneral is the subindex of the headline (all items) IPC.
+const SubindexGeneral = "general"
+
+// RegionSpain is the region of the national series.
+const RegionSpain = "ES"
+
+// ineRegions maps INE community codes to ISO 3166-2:ES codes.
+var ineRegions = map[string]string{
+	"00": RegionSpain,
+	"01": "AN", "02": "AR", "03": "AS", "04": "IB", "05": "CN",
+	"06": "CB", "07": "CL", "08": "CM", "09": "CT", "10": "VC",
+	"11": "EX", "12": "GA", "13": "MD", "14": "MC", "15": "NC",
+	"16": "PV", "17": "RI", "18": "CE", "19": "ML",
+}
+
+// regionNames maps normalised region labels that carry no INE code.
+var regionNames = map[string]string{
+	"nacional":       RegionSpain,
+	"total nacional": RegionSpain,
+	"espana":         RegionSpain,
+	"espanya":        RegionSpain,
+	"cataluna":       "CT",
+	"catalunya":      "CT",
+}
+
+var (
+	// Leading numeric code of an INE label: "09 Cataluña", "01 Alimentos…".
+	reLeadingCode = regexp.MustCompile(`^(\d+)\b`)
+
+	// Monthly periods: "2024M03", "2024-03", "03/2024".
+	rePeriodINE   = regexp.MustCompile(`^(\d{4})M(\d{2})$`)
+	rePeriodISO   = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+	rePeriodSlash = regexp.MustCompile(`^(\d{2})/(\d{4})$`)
+
+	// Yearly periods (annual averages), which are skipped.
+	rePeriodYear = regexp.MustCompile(`^\d{4}$`)
+)
+
+// columns holds the indices of the recognised header columns (-1 if absent).
+type columns struct {
+	period, region, subindex int
+	// Long layout (INE): one row per data type, "Tipo de dato" + "Total".
+	dataType, value int
+	// Wide layout (IDESCAT): one column per data type.
+	index, annual int
+}
+
+// detectColumns recognises the header row of an export. ok is false when the
+// row is not a header.
+func detectColumns(header []string) (c columns, ok bool) {
+	c = columns{-1, -1, -1, -1, -1, -1, -1}
+	for i, cell := range header {
+		name := textnorm.Normalise(cell)
+		switch {
+		case name == "periodo" || name == "periode" || name == "period":
+			c.period = i
+		case strings.Contains(name, "comunidad") || strings.Contains(name, "territori") ||
+			strings.Contains(name, "ambit") || name == "region" || name == "total nacional":
+			c.region = i
+		case strings.Contains(name, "ecoicop") || strings.HasPrefix(name, "grup") ||
+			strings.Contains(name, "subclase") || strings.Contains(name, "rubrica") || name == "clases":
+			c.subindex = i
+		case name == "tipo de dato" || name == "tipus de dada":
+			c.dataType = i
+		case name == "total" || name == "valor" || name == "value":
+			c.value = i
+		case strings.HasPrefix(name, "indice") || strings.HasPrefix(name, "index"):
+			c.index = i
+		case strings.Contains(name, "anual") && !strings.Contains(name, "mitjana") && !strings.Contains(name, "media"):
+			c.annual = i
+		}
+	}
+	long := c.dataType >= 0 && c.value >= 0
+	wide := c.index >= 0 || c.annual >= 0
+	return c, c.period >= 0 && (long || wide)
+}
+
+// Parse reads an INE or IDESCAT IPC export and returns one observation per
+// region, subindex and month, in file order.
+//
+// Both the INE long layout ("Tipo de dato;Periodo;Total", one row per data
+// type) and the IDESCAT wide layout (one column per data type) are accepted,
+// with ';', ',' or tab separators, decimal commas and UTF-8 or Latin-1
+// encoding. Title lines before the header are skipped, as are yearly rows and
+// data types other than the index and the annual rate. defaultRegion is used
+// when the export has no region column.
+func Parse(r io.Reader, defaultRegion string) ([]models.IPCObservation, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read ipc export: %w", err)
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(raw) {
+		raw = latin1ToUTF8(raw)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(raw))
+	reader.Comma = detectSeparator(raw)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var (
+		cols   columns
+		found  bool
+		out    []models.IPCObservation
+		byKey  = make(map[string]int)
+		lineNo int
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read ipc export: %w", err)
+		}
+		lineNo++
+		if !found {
+			cols, found = detectColumns(record)
+			continue
+		}
+		if len(record) <= cols.period || strings.TrimSpace(record[cols.period]) == "" {
+			continue
+		}
+
+		periodLabel := strings.TrimSpace(record[cols.period])
+		if rePeriodYear.MatchString(periodLabel) {
+			continue
+		}
+		period, err := parsePeriod(periodLabel)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		region := defaultRegion
+		if cols.region >= 0 {
+			region = regionCode(cell(record, cols.region))
+		}
+		subindex := SubindexGeneral
+		if cols.subindex >= 0 {
+			subindex = subindexCode(cell(record, cols.subindex))
+		}
+
+		var index, annual *float64
+		if cols.dataType >= 0 && cols.value >= 0 {
+			value, err := parseNumber(cell(record, cols.value))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			switch dataType := textnorm.Normalise(cell(record, cols.dataType)); {
+			case strings.HasPrefix(dataType, "indice") || strings.HasPrefix(dataType, "index"):
+				index = value
+			case strings.Contains(dataType, "anual") && !strings.Contains(dataType, "media"):
+				annual = value
+			default:
+				continue
+			}
+		} else {
+			if cols.index >= 0 {
+				if index, err = parseNumber(cell(record, cols.index)); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+			}
+			if cols.annual >= 0 {
+				if annual, err = parseNumber(cell(record, cols.annual)); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+			}
+		}
+		if annual != nil {
+			// Exports publish rates as percentages with at most two decimals.
+			rate := math.Round(*annual*100) / 10000
+			annual = &rate
+		}
+		if index == nil && annual == nil {
+			continue
+		}
+
+		key := region + "|" + subindex + "|" + period
+		i, ok := byKey[key]
+		if !ok {
+			i = len(out)
+			byKey[key] = i
+			out = append(out, models.IPCObservation{Region: region, Subindex: subindex, Period: period})
+		}
+		if index != nil {
+			out[i].IndexValue = index
+		}
+		if annual != nil {
+			out[i].AnnualRate = annual
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("ipc export: no header with a period column and index or rate columns")
+	}
+	return out, nil
+}
+
+// cell returns the trimmed field i of record, or "" when the row is short.
+func cell(record []string, i int) string {
+	if i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// detectSeparator picks the most frequent of ';', tab and ',' in the header
+// line (the first one mentioning the period column). Title lines before it
+// may contain commas of their own.
+func detectSeparator(raw []byte) rune {
+	lines := bytes.Split(raw, []byte("\n"))
+	header := lines[0]
+	for _, line := range lines {
+		if strings.Contains(textnorm.Normalise(string(line)), "period") {
+			header = line
+			break
+		}
+	}
+	semi, tab, comma := bytes.Count(header, []byte(";")), bytes.Count(header, []byte("\t")), bytes.Count(header, []byte(","))
+	switch {
+	case semi >= tab && semi >= comma:
+		return ';'
+	case tab >= comma:
+		return '\t'
+	default:
+		return ','
+	}
+}
+
+// latin1ToUTF8 re-encodes an ISO-8859-1 byte slice as UTF-8.
+func latin1ToUTF8(raw []byte) []byte {
+	out := make([]byte, 0, len(raw)+len(raw)/8)
+	for _, b := range raw {
+		out = utf8.AppendRune(out, rune(b))
+	}
+	return out
+}
+
+// parsePeriod converts a monthly period label to "YYYY-MM".
+func parsePeriod(label string) (string, error) {
+	var year, month string
+	if m := rePeriodINE.FindStringSubmatch(label); m != nil {
+		year, month = m[1], m[2]
+	} else if m := rePeriodISO.FindStringSubmatch(label); m != nil {
+		year, month = m[1], m[2]
+	} else if m := rePeriodSlash.FindStringSubmatch(label); m != nil {
+		year, month = m[2], m[1]
+	} else {
+		return "", fmt.Errorf("unrecognised period %q", label)
+	}
+	if n, _ := strconv.Atoi(month); n < 1 || n > 12 {
+		return "", fmt.Errorf("unrecognised period %q", label)
+	}
+	return year + "-" + month, nil
+}
+
+// parseNumber parses a value that may use a decimal comma and dots as
+// thousands separators. Empty cells and INE's ".." placeholder yield nil.
+func parseNumber(s string) (*float64, error) {
+	switch s {
+	case "", ".", "..", "-":
+		return nil, nil
+	}
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	return &v, nil
+}
+
+// regionCode maps a region label ("09 Cataluña", "Catalunya", "Nacional") to
+// "ES" or an ISO 3166-2:ES code. Unknown labels are returned normalised.
+func regionCode(label string) string {
+	name := textnorm.Normalise(label)
+	if m := reLeadingCode.FindStringSubmatch(name); m != nil {
+		if code, ok := ineRegions[m[1]]; ok {
+			return code
+		}
+	}
+	if code, ok := regionNames[name]; ok {
+		return code
+	}
+	return name
+}
+
+// subindexCode maps a subindex label to SubindexGeneral or its ECOICOP code:
+// "Índice general" → "general", "01 Alimentos y bebidas no alcohólicas" → "01".
+// Labels without a code are returned normalised.
+func subindexCode(label string) string {
+	name := textnorm.Normalise(label)
+	if m := reLeadingCode.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	if strings.Contains(name, "general") {
+		return SubindexGeneral
+	}
+	return name
+}
@@ -0,0 +1,94 @@
+package ipc_test
+
+import (
+	"strings"
+	"testing"
+
+	"basket-cost/internal/ipc"
+)
+
+func value(p *float64) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func TestParse_INELongLayout(t *testing.T) {
+	export := strings.Join([]string{
+		"Índices por comunidades autónomas: general y por grupos ECOICOP(50913)",
+		"",
+		"Comunidades y Ciudades Autónomas;Grupos ECOICOP;Tipo de dato;Periodo;Total",
+		"09 Cataluña;Índice general;Índice;2024M03;114,875",
+		"09 Cataluña;Índice general;Variación anual;2024M03;3,4",
+		"09 Cataluña;Índice general;Variación mensual;2024M03;0,8",
+		"09 Cataluña;01 Alimentos y bebidas no alcohólicas;Índice;2024M03;129,412",
+		"09 Cataluña;01 Alimentos y bebidas no alcohólicas;Variación anual;2024M03;..",
+		"Nacional;Índice general;Índice;2024M03;1.114,020",
+		"09 Cataluña;Índice general;Índice;2023;111,2",
+	}, "\n")
+
+	got, err := ipc.Parse(strings.NewReader(export), "XX")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []struct {
+		region, subindex, period string
+		index, annual            any
+	}{
+		{"CT", "general", "2024-03", 114.875, 0.034},
+		{"CT", "01", "2024-03", 129.412, nil},
+		{"ES", "general", "2024-03", 1114.02, nil},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d observations, got %d: %+v", len(want), len(got), got)
+	}
+	for i, w := range want {
+		o := got[i]
+		if o.Region != w.region || o.Subindex != w.subindex || o.Period != w.period ||
+			value(o.IndexValue) != w.index || value(o.AnnualRate) != w.annual {
+			t.Errorf("observation %d: want %v, got %s/%s %s index=%v annual=%v",
+				i, w, o.Region, o.Subindex, o.Period, value(o.IndexValue), value(o.AnnualRate))
+		}
+	}
+}
+
+func TestParse_IDESCATWideLayout_Latin1(t *testing.T) {
+	// "Període" and "Índex" encoded as ISO-8859-1.
+	export := "Per\xedode,\xcdndex,Variaci\xf3 mensual,Variaci\xf3 interanual\n" +
+		"2025-01,118.2,-0.5,2.9\n" +
+		"2025-02,118.5,0.3,\n"
+
+	got, err := ipc.Parse(strings.NewReader(export), "CT")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 observations, got %+v", got)
+	}
+	jan, feb := got[0], got[1]
+	if jan.Region != "CT" || jan.Subindex != ipc.SubindexGeneral || jan.Period != "2025-01" ||
+		value(jan.IndexValue) != 118.2 || value(jan.AnnualRate) != 0.029 {
+		t.Errorf("january: got %+v", jan)
+	}
+	if feb.Period != "2025-02" || value(feb.IndexValue) != 118.5 || feb.AnnualRate != nil {
+		t.Errorf("february: got %+v", feb)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name, export string
+	}{
+		{"no header", "foo;bar\n1;2\n"},
+		{"bad period", "Periodo;Índice\n2024 marzo;110\n"},
+		{"bad number", "Periodo;Índice\n2024M01;abc\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ipc.Parse(strings.NewReader(tt.export), "CT"); err == nil {
+				t.Error("want error, got nil")
+			}
+		})
+	}
+}
@@ -13,34 +13,69 @@ type User struct {
 
 // PriceRecord represents a single price observation for a product,
 // typically extracted from a digital receipt/ticket.
+// Price is the unit price; Quantity is the number of units on the ticket line
+// (0 is stored as 1), so the amount paid is Price × Quantity.
 type PriceRecord struct {
 	RecordID int64     `json:"recordId,omitempty"` // DB primary key; 0 for seed/anonymous records
 	Date     time.Time `json:"date"`
 	Price    float64   `json:"price"`
+	Quantity int       `json:"quantity,omitempty"`
 	Store    string    `json:"store,omitempty"`
+	// Anomaly is set by the importer when the price looks like a parse or
+	// keying error ("outlier" or "line_total") and cleared once a user
+	// confirms the record.
+	Anomaly string `json:"anomaly,omitempty"`
+	// BoughtBy is the username of the household member who bought it; empty
+	// for anonymous records.
+	BoughtBy string `json:"boughtBy,omitempty"`
 }
 
 // Product represents a grocery item with its price history.
+// Category is the retailer taxonomy path, e.g. "Lácteos y huevos > Leche y
+// bebidas vegetales"; it is empty until the enricher or a user sets it.
 type Product struct {
 	ID             string        `json:"id"`
 	Name           string        `json:"name"`
 	Category       string        `json:"category,omitempty"`
+	CategoryLocked bool          `json:"categoryLocked"`
 	ImageURL       string        `json:"imageUrl,omitempty"`
 	ImageURLLocked bool          `json:"imageUrlLocked"`
+	Tags           []string      `json:"tags,omitempty"`
+	Favourite      bool          `json:"favourite"`
 	CurrentPrice   float64       `json:"currentPrice"`
 	PriceHistory   []PriceRecord `json:"priceHistory"`
+	// InflationAdjusted is filled by the product detail endpoint; it is nil
+	// when no IPC data is available.
+	InflationAdjusted *InflationAdjustment `json:"inflationAdjusted,omitempty"`
+	// Forecast is filled by the product detail endpoint; it is nil when the
+	// product has no purchases.
+	Forecast *PriceForecast `json:"forecast,omitempty"`
 }
 
 // SearchResult is a lightweight version of Product returned in search listings.
+// PriceChangePercent is ((currentPrice - firstPrice) / firstPrice) * 100.
 type SearchResult struct {
-	ID               string  `json:"id"`
-	Name             string  `json:"name"`
-	Category         string  `json:"category,omitempty"`
-	ImageURL         string  `json:"imageUrl,omitempty"`
-	CurrentPrice     float64 `json:"currentPrice"`
-	MinPrice         float64 `json:"minPrice"`
-	MaxPrice         float64 `json:"maxPrice"`
-	LastPurchaseDate string  `json:"lastPurchaseDate,omitempty"`
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	Category           string   `json:"category,omitempty"`
+	ImageURL           string   `json:"imageUrl,omitempty"`
+	Tags               []string `json:"tags,omitempty"`
+	Favourite          bool     `json:"favourite"`
+	CurrentPrice       float64  `json:"currentPrice"`
+	MinPrice           float64  `json:"minPrice"`
+	MaxPrice           float64  `json:"maxPrice"`
+	PriceChangePercent float64  `json:"priceChangePercent"`
+	PurchaseCount      int      `json:"purchaseCount"`
+	LastPurchaseDate   string   `json:"lastPurchaseDate,omitempty"`
+}
+
+// SearchPage is one page of search results. Total counts every match across
+// all pages; NextCursor is empty on the last page. It is not encoded as is:
+// SearchHandler writes Results as the body and the rest as headers.
+type SearchPage struct {
+	Results    []SearchResult
+	Total      int
+	NextCursor string
 }
 
 // PriceRecordEntry is the unit of work for batch price-record persistence.
@@ -50,6 +85,17 @@ type PriceRecordEntry struct {
 	Record PriceRecord
 }
 
+// TicketHeader is the receipt-level data persisted with an imported ticket.
+// Total is the amount printed on the receipt, or Σ price × quantity over the
+// ticket's lines when the receipt shows none.
+type TicketHeader struct {
+	InvoiceNumber string
+	Store         string
+	Date          time.Time
+	Total         float64
+	Lines         int
+}
+
 // MostPurchasedProduct is a row in the "most purchased products" analytics ranking.
 // PurchaseCount reflects the total number of price records (i.e. ticket lines) for the product.
 type MostPurchasedProduct struct {
@@ -71,10 +117,296 @@ type PriceIncreaseProduct struct {
 	IncreasePercent float64 `json:"increasePercent"`
 }
 
+// PriceRankings groups the price-history rankings of the analytics view,
+// computed together from one read of the price records.
+type PriceRankings struct {
+	BiggestDecreases []PriceDecreaseProduct
+	MostVolatile     []VolatileProduct
+	LongestStable    []StablePriceProduct
+}
+
+// PriceDecreaseProduct is a row in the "biggest price drop" analytics ranking.
+// DecreasePercent is ((firstPrice - currentPrice) / firstPrice) * 100.
+type PriceDecreaseProduct struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	ImageURL        string  `json:"imageUrl,omitempty"`
+	FirstPrice      float64 `json:"firstPrice"`
+	CurrentPrice    float64 `json:"currentPrice"`
+	DecreasePercent float64 `json:"decreasePercent"`
+}
+
+// VolatileProduct is a row in the "most volatile prices" analytics ranking.
+// VariationPercent is the coefficient of variation of the product's prices
+// (standard deviation / mean × 100).
+type VolatileProduct struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	ImageURL         string  `json:"imageUrl,omitempty"`
+	PurchaseCount    int     `json:"purchaseCount"`
+	MeanPrice        float64 `json:"meanPrice"`
+	MinPrice         float64 `json:"minPrice"`
+	MaxPrice         float64 `json:"maxPrice"`
+	VariationPercent float64 `json:"variationPercent"`
+}
+
+// StablePriceProduct is a row in the "longest stable price" analytics ranking.
+// The product has cost CurrentPrice on every purchase from StableSince to
+// LastPurchaseDate (YYYY-MM-DD), PurchaseCount purchases spanning StableDays.
+type StablePriceProduct struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	ImageURL         string  `json:"imageUrl,omitempty"`
+	CurrentPrice     float64 `json:"currentPrice"`
+	StableSince      string  `json:"stableSince"`
+	LastPurchaseDate string  `json:"lastPurchaseDate"`
+	StableDays       int     `json:"stableDays"`
+	PurchaseCount    int     `json:"purchaseCount"`
+}
+
+// PriceChangeEvent is a change between two consecutive purchases of a product:
+// OldPrice on PreviousDate, NewPrice on Date (YYYY-MM-DD) at Store.
+// ChangePercent is ((newPrice - oldPrice) / oldPrice) * 100.
+type PriceChangeEvent struct {
+	ProductID     string  `json:"productId"`
+	Name          string  `json:"name"`
+	ImageURL      string  `json:"imageUrl,omitempty"`
+	Store         string  `json:"store,omitempty"`
+	PreviousDate  string  `json:"previousDate"`
+	Date          string  `json:"date"`
+	OldPrice      float64 `json:"oldPrice"`
+	NewPrice      float64 `json:"newPrice"`
+	ChangePercent float64 `json:"changePercent"`
+}
+
+// TagSpend is a row in the "spend by tag" analytics breakdown.
+// A product with several tags counts towards each of them.
+type TagSpend struct {
+	Tag           string  `json:"tag"`
+	ProductCount  int     `json:"productCount"`
+	PurchaseCount int     `json:"purchaseCount"`
+	TotalSpent    float64 `json:"totalSpent"`
+}
+
+// StoreSpend is the amount paid at one store within an analytics bucket.
+type StoreSpend struct {
+	Store string  `json:"store"`
+	Total float64 `json:"total"`
+}
+
+// SpendingPoint is one period of the spending time series. Period is the first
+// day of the period (YYYY-MM-DD); weeks start on Monday. Periods without
+// purchases are included with zero totals so the series has no gaps.
+type SpendingPoint struct {
+	Period        string       `json:"period"`
+	Total         float64      `json:"total"`
+	PurchaseCount int          `json:"purchaseCount"`
+	ByStore       []StoreSpend `json:"byStore"`
+}
+
+// SpendingSeries is the response body for GET /api/analytics/spending.
+// Amounts are price × quantity, rounded to cents.
+type SpendingSeries struct {
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Granularity string          `json:"granularity"`
+	Total       float64         `json:"total"`
+	Points      []SpendingPoint `json:"points"`
+}
+
+// BreakdownItem is one slice of a spending breakdown: a category, a
+// subcategory or a store. ChangePercent compares Total with PreviousTotal and
+// is null when nothing was spent in the previous period. SharePercent is
+// Total as a percentage of the breakdown's overall total.
+type BreakdownItem struct {
+	Name          string          `json:"name"`
+	Total         float64         `json:"total"`
+	PreviousTotal float64         `json:"previousTotal"`
+	ChangePercent *float64        `json:"changePercent"`
+	SharePercent  float64         `json:"sharePercent"`
+	Subcategories []BreakdownItem `json:"subcategories,omitempty"`
+}
+
+// SpendingBreakdown is the response body for GET /api/analytics/breakdown.
+// The previous period has the same length and ends the day before From.
+// Products without a category are grouped under an empty Name.
+type SpendingBreakdown struct {
+	From          string          `json:"from"`
+	To            string          `json:"to"`
+	PreviousFrom  string          `json:"previousFrom"`
+	PreviousTo    string          `json:"previousTo"`
+	Total         float64         `json:"total"`
+	PreviousTotal float64         `json:"previousTotal"`
+	ChangePercent *float64        `json:"changePercent"`
+	ByCategory    []BreakdownItem `json:"byCategory"`
+	ByStore       []BreakdownItem `json:"byStore"`
+	// Budget reports the household's budgets for the month of To, when it
+	// has any.
+	Budget *BudgetReport `json:"budget,omitempty"`
+}
+
+// InflationPoint is one period of the personal inflation series. Both indices
+// are 100 in the first period. IPCIndex is null once official data runs out.
+// MatchedProducts is the number of products priced in this period that had
+// also been bought before, i.e. that contributed to this period's link.
+type InflationPoint struct {
+	Period          string   `json:"period"`
+	PersonalIndex   float64  `json:"personalIndex"`
+	IPCIndex        *float64 `json:"ipcIndex"`
+	MatchedProducts int      `json:"matchedProducts"`
+}
+
+// InflationComparison is the response body for GET /api/analytics/inflation.
+// PersonalChangePercent is the household's basket inflation over the series;
+// IPCChangePercent is official inflation over the periods IPC covers.
+// IPCRegion and IPCSubindex identify the official series compared against.
+type InflationComparison struct {
+	Granularity           string           `json:"granularity"`
+	From                  string           `json:"from"`
+	To                    string           `json:"to"`
+	IPCRegion             string           `json:"ipcRegion"`
+	IPCSubindex           string           `json:"ipcSubindex"`
+	PersonalChangePercent float64          `json:"personalChangePercent"`
+	IPCChangePercent      *float64         `json:"ipcChangePercent"`
+	Points                []InflationPoint `json:"points"`
+}
+
+// DeflatedPrice is a price observation alongside its value in constant euros
+// of the base year of the enclosing InflationAdjustment.
+type DeflatedPrice struct {
+	Date      time.Time `json:"date"`
+	Price     float64   `json:"price"`
+	RealPrice float64   `json:"realPrice"`
+}
+
+// InflationAdjustment compares a product's price history with IPC.
+// The change percentages run from the first to the latest purchase;
+// RealChangePercent is the change in constant euros. Verdict is "faster",
+// "slower" or "in_line" (within one percentage point of IPC).
+type InflationAdjustment struct {
+	BaseYear             int             `json:"baseYear"`
+	History              []DeflatedPrice `json:"history"`
+	NominalChangePercent float64         `json:"nominalChangePercent"`
+	IPCChangePercent     float64         `json:"ipcChangePercent"`
+	RealChangePercent    float64         `json:"realChangePercent"`
+	Verdict              string          `json:"verdict"`
+}
+
+// PriceBaseline summarises what a household usually pays for a product:
+// the median price over its unflagged purchases.
+type PriceBaseline struct {
+	Median    float64
+	Purchases int
+}
+
+// FlaggedRecord is a price record awaiting review, next to the median price
+// of the product's other purchases (nil when there are none).
+type FlaggedRecord struct {
+	RecordID    int64    `json:"recordId"`
+	ProductID   string   `json:"productId"`
+	ProductName string   `json:"productName"`
+	Date        string   `json:"date"`
+	Price       float64  `json:"price"`
+	Quantity    int      `json:"quantity"`
+	Store       string   `json:"store,omitempty"`
+	Anomaly     string   `json:"anomaly"`
+	MedianPrice *float64 `json:"medianPrice"`
+}
+
+// MemberSpend is what one household member bought within the range of a
+// MemberSpending. SharePercent is Spent as a percentage of the household
+// total; Trips counts distinct shopping days per store.
+type MemberSpend struct {
+	UserID       int64   `json:"userId"`
+	Username     string  `json:"username"`
+	Spent        float64 `json:"spent"`
+	SharePercent float64 `json:"sharePercent"`
+	Purchases    int     `json:"purchases"`
+	Products     int     `json:"products"`
+	Trips        int     `json:"trips"`
+}
+
+// MemberSpending is the response body for GET /api/analytics/members: the
+// household's spend between From and To split by the member who bought.
+type MemberSpending struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Total   float64       `json:"total"`
+	Members []MemberSpend `json:"members"`
+}
+
+// PatternBucket aggregates the tickets that fall on one weekday, day of
+// month or part of the month. BigShops counts the tickets whose total reaches
+// the BigShopThreshold of the ShoppingPatterns they belong to.
+type PatternBucket struct {
+	Label         string  `json:"label"`
+	Tickets       int     `json:"tickets"`
+	Total         float64 `json:"total"`
+	AverageBasket float64 `json:"averageBasket"`
+	AverageLines  float64 `json:"averageLines"`
+	BigShops      int     `json:"bigShops"`
+}
+
+// StorePattern is the basket size and visit frequency of one store.
+// AverageDaysBetween is the mean gap between consecutive shopping days there,
+// nil with fewer than two.
+type StorePattern struct {
+	Store              string   `json:"store"`
+	Tickets            int      `json:"tickets"`
+	Total              float64  `json:"total"`
+	AverageBasket      float64  `json:"averageBasket"`
+	AverageLines       float64  `json:"averageLines"`
+	VisitsPerMonth     float64  `json:"visitsPerMonth"`
+	AverageDaysBetween *float64 `json:"averageDaysBetween"`
+}
+
+// ShoppingPatterns is the response body for GET /api/analytics/patterns: the
+// household's tickets between From and To aggregated by weekday (Monday
+// first), day of month (1 to 31), part of the month ("start" days 1-10,
+// "middle" 11-20, "end" 21-31) and store. BigShopThreshold is the basket
+// value of the top quarter of the tickets.
+type ShoppingPatterns struct {
+	From             string          `json:"from"`
+	To               string          `json:"to"`
+	Tickets          int             `json:"tickets"`
+	Total            float64         `json:"total"`
+	AverageBasket    float64         `json:"averageBasket"`
+	AverageLines     float64         `json:"averageLines"`
+	BigShopThreshold float64         `json:"bigShopThreshold"`
+	ByWeekday        []PatternBucket `json:"byWeekday"`
+	ByDayOfMonth     []PatternBucket `json:"byDayOfMonth"`
+	ByMonthPart      []PatternBucket `json:"byMonthPart"`
+	ByStore          []StorePattern  `json:"byStore"`
+}
+
+// ForecastPoint is the expected price of a product in Month ("YYYY-MM"),
+// with a 95% band around it.
+type ForecastPoint struct {
+	Month string  `json:"month"`
+	Price float64 `json:"price"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+}
+
+// PriceForecast projects a product's price for the months after the current
+// one, from its latest purchase (BasePrice on BaseDate). Method is "trend" (fitted to the price
+// history and pulled toward the IPC trend), "ipc" (too little history, the
+// IPC trend alone) or "flat" (neither is available).
+type PriceForecast struct {
+	Method              string          `json:"method"`
+	BaseDate            string          `json:"baseDate"`
+	BasePrice           float64         `json:"basePrice"`
+	AnnualChangePercent float64         `json:"annualChangePercent"`
+	Points              []ForecastPoint `json:"points"`
+}
+
 // AnalyticsResult is the top-level response body for GET /api/analytics.
 type AnalyticsResult struct {
 	MostPurchased    []MostPurchasedProduct `json:"mostPurchased"`
 	BiggestIncreases []PriceIncreaseProduct `json:"biggestIncreases"`
+	BiggestDecreases []PriceDecreaseProduct `json:"biggestDecreases"`
+	MostVolatile     []VolatileProduct      `json:"mostVolatile"`
+	LongestStable    []StablePriceProduct   `json:"longestStable"`
 }
 
 // IPCResult is the response body for GET /api/ipc?from=<year>.
@@ -87,6 +419,280 @@ type IPCResult struct {
 	AccumulatedRate float64 `json:"accumulated_rate"`
 }
 
+// IPCObservation is one month of an official IPC series. Region is "ES" or an
+// ISO 3166-2:ES community code, Subindex is "general" or an ECOICOP code and
+// Period is "YYYY-MM". Either value may be nil when the export omits it;
+// AnnualRate is a decimal (0.028 for 2.8%).
+type IPCObservation struct {
+	Region     string
+	Subindex   string
+	Period     string
+	IndexValue *float64
+	AnnualRate *float64
+}
+
+// Watch is a watchlist rule on a product. Threshold is a price in euros for
+// the price_above and price_below rules, a percentage for increase_percent and
+// nil for any_increase.
+type Watch struct {
+	ID           int64     `json:"id"`
+	ProductID    string    `json:"productId"`
+	ProductName  string    `json:"productName"`
+	Rule         string    `json:"rule"`
+	Threshold    *float64  `json:"threshold,omitempty"`
+	CurrentPrice float64   `json:"currentPrice"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Alert is an entry in a user's alert inbox. Kind names what raised it; for
+// watchlist alerts it is the rule that fired, and OldPrice/NewPrice are the
+// previous and new purchase prices on Date (YYYY-MM-DD). Budget alerts
+// ("budget_warning" at 80%, "budget_exceeded" at 100%) carry the budget's
+// Category, its monthly limit as Threshold and the month's Spent so far.
+type Alert struct {
+	ID            int64     `json:"id"`
+	Kind          string    `json:"kind"`
+	ProductID     string    `json:"productId,omitempty"`
+	ProductName   string    `json:"productName,omitempty"`
+	OldPrice      *float64  `json:"oldPrice,omitempty"`
+	NewPrice      *float64  `json:"newPrice,omitempty"`
+	ChangePercent *float64  `json:"changePercent,omitempty"`
+	Threshold     *float64  `json:"threshold,omitempty"`
+	BudgetID      *int64    `json:"budgetId,omitempty"`
+	Category      string    `json:"category,omitempty"`
+	Spent         *float64  `json:"spent,omitempty"`
+	Date          string    `json:"date,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Read          bool      `json:"read"`
+}
+
+// AlertInbox is the response body for GET /api/alerts.
+type AlertInbox struct {
+	Unread int     `json:"unread"`
+	Alerts []Alert `json:"alerts"`
+}
+
+// Budget is a monthly spending limit of the household, overall (empty
+// Category) or for one top-level category.
+type Budget struct {
+	ID           int64     `json:"id"`
+	Category     string    `json:"category"`
+	MonthlyLimit float64   `json:"monthlyLimit"`
+	CreatedBy    string    `json:"createdBy"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// BudgetStatus is a budget with the month's spend so far and the spend
+// projected for the whole month at the current daily rate. Status is "ok",
+// "warning" (80% used) or "exceeded" (100% used).
+type BudgetStatus struct {
+	Budget
+	Spent            float64 `json:"spent"`
+	Remaining        float64 `json:"remaining"`
+	UsedPercent      float64 `json:"usedPercent"`
+	ProjectedSpend   float64 `json:"projectedSpend"`
+	ProjectedPercent float64 `json:"projectedPercent"`
+	Status           string  `json:"status"`
+}
+
+// BudgetReport is the response body for GET /api/budgets: the household's
+// budgets for Month ("YYYY-MM") as of AsOf.
+type BudgetReport struct {
+	Month       string         `json:"month"`
+	AsOf        string         `json:"asOf"`
+	DaysElapsed int            `json:"daysElapsed"`
+	DaysInMonth int            `json:"daysInMonth"`
+	Budgets     []BudgetStatus `json:"budgets"`
+}
+
+// ProductGroupMember is a product linked into a ProductGroup, with the store,
+// price and date of the household's latest purchase of it.
+type ProductGroupMember struct {
+	ProductID   string  `json:"productId"`
+	Name        string  `json:"name"`
+	ImageURL    string  `json:"imageUrl,omitempty"`
+	Store       string  `json:"store,omitempty"`
+	LatestPrice float64 `json:"latestPrice"`
+	LatestDate  string  `json:"latestDate,omitempty"`
+}
+
+// ProductGroup links products that are equivalent for the household, such as
+// the same milk sold by different retailers.
+type ProductGroup struct {
+	ID        int64                `json:"id"`
+	Name      string               `json:"name"`
+	CreatedBy string               `json:"createdBy"`
+	CreatedAt time.Time            `json:"createdAt"`
+	Products  []ProductGroupMember `json:"products"`
+}
+
+// StorePrice is the latest price paid at Store for any product of a group.
+type StorePrice struct {
+	Store       string  `json:"store"`
+	ProductID   string  `json:"productId"`
+	ProductName string  `json:"productName"`
+	Price       float64 `json:"price"`
+	Date        string  `json:"date"`
+}
+
+// GroupComparison compares the stores selling a product group. Prices are
+// cheapest first. UnitSaving is the gap between the dearest and the cheapest
+// store; BasketSaving is that gap times BasketQuantity, the group's units in
+// the typical weekly basket.
+type GroupComparison struct {
+	GroupID        int64        `json:"groupId"`
+	Name           string       `json:"name"`
+	Prices         []StorePrice `json:"prices"`
+	CheapestStore  string       `json:"cheapestStore"`
+	UnitSaving     float64      `json:"unitSaving"`
+	BasketQuantity float64      `json:"basketQuantity"`
+	BasketSaving   float64      `json:"basketSaving"`
+}
+
+// StoreBasketCost is what the typical weekly basket costs at one store, using
+// its latest price for each item. Items the store has never priced are
+// counted in MissingItems and left out of Cost.
+type StoreBasketCost struct {
+	Store        string  `json:"store"`
+	Cost         float64 `json:"cost"`
+	PricedItems  int     `json:"pricedItems"`
+	MissingItems int     `json:"missingItems"`
+}
+
+// StoreComparison is the response body for GET /api/stores/compare. The
+// typical weekly basket is what the household bought between BasketFrom and
+// BasketTo, scaled to one week, with linked products counted as one item.
+// Stores are ranked by fewest missing items, then by cost.
+type StoreComparison struct {
+	BasketFrom  string            `json:"basketFrom"`
+	BasketTo    string            `json:"basketTo"`
+	BasketItems int               `json:"basketItems"`
+	Groups      []GroupComparison `json:"groups"`
+	Stores      []StoreBasketCost `json:"stores"`
+}
+
+// BasketItem is a product and how many units of it a basket holds.
+type BasketItem struct {
+	ProductID string  `json:"productId"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// BasketReplayItem describes one product of a replayed basket. FirstPurchase
+// is the date of its earliest known price (empty if it has none by To) and
+// LatestPrice the last known price up to To.
+type BasketReplayItem struct {
+	ProductID     string  `json:"productId"`
+	Name          string  `json:"name"`
+	Quantity      float64 `json:"quantity"`
+	FirstPurchase string  `json:"firstPurchase,omitempty"`
+	LatestPrice   float64 `json:"latestPrice"`
+}
+
+// BasketReplayPoint is the cost of the basket on Date, the last day of the
+// period starting on Period, with each product at its last known price.
+// Missing lists the products with no price yet, left out of Cost.
+type BasketReplayPoint struct {
+	Period  string   `json:"period"`
+	Date    string   `json:"date"`
+	Cost    float64  `json:"cost"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// BasketReplay is the response body for GET /api/basket/replay.
+// ChangePercent compares the last point with the first point at which every
+// product had a price; it is null when there is no such pair.
+type BasketReplay struct {
+	From          string              `json:"from"`
+	To            string              `json:"to"`
+	Granularity   string              `json:"granularity"`
+	Items         []BasketReplayItem  `json:"items"`
+	ChangePercent *float64            `json:"changePercent"`
+	Points        []BasketReplayPoint `json:"points"`
+}
+
+// ShoppingListItem is a product on a shopping list. CurrentPrice and Store
+// are those of the household's latest purchase (zero and empty if it never
+// bought the product); Subtotal is CurrentPrice × Quantity.
+type ShoppingListItem struct {
+	ID           int64   `json:"id"`
+	ProductID    string  `json:"productId"`
+	Name         string  `json:"name"`
+	ImageURL     string  `json:"imageUrl,omitempty"`
+	Quantity     float64 `json:"quantity"`
+	Checked      bool    `json:"checked"`
+	CurrentPrice float64 `json:"currentPrice"`
+	Store        string  `json:"store,omitempty"`
+	Subtotal     float64 `json:"subtotal"`
+}
+
+// ShoppingList is a household shopping list. EstimatedTotal adds up the
+// subtotals of all items; RemainingTotal only those not yet checked.
+type ShoppingList struct {
+	ID             int64              `json:"id"`
+	Name           string             `json:"name"`
+	CreatedBy      string             `json:"createdBy"`
+	CreatedAt      time.Time          `json:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt"`
+	ItemCount      int                `json:"itemCount"`
+	EstimatedTotal float64            `json:"estimatedTotal"`
+	RemainingTotal float64            `json:"remainingTotal"`
+	Items          []ShoppingListItem `json:"items"`
+}
+
+// RepurchasePrediction estimates when the household will next buy a product
+// from its typical repurchase interval (the median of the days between
+// purchases). DaysUntil is negative once the product is overdue. Status is
+// "ok", "due_soon" or "overdue".
+type RepurchasePrediction struct {
+	ProductID    string  `json:"productId"`
+	Name         string  `json:"name"`
+	ImageURL     string  `json:"imageUrl,omitempty"`
+	Purchases    int     `json:"purchases"`
+	IntervalDays float64 `json:"intervalDays"`
+	LastPurchase string  `json:"lastPurchase"`
+	NextPurchase string  `json:"nextPurchase"`
+	DaysUntil    int     `json:"daysUntil"`
+	Status       string  `json:"status"`
+}
+
+// DigestProduct is a product first bought by the household within a digest
+// period, with the price and store of that first purchase.
+type DigestProduct struct {
+	ProductID string  `json:"productId"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Store     string  `json:"store,omitempty"`
+	Date      string  `json:"date"`
+}
+
+// DigestInflation compares the household's basket inflation with IPC over the
+// twelve months ending with a digest period.
+type DigestInflation struct {
+	From                  string   `json:"from"`
+	To                    string   `json:"to"`
+	PersonalChangePercent float64  `json:"personalChangePercent"`
+	IPCChangePercent      *float64 `json:"ipcChangePercent"`
+}
+
+// Digest summarises a household's week or month of shopping. PriceChanges
+// holds the largest changes only; PriceIncreases and PriceDecreases count
+// them all. SpendChangePercent is nil when the previous period had no spend.
+// TicketsImported counts the imported tickets dated within the period.
+type Digest struct {
+	Period             string             `json:"period"`
+	From               string             `json:"from"`
+	To                 string             `json:"to"`
+	TotalSpent         float64            `json:"totalSpent"`
+	PreviousTotalSpent float64            `json:"previousTotalSpent"`
+	SpendChangePercent *float64           `json:"spendChangePercent"`
+	TicketsImported    int                `json:"ticketsImported"`
+	PriceIncreases     int                `json:"priceIncreases"`
+	PriceDecreases     int                `json:"priceDecreases"`
+	PriceChanges       []PriceChangeEvent `json:"priceChanges"`
+	NewProducts        []DigestProduct    `json:"newProducts"`
+	Inflation          DigestInflation    `json:"inflation"`
+}
+
 // Household represents a shared grocery group (e.g. people living together).
 // Members share ticket imports and purchase analytics.
 type Household struct {
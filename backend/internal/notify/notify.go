@@ -0,0 +1,129 @@
+// Package notify delivers domain events (ticket imported, price alert fired,
+// household invitation created) to users and external systems through
+// pluggable channels such as SMTP email and signed HTTP webhooks.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// EventKind identifies a domain event.
+type EventKind string
+
+const (
+	// EventTicketImported is published after a ticket upload is persisted.
+	// Data is a TicketImported.
+	EventTicketImported EventKind = "ticket.imported"
+	// EventPriceAlertFired is published for each alert a watchlist rule
+	// raises. Data is a models.Alert.
+	EventPriceAlertFired EventKind = "price_alert.fired"
+	// EventHouseholdInvitation is published when a member invites someone by
+	// email. Data is a HouseholdInvitation; Email is the invitee.
+	EventHouseholdInvitation EventKind = "household.invitation_created"
+)
+
+// Event is a domain event addressed to a user.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	// UserID is the user the event concerns (the inviter for invitations).
+	UserID int64 `json:"userId"`
+	// Email is the recipient address for channels that reach people. When
+	// empty the Notifier fills it in with the email of UserID, if any.
+	Email      string    `json:"email,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Data       any       `json:"data"`
+}
+
+// TicketImported is the payload of EventTicketImported.
+type TicketImported struct {
+	InvoiceNumber string `json:"invoiceNumber"`
+	LinesImported int    `json:"linesImported"`
+}
+
+// HouseholdInvitation is the payload of EventHouseholdInvitation. Webhooks
+// receive it without the Token.
+type HouseholdInvitation struct {
+	Token     string    `json:"token,omitempty"`
+	InvitedBy string    `json:"invitedBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Channel delivers events to one destination.
+type Channel interface {
+	// Name identifies the channel in logs.
+	Name() string
+	// Send delivers e. Channels that cannot address e (e.g. email without a
+	// recipient) return nil without doing anything.
+	Send(ctx context.Context, e Event) error
+}
+
+// UserLookup is the subset of store.Store used to resolve recipients.
+type UserLookup interface {
+	GetUserByID(id int64) (*models.User, error)
+}
+
+// deliveryTimeout bounds a single asynchronous delivery across all channels.
+const deliveryTimeout = 30 * time.Second
+
+// Notifier fans events out to its channels.
+type Notifier struct {
+	users    UserLookup
+	channels []Channel
+	pending  sync.WaitGroup
+}
+
+// New returns a Notifier that resolves recipients through users and delivers
+// to every channel.
+func New(users UserLookup, channels ...Channel) *Notifier {
+	return &Notifier{users: users, channels: channels}
+}
+
+// Deliver sends e to every channel and returns the joined channel errors.
+// A failing channel does not stop delivery to the others.
+func (n *Notifier) Deliver(ctx context.Context, e Event) error {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now().UTC()
+	}
+	if e.Email == "" && e.UserID != 0 {
+		u, err := n.users.GetUserByID(e.UserID)
+		if err != nil {
+			return fmt.Errorf("resolve recipient %d: %w", e.UserID, err)
+		}
+		if u != nil {
+			e.Email = u.Email
+		}
+	}
+	var errs []error
+	for _, c := range n.channels {
+		if err := c.Send(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Publish delivers e in the background so that request handlers never wait
+// on a mail server or webhook. Failures are logged.
+func (n *Notifier) Publish(e Event) {
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		if err := n.Deliver(ctx, e); err != nil {
+			log.Printf("notify: deliver %s for user %d: %v", e.Kind, e.UserID, err)
+		}
+	}()
+}
+
+// Wait blocks until every event passed to Publish has been delivered.
+func (n *Notifier) Wait() {
+	n.pending.Wait()
+}
@@ -0,0 +1,82 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/notify"
+)
+
+type fakeUsers map[int64]*models.User
+
+func (f fakeUsers) GetUserByID(id int64) (*models.User, error) { return f[id], nil }
+
+// recordingChannel keeps every event it is sent and fails when err is set.
+type recordingChannel struct {
+	mu     sync.Mutex
+	events []notify.Event
+	err    error
+}
+
+func (c *recordingChannel) Name() string { return "recording" }
+
+func (c *recordingChannel) Send(_ context.Context, e notify.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	return c.err
+}
+
+func TestDeliver_ResolvesRecipientEmail(t *testing.T) {
+	users := fakeUsers{1: {ID: 1, Username: "ana", Email: "ana@example.com"}}
+	ch := &recordingChannel{}
+	n := notify.New(users, ch)
+
+	if err := n.Deliver(context.Background(), notify.Event{Kind: notify.EventTicketImported, UserID: 1}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if err := n.Deliver(context.Background(), notify.Event{
+		Kind: notify.EventHouseholdInvitation, UserID: 1, Email: "bob@example.com",
+	}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	if len(ch.events) != 2 {
+		t.Fatalf("want 2 events, got %+v", ch.events)
+	}
+	if ch.events[0].Email != "ana@example.com" || ch.events[0].OccurredAt.IsZero() {
+		t.Errorf("want the user's email and a timestamp, got %+v", ch.events[0])
+	}
+	if ch.events[1].Email != "bob@example.com" {
+		t.Errorf("an explicit recipient must be kept, got %q", ch.events[1].Email)
+	}
+}
+
+func TestDeliver_FailingChannelDoesNotStopOthers(t *testing.T) {
+	failing := &recordingChannel{err: errors.New("boom")}
+	ok := &recordingChannel{}
+	n := notify.New(fakeUsers{}, failing, ok)
+
+	err := n.Deliver(context.Background(), notify.Event{Kind: notify.EventTicketImported})
+	if err == nil {
+		t.Error("expected the failing channel's error")
+	}
+	if len(ok.events) != 1 {
+		t.Errorf("second channel should still receive the event, got %d", len(ok.events))
+	}
+}
+
+func TestPublish_DeliversInBackground(t *testing.T) {
+	ch := &recordingChannel{}
+	n := notify.New(fakeUsers{}, ch)
+	for range 3 {
+		n.Publish(notify.Event{Kind: notify.EventTicketImported})
+	}
+	n.Wait()
+	if len(ch.events) != 3 {
+		t.Errorf("want 3 delivered events, got %d", len(ch.events))
+	}
+}
@@ -0,0 +1,283 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// SMTPConfig configures an SMTP channel. Username and Password are optional;
+// when set, PLAIN authentication is used (net/smtp only sends credentials
+// over TLS or to localhost).
+type SMTPConfig struct {
+	// Addr is the relay address as host:port.
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// smtpTimeout bounds a whole SMTP conversation when the caller's context
+// has no deadline.
+const smtpTimeout = 30 * time.Second
+
+// SMTP emails events to their recipient through a mail relay.
+type SMTP struct {
+	cfg  SMTPConfig
+	host string
+	auth smtp.Auth
+}
+
+// NewSMTP returns an SMTP channel for cfg.
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("smtp from address %q: %w", cfg.From, err)
+	}
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp address %q: %w", cfg.Addr, err)
+	}
+	s := &SMTP{cfg: cfg, host: host}
+	if cfg.Username != "" {
+		s.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return s, nil
+}
+
+// Name implements Channel.
+func (s *SMTP) Name() string { return "smtp" }
+
+// Send implements Channel. Events without a recipient address are skipped.
+func (s *SMTP) Send(ctx context.Context, e Event) error {
+	if e.Email == "" {
+		return nil
+	}
+	subject, text := renderEmail(e)
+	return s.SendMail(ctx, Mail{To: e.Email, Subject: subject, Text: text})
+}
+
+// Mail is an email with a plain-text body and an optional HTML alternative.
+type Mail struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// SendMail delivers m through the relay. The conversation is bounded by
+// ctx's deadline (smtpTimeout when it has none) and aborted when ctx is
+// cancelled, so that a hung relay cannot hold the caller.
+func (s *SMTP) SendMail(ctx context.Context, m Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg, err := s.compose(m)
+	if err != nil {
+		return err
+	}
+	from, _ := mail.ParseAddress(s.cfg.From)
+	if err := s.deliver(ctx, from.Address, m.To, msg); err != nil {
+		return fmt.Errorf("send mail to %s: %w", m.To, err)
+	}
+	return nil
+}
+
+// deliver runs the SMTP conversation of smtp.SendMail over a connection
+// whose deadline follows ctx.
+func (s *SMTP) deliver(ctx context.Context, from, to string, msg []byte) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Expire the connection at once when ctx is cancelled early.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) }) //nolint:errcheck
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose renders m as an RFC 5322 message with quoted-printable UTF-8
+// parts: text/plain alone, or multipart/alternative when m has HTML.
+func (s *SMTP) compose(m Mail) ([]byte, error) {
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("recipient %q: %w", m.To, err)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	// Folding the subject onto one line keeps product names from injecting headers.
+	subject := strings.Join(strings.Fields(m.Subject), " ")
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if m.HTML == "" {
+		if err := writePart(&buf, "text/plain", m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", m.Text},
+		{"text/html", m.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create mail part: %w", err)
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("close mail parts: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writePart writes the headers of a single-part body followed by body.
+func writePart(buf *bytes.Buffer, contentType, body string) error {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=UTF-8\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	return writeQuotedPrintable(buf, body)
+}
+
+// writeQuotedPrintable encodes body with CRLF line endings onto w.
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return fmt.Errorf("encode mail body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("encode mail body: %w", err)
+	}
+	return nil
+}
+
+// renderEmail returns the subject and plain-text body for e.
+func renderEmail(e Event) (subject, text string) {
+	switch d := e.Data.(type) {
+	case TicketImported:
+		return "Ticket importado",
+			fmt.Sprintf("Se ha importado el ticket %s con %d productos.\n", d.InvoiceNumber, d.LinesImported)
+	case models.Alert:
+		if d.Kind == store.AlertBudgetWarning || d.Kind == store.AlertBudgetExceeded {
+			return "Presupuesto " + budgetName(d.Category), budgetText(d)
+		}
+		return "Alerta de precio: " + d.ProductName, alertText(d)
+	case HouseholdInvitation:
+		return d.InvitedBy + " te invita a su hogar en basket-cost",
+			fmt.Sprintf("%s te ha invitado a compartir sus compras en basket-cost.\n\n"+
+				"Inicia sesión y acepta la invitación con este código:\n\n    %s\n\n"+
+				"La invitación caduca el %s.\n",
+				d.InvitedBy, d.Token, d.ExpiresAt.Format("02/01/2006 15:04 MST"))
+	}
+	return string(e.Kind), fmt.Sprintf("%+v\n", e.Data)
+}
+
+// alertText describes a price alert in one sentence.
+func alertText(a models.Alert) string {
+	if a.OldPrice == nil || a.NewPrice == nil {
+		return fmt.Sprintf("Se ha disparado una alerta (%s) para %s.\n", a.Kind, a.ProductName)
+	}
+	text := fmt.Sprintf("%s ha pasado de %.2f € a %.2f €", a.ProductName, *a.OldPrice, *a.NewPrice)
+	if a.ChangePercent != nil {
+		text += fmt.Sprintf(" (%+.2f%%)", *a.ChangePercent)
+	}
+	text += " el " + a.Date + "."
+	if a.Threshold != nil {
+		switch a.Kind {
+		case string(store.RulePriceAbove), string(store.RulePriceBelow):
+			text += fmt.Sprintf(" Tu umbral era %.2f €.", *a.Threshold)
+		case string(store.RuleIncreasePercent):
+			text += fmt.Sprintf(" Tu umbral era %.2f%%.", *a.Threshold)
+		}
+	}
+	return text + "\n"
+}
+
+// budgetName names a budget by its category, or as the overall budget.
+func budgetName(category string) string {
+	if category == "" {
+		return "general"
+	}
+	return "de " + category
+}
+
+// budgetText describes a budget alert in one sentence.
+func budgetText(a models.Alert) string {
+	if a.Spent == nil || a.Threshold == nil {
+		return fmt.Sprintf("Se ha disparado una alerta del presupuesto %s.\n", budgetName(a.Category))
+	}
+	what := "Has gastado más del 80% del"
+	if a.Kind == store.AlertBudgetExceeded {
+		what = "Has superado el"
+	}
+	return fmt.Sprintf("%s presupuesto %s del mes: %.2f € de %.2f € (último ticket del %s).\n",
+		what, budgetName(a.Category), *a.Spent, *a.Threshold, a.Date)
+}
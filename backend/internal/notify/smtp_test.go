@@ -0,0 +1,253 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/notify"
+)
+
+// receivedMail is a message accepted by fakeSMTP.
+type receivedMail struct {
+	from, to string
+	header   textproto.MIMEHeader
+	body     string
+}
+
+// fakeSMTP is a minimal SMTP stand-in that accepts every message and hands
+// it over on a channel. It returns the listen address.
+func fakeSMTP(t *testing.T) (string, <-chan receivedMail) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	mails := make(chan receivedMail, 8)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, mails)
+		}
+	}()
+	return ln.Addr().String(), mails
+}
+
+func serveSMTP(conn net.Conn, mails chan<- receivedMail) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	var m receivedMail
+	tp.PrintfLine("220 localhost ready")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			tp.PrintfLine("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			m.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			tp.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			m.to = strings.Trim(line[len("RCPT TO:"):], "<> ")
+			tp.PrintfLine("250 OK")
+		case cmd == "DATA":
+			tp.PrintfLine("354 go ahead")
+			r := textproto.NewReader(bufio.NewReader(tp.DotReader()))
+			m.header, _ = r.ReadMIMEHeader()
+			body, _ := io.ReadAll(quotedprintable.NewReader(r.R))
+			m.body = string(body)
+			tp.PrintfLine("250 OK")
+			mails <- m
+		case cmd == "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+func receive(t *testing.T, mails <-chan receivedMail) receivedMail {
+	t.Helper()
+	select {
+	case m := <-mails:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+		return receivedMail{}
+	}
+}
+
+func TestNewSMTP_RejectsInvalidConfig(t *testing.T) {
+	if _, err := notify.NewSMTP(notify.SMTPConfig{Addr: "localhost:25", From: "not an address"}); err == nil {
+		t.Error("expected error for invalid from address")
+	}
+	if _, err := notify.NewSMTP(notify.SMTPConfig{Addr: "localhost", From: "a@example.com"}); err == nil {
+		t.Error("expected error for address without port")
+	}
+}
+
+func TestSMTP_SendsPriceAlert(t *testing.T) {
+	addr, mails := fakeSMTP(t)
+	ch, err := notify.NewSMTP(notify.SMTPConfig{Addr: addr, From: "basket-cost <no-reply@example.com>"})
+	if err != nil {
+		t.Fatalf("NewSMTP: %v", err)
+	}
+
+	oldPrice, newPrice, pct, threshold := 1.00, 1.10, 10.0, 1.05
+	err = ch.Send(context.Background(), notify.Event{
+		Kind:  notify.EventPriceAlertFired,
+		Email: "ana@example.com",
+		Data: models.Alert{
+			Kind: "price_above", ProductName: "LECHE ENTERA", Date: "2025-02-10",
+			OldPrice: &oldPrice, NewPrice: &newPrice, ChangePercent: &pct, Threshold: &threshold,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	m := receive(t, mails)
+	if m.from != "no-reply@example.com" || m.to != "ana@example.com" {
+		t.Errorf("envelope: got from=%q to=%q", m.from, m.to)
+	}
+	if got := m.header.Get("Subject"); got != "Alerta de precio: LECHE ENTERA" {
+		t.Errorf("subject: got %q", got)
+	}
+	want := "LECHE ENTERA ha pasado de 1.00 € a 1.10 € (+10.00%) el 2025-02-10. Tu umbral era 1.05 €."
+	if !strings.Contains(m.body, want) {
+		t.Errorf("body: want %q in %q", want, m.body)
+	}
+}
+
+func TestSMTP_EncodesSubjectOnOneLine(t *testing.T) {
+	addr, mails := fakeSMTP(t)
+	ch, err := notify.NewSMTP(notify.SMTPConfig{Addr: addr, From: "no-reply@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTP: %v", err)
+	}
+	err = ch.SendMail(context.Background(), notify.Mail{
+		To: "ana@example.com", Subject: "Invitación\r\nBcc: evil@example.com", Text: "hola",
+	})
+	if err != nil {
+		t.Fatalf("SendMail: %v", err)
+	}
+	m := receive(t, mails)
+	if m.header.Get("Bcc") != "" {
+		t.Errorf("subject injected a header: %v", m.header)
+	}
+	if !strings.HasPrefix(m.header.Get("Subject"), "=?utf-8?q?") {
+		t.Errorf("want a Q-encoded subject, got %q", m.header.Get("Subject"))
+	}
+}
+
+func TestSMTP_SendsHTMLAlternative(t *testing.T) {
+	addr, mails := fakeSMTP(t)
+	ch, err := notify.NewSMTP(notify.SMTPConfig{Addr: addr, From: "no-reply@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTP: %v", err)
+	}
+	err = ch.SendMail(context.Background(), notify.Mail{
+		To: "ana@example.com", Subject: "Resumen", Text: "Gasto: 10 €", HTML: "<p>Gasto: 10 €</p>",
+	})
+	if err != nil {
+		t.Fatalf("SendMail: %v", err)
+	}
+	m := receive(t, mails)
+	if !strings.HasPrefix(m.header.Get("Content-Type"), "multipart/alternative; boundary=") {
+		t.Errorf("want multipart/alternative, got %q", m.header.Get("Content-Type"))
+	}
+	for _, want := range []string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8", "<p>Gasto: 10 €</p>"} {
+		if !strings.Contains(m.body, want) {
+			t.Errorf("body lacks %q:\n%s", want, m.body)
+		}
+	}
+}
+
+func TestSMTP_GivesUpOnHungRelay(t *testing.T) {
+	// A relay that accepts the connection and never greets.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(io.Discard, conn) //nolint:errcheck
+				conn.Close()
+			}()
+		}
+	}()
+
+	ch, err := notify.NewSMTP(notify.SMTPConfig{Addr: ln.Addr().String(), From: "basket@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTP: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = ch.SendMail(ctx, notify.Mail{To: "ana@example.com", Subject: "x", Text: "x"})
+	if err == nil {
+		t.Fatal("expected an error from a relay that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SendMail returned after %v; want it bounded by ctx", elapsed)
+	}
+}
+
+func TestSMTP_SkipsEventsWithoutRecipient(t *testing.T) {
+	ch, err := notify.NewSMTP(notify.SMTPConfig{Addr: "127.0.0.1:1", From: "no-reply@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTP: %v", err)
+	}
+	if err := ch.Send(context.Background(), notify.Event{Kind: notify.EventTicketImported}); err != nil {
+		t.Errorf("want nil for an event without email, got %v", err)
+	}
+}
+
+func TestSMTP_SendsBudgetAlert(t *testing.T) {
+	addr, mails := fakeSMTP(t)
+	ch, err := notify.NewSMTP(notify.SMTPConfig{Addr: addr, From: "no-reply@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTP: %v", err)
+	}
+
+	spent, limit := 152.3, 150.0
+	err = ch.Send(context.Background(), notify.Event{
+		Kind:  notify.EventPriceAlertFired,
+		Email: "ana@example.com",
+		Data:  models.Alert{Kind: "budget_exceeded", Category: "Frescos", Spent: &spent, Threshold: &limit, Date: "2025-03-20"},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	m := receive(t, mails)
+	if got := m.header.Get("Subject"); got != "Presupuesto de Frescos" {
+		t.Errorf("subject: got %q", got)
+	}
+	want := "Has superado el presupuesto de Frescos del mes: 152.30 € de 150.00 € (último ticket del 2025-03-20)."
+	if !strings.Contains(m.body, want) {
+		t.Errorf("body: want %q in %q", want, m.body)
+	}
+}
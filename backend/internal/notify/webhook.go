@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook request headers. The signature covers "<timestamp>.<body>" so that
+// receivers can reject replayed deliveries.
+const (
+	HeaderEvent     = "X-Basket-Event"
+	HeaderTimestamp = "X-Basket-Timestamp"
+	HeaderSignature = "X-Basket-Signature"
+)
+
+// webhookTimeout bounds a single webhook request.
+const webhookTimeout = 10 * time.Second
+
+// Webhook POSTs every event as JSON to a URL, signed with HMAC-SHA256.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook returns a Webhook channel posting to url and signing with secret.
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{url: url, secret: []byte(secret), client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Name implements Channel.
+func (w *Webhook) Name() string { return "webhook" }
+
+// Send implements Channel. Any response other than 2xx is an error.
+func (w *Webhook) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(webhookPayload(e))
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(e.Kind))
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, Sign(w.secret, ts, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16)) //nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// webhookPayload returns e without what only its recipient should see: the
+// recipient address and the invitation token, which would let the receiver
+// join the inviter's household.
+func webhookPayload(e Event) Event {
+	e.Email = ""
+	if inv, ok := e.Data.(HouseholdInvitation); ok {
+		inv.Token = ""
+		e.Data = inv
+	}
+	return e
+}
+
+// Sign returns the HeaderSignature value for a delivery: "sha256=" followed
+// by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the valid HeaderSignature for
+// timestamp and body under secret, in constant time.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,105 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"basket-cost/internal/notify"
+)
+
+func TestWebhook_PostsSignedEvent(t *testing.T) {
+	secret := []byte("s3cret")
+	var got struct {
+		kind, ts, sig string
+		body          []byte
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.kind = r.Header.Get(notify.HeaderEvent)
+		got.ts = r.Header.Get(notify.HeaderTimestamp)
+		got.sig = r.Header.Get(notify.HeaderSignature)
+		got.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ch := notify.NewWebhook(srv.URL, string(secret))
+	err := ch.Send(context.Background(), notify.Event{
+		Kind:   notify.EventTicketImported,
+		UserID: 7,
+		Data:   notify.TicketImported{InvoiceNumber: "A-1", LinesImported: 3},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if got.kind != string(notify.EventTicketImported) {
+		t.Errorf("event header: got %q", got.kind)
+	}
+	if !notify.Verify(secret, got.ts, got.body, got.sig) {
+		t.Errorf("signature %q does not verify", got.sig)
+	}
+	if notify.Verify([]byte("other"), got.ts, got.body, got.sig) {
+		t.Error("signature verified with the wrong secret")
+	}
+	var payload struct {
+		Kind   string                `json:"kind"`
+		UserID int64                 `json:"userId"`
+		Data   notify.TicketImported `json:"data"`
+	}
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if payload.UserID != 7 || payload.Data.InvoiceNumber != "A-1" || payload.Data.LinesImported != 3 {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
+
+func TestWebhook_OmitsRecipientAndInvitationToken(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	err := notify.NewWebhook(srv.URL, "x").Send(context.Background(), notify.Event{
+		Kind:   notify.EventHouseholdInvitation,
+		UserID: 7,
+		Email:  "invitee@example.com",
+		Data:   notify.HouseholdInvitation{Token: "secret-token", InvitedBy: "ana"},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if strings.Contains(string(body), "invitee@example.com") || strings.Contains(string(body), "secret-token") {
+		t.Errorf("webhook payload leaks the recipient or token: %s", body)
+	}
+	if !strings.Contains(string(body), `"invitedBy":"ana"`) {
+		t.Errorf("webhook payload lost the invitation: %s", body)
+	}
+}
+
+func TestWebhook_ErrorStatusFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := notify.NewWebhook(srv.URL, "x").Send(context.Background(), notify.Event{Kind: notify.EventTicketImported})
+	if err == nil {
+		t.Error("expected error for 502 response")
+	}
+}
+
+func TestSign_KnownVector(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac key
+	want := "sha256=9d713ed406bb7076d4123f0dc2c39d2df5c654ed4b0cd56b52c8b4c940bd63ae"
+	if got := notify.Sign([]byte("key"), "1700000000", []byte("{}")); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,322 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Spending analytics ----------
+
+// Granularity is the bucket size of a spending time series.
+type Granularity string
+
+const (
+	GranularityDay   Granularity = "day"
+	GranularityWeek  Granularity = "week"
+	GranularityMonth Granularity = "month"
+	GranularityYear  Granularity = "year"
+)
+
+// periodStartSQL returns the SQLite expression mapping price_records.date to
+// the first day of its period.
+func periodStartSQL(g Granularity) (string, error) {
+	switch g {
+	case GranularityDay:
+		return `date`, nil
+	case GranularityWeek:
+		// 'weekday 0' moves forward to Sunday; six days back is that week's Monday.
+		return `date(date, 'weekday 0', '-6 days')`, nil
+	case GranularityMonth:
+		return `strftime('%Y-%m-01', date)`, nil
+	case GranularityYear:
+		return `strftime('%Y-01-01', date)`, nil
+	default:
+		return "", fmt.Errorf("unknown granularity %q", g)
+	}
+}
+
+// PeriodStart returns the first day of the period containing t.
+func PeriodStart(t time.Time, g Granularity) time.Time {
+	t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch g {
+	case GranularityWeek:
+		offset := (int(t.Weekday()) + 6) % 7 // Monday → 0
+		return t.AddDate(0, 0, -offset)
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case GranularityYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return t
+	}
+}
+
+// nextPeriod returns the first day of the period after the one starting at t.
+func nextPeriod(t time.Time, g Granularity) time.Time {
+	switch g {
+	case GranularityWeek:
+		return t.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return t.AddDate(0, 1, 0)
+	case GranularityYear:
+		return t.AddDate(1, 0, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// AnalyticsFilter narrows the purchases GetMostPurchased and
+// GetBiggestPriceIncreases consider. A zero From or To leaves that end of the
+// range open, an empty Store matches every store (case-insensitively
+// otherwise) and Limit <= 0 returns every result.
+type AnalyticsFilter struct {
+	From  time.Time
+	To    time.Time
+	Store string
+	Limit int
+}
+
+// recordClause returns a condition restricting price_records to f, prefixed
+// with " AND ", and its arguments. prefix qualifies the column names, e.g. "pr.".
+// Records flagged for review are always left out.
+func (f AnalyticsFilter) recordClause(prefix string) (string, []any) {
+	clause := " AND " + prefix + "anomaly IS NULL"
+	var args []any
+	if !f.From.IsZero() {
+		clause += " AND " + prefix + "date >= ?"
+		args = append(args, f.From.Format(time.DateOnly))
+	}
+	if !f.To.IsZero() {
+		clause += " AND " + prefix + "date <= ?"
+		args = append(args, f.To.Format(time.DateOnly))
+	}
+	if f.Store != "" {
+		clause += " AND " + prefix + "store = ? COLLATE NOCASE"
+		args = append(args, f.Store)
+	}
+	return clause, args
+}
+
+// sqlLimit returns f.Limit as a LIMIT argument; SQLite treats -1 as no limit.
+func (f AnalyticsFilter) sqlLimit() int {
+	if f.Limit <= 0 {
+		return -1
+	}
+	return f.Limit
+}
+
+// roundCents rounds an amount in euros to two decimals.
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// GetSpendingSeries returns the amount userID's household paid (price ×
+// quantity) per period between from and to inclusive, broken down by store,
+// leaving out records flagged for review. Every period in the range is present, oldest first; stores within a period
+// are ordered by amount, descending.
+func (s *SQLiteStore) GetSpendingSeries(userID int64, from, to time.Time, g Granularity) (models.SpendingSeries, error) {
+	series := models.SpendingSeries{
+		From:        from.Format(time.DateOnly),
+		To:          to.Format(time.DateOnly),
+		Granularity: string(g),
+		Points:      []models.SpendingPoint{},
+	}
+	period, err := periodStartSQL(g)
+	if err != nil {
+		return series, err
+	}
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return series, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	args := append(append([]any{}, baseArgs...), from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	rows, err := s.db.Query(`
+		SELECT `+period+` AS period, store, SUM(price * quantity), COUNT(*)
+		FROM price_records
+		WHERE `+clause+` AND anomaly IS NULL AND date >= ? AND date <= ?
+		GROUP BY period, store
+	`, args...)
+	if err != nil {
+		return series, fmt.Errorf("get spending series: %w", err)
+	}
+	defer rows.Close()
+
+	byPeriod := make(map[string]*models.SpendingPoint)
+	for rows.Next() {
+		var periodStr, storeName string
+		var total float64
+		var count int
+		if err := rows.Scan(&periodStr, &storeName, &total, &count); err != nil {
+			return series, fmt.Errorf("scan spending row: %w", err)
+		}
+		pt := byPeriod[periodStr]
+		if pt == nil {
+			pt = &models.SpendingPoint{Period: periodStr}
+			byPeriod[periodStr] = pt
+		}
+		pt.Total += total
+		pt.PurchaseCount += count
+		pt.ByStore = append(pt.ByStore, models.StoreSpend{Store: storeName, Total: roundCents(total)})
+	}
+	if err := rows.Err(); err != nil {
+		return series, fmt.Errorf("iterate spending rows: %w", err)
+	}
+
+	var total float64
+	for p := PeriodStart(from, g); !p.After(to); p = nextPeriod(p, g) {
+		key := p.Format(time.DateOnly)
+		pt, ok := byPeriod[key]
+		if !ok {
+			series.Points = append(series.Points, models.SpendingPoint{Period: key, ByStore: []models.StoreSpend{}})
+			continue
+		}
+		total += pt.Total
+		pt.Total = roundCents(pt.Total)
+		sort.Slice(pt.ByStore, func(i, j int) bool {
+			if pt.ByStore[i].Total != pt.ByStore[j].Total {
+				return pt.ByStore[i].Total > pt.ByStore[j].Total
+			}
+			return pt.ByStore[i].Store < pt.ByStore[j].Store
+		})
+		series.Points = append(series.Points, *pt)
+	}
+	series.Total = roundCents(total)
+	return series, nil
+}
+
+// ---------- Spending breakdown ----------
+
+// changePercent returns the percentage change from prev to cur, rounded to two
+// decimals, or nil when prev is zero and the change is undefined.
+func changePercent(cur, prev float64) *float64 {
+	if prev == 0 {
+		return nil
+	}
+	v := math.Round((cur-prev)/prev*10000) / 100
+	return &v
+}
+
+// breakdownAcc accumulates current and previous totals for one breakdown item.
+type breakdownAcc struct {
+	cur, prev float64
+	sub       map[string]*breakdownAcc
+}
+
+func (a *breakdownAcc) child(name string) *breakdownAcc {
+	if a.sub == nil {
+		a.sub = make(map[string]*breakdownAcc)
+	}
+	c := a.sub[name]
+	if c == nil {
+		c = &breakdownAcc{}
+		a.sub[name] = c
+	}
+	return c
+}
+
+// items converts the children of a into BreakdownItems ordered by current
+// total, descending. Shares are relative to total.
+func (a *breakdownAcc) items(total float64) []models.BreakdownItem {
+	out := make([]models.BreakdownItem, 0, len(a.sub))
+	for name, c := range a.sub {
+		item := models.BreakdownItem{
+			Name:          name,
+			Total:         roundCents(c.cur),
+			PreviousTotal: roundCents(c.prev),
+			ChangePercent: changePercent(c.cur, c.prev),
+		}
+		if total > 0 {
+			item.SharePercent = math.Round(c.cur/total*10000) / 100
+		}
+		// A category whose only child is the unnamed one (no subcategory
+		// level) gets no Subcategories list.
+		if len(c.sub) > 1 || (len(c.sub) == 1 && c.sub[""] == nil) {
+			item.Subcategories = c.items(total)
+		}
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// GetSpendingBreakdown returns the amount userID's household paid between
+// from and to inclusive, broken down by top-level category (with
+// subcategories) and by store, each compared with the previous period of the
+// same length. Records flagged for review are left out.
+func (s *SQLiteStore) GetSpendingBreakdown(userID int64, from, to time.Time) (models.SpendingBreakdown, error) {
+	days := int(to.Sub(from).Hours()/24) + 1
+	prevTo := from.AddDate(0, 0, -1)
+	prevFrom := from.AddDate(0, 0, -days)
+	result := models.SpendingBreakdown{
+		From:         from.Format(time.DateOnly),
+		To:           to.Format(time.DateOnly),
+		PreviousFrom: prevFrom.Format(time.DateOnly),
+		PreviousTo:   prevTo.Format(time.DateOnly),
+		ByCategory:   []models.BreakdownItem{},
+		ByStore:      []models.BreakdownItem{},
+	}
+
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return result, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	args := []any{result.From, result.PreviousTo}
+	args = append(args, baseArgs...)
+	args = append(args, result.PreviousFrom, result.To)
+
+	rows, err := s.db.Query(`
+		SELECT
+			p.category,
+			pr.store,
+			SUM(CASE WHEN pr.date >= ? THEN pr.price * pr.quantity ELSE 0 END) AS cur,
+			SUM(CASE WHEN pr.date <= ? THEN pr.price * pr.quantity ELSE 0 END) AS prev
+		FROM price_records pr
+		JOIN products p ON p.id = pr.product_id
+		WHERE pr.`+clause+` AND pr.anomaly IS NULL AND pr.date >= ? AND pr.date <= ?
+		GROUP BY p.category, pr.store
+	`, args...)
+	if err != nil {
+		return result, fmt.Errorf("get spending breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	categories, stores := &breakdownAcc{}, &breakdownAcc{}
+	var total, prevTotal float64
+	for rows.Next() {
+		var category, storeName string
+		var cur, prev float64
+		if err := rows.Scan(&category, &storeName, &cur, &prev); err != nil {
+			return result, fmt.Errorf("scan spending breakdown: %w", err)
+		}
+		top, sub, _ := strings.Cut(category, categorySeparator)
+		for _, acc := range []*breakdownAcc{categories.child(top), categories.child(top).child(sub), stores.child(storeName)} {
+			acc.cur += cur
+			acc.prev += prev
+		}
+		total += cur
+		prevTotal += prev
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate spending breakdown: %w", err)
+	}
+
+	result.Total = roundCents(total)
+	result.PreviousTotal = roundCents(prevTotal)
+	result.ChangePercent = changePercent(total, prevTotal)
+	result.ByCategory = categories.items(total)
+	result.ByStore = stores.items(total)
+	return result, nil
+}
@@ -0,0 +1,157 @@
+package store_test
+
+import (
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// ---------- GetSpendingSeries ----------
+
+func seedSpending(t *testing.T, s *store.SQLiteStore, uid int64) {
+	t.Helper()
+	entries := []models.PriceRecordEntry{
+		{Name: "LECHE ENTERA", Record: models.PriceRecord{Date: date(2025, 1, 6), Price: 0.90, Quantity: 2, Store: "Mercadona"}}, // Mon
+		{Name: "PAN DE MOLDE", Record: models.PriceRecord{Date: date(2025, 1, 12), Price: 1.50, Store: "Bonpreu"}},               // Sun, same week
+		{Name: "LECHE ENTERA", Record: models.PriceRecord{Date: date(2025, 1, 13), Price: 0.95, Store: "Mercadona"}},             // next Mon
+		{Name: "CAFE MOLIDO", Record: models.PriceRecord{Date: date(2025, 3, 2), Price: 3.20, Store: "Mercadona"}},
+	}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("UpsertPriceRecordBatch: %v", err)
+	}
+}
+
+func TestGetSpendingSeries_Monthly_FillsGaps(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedSpending(t, s, uid)
+
+	series, err := s.GetSpendingSeries(uid, date(2025, 1, 1), date(2025, 3, 31), store.GranularityMonth)
+	if err != nil {
+		t.Fatalf("GetSpendingSeries: %v", err)
+	}
+	if len(series.Points) != 3 {
+		t.Fatalf("want 3 months, got %+v", series.Points)
+	}
+	jan, feb, mar := series.Points[0], series.Points[1], series.Points[2]
+	if jan.Period != "2025-01-01" || jan.Total != 4.25 || jan.PurchaseCount != 3 {
+		t.Errorf("january: want 4.25 over 3 lines, got %+v", jan)
+	}
+	if len(jan.ByStore) != 2 || jan.ByStore[0].Store != "Mercadona" || jan.ByStore[0].Total != 2.75 {
+		t.Errorf("january by store: want Mercadona 2.75 first, got %+v", jan.ByStore)
+	}
+	if feb.Period != "2025-02-01" || feb.Total != 0 || feb.ByStore == nil {
+		t.Errorf("february: want empty non-nil bucket, got %+v", feb)
+	}
+	if mar.Total != 3.20 {
+		t.Errorf("march: want 3.20, got %+v", mar)
+	}
+	if series.Total != 7.45 {
+		t.Errorf("total: want 7.45, got %.2f", series.Total)
+	}
+}
+
+func TestGetSpendingSeries_Weekly_StartsOnMonday(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedSpending(t, s, uid)
+
+	series, err := s.GetSpendingSeries(uid, date(2025, 1, 8), date(2025, 1, 19), store.GranularityWeek)
+	if err != nil {
+		t.Fatalf("GetSpendingSeries: %v", err)
+	}
+	if len(series.Points) != 2 {
+		t.Fatalf("want 2 weeks, got %+v", series.Points)
+	}
+	// The range starts mid-week, so records before 'from' in that week are excluded.
+	if series.Points[0].Period != "2025-01-06" || series.Points[0].Total != 1.50 {
+		t.Errorf("first week: want 2025-01-06 with 1.50, got %+v", series.Points[0])
+	}
+	if series.Points[1].Period != "2025-01-13" || series.Points[1].Total != 0.95 {
+		t.Errorf("second week: want 2025-01-13 with 0.95, got %+v", series.Points[1])
+	}
+}
+
+func TestGetSpendingSeries_ScopedToHousehold(t *testing.T) {
+	s := newTestStore(t)
+	alice := createTestUser2(t, s, "alice")
+	bob := createTestUser2(t, s, "bob")
+	seedSpending(t, s, alice)
+
+	series, err := s.GetSpendingSeries(bob, date(2025, 1, 1), date(2025, 3, 31), store.GranularityMonth)
+	if err != nil {
+		t.Fatalf("GetSpendingSeries: %v", err)
+	}
+	if series.Total != 0 {
+		t.Errorf("bob must not see alice's spend, got %.2f", series.Total)
+	}
+}
+
+// ---------- GetSpendingBreakdown ----------
+
+func TestGetSpendingBreakdown_ByCategoryAndStore_WithPreviousPeriod(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	entries := []models.PriceRecordEntry{
+		// Previous period (2025-01-01..2025-01-31).
+		{Name: "LECHE ENTERA", Record: models.PriceRecord{Date: date(2025, 1, 10), Price: 1.00, Quantity: 2, Store: "Mercadona"}},
+		// Current period (2025-02-01..2025-03-03, 31 days).
+		{Name: "LECHE ENTERA", Record: models.PriceRecord{Date: date(2025, 2, 10), Price: 1.00, Quantity: 3, Store: "Mercadona"}},
+		{Name: "YOGUR NATURAL", Record: models.PriceRecord{Date: date(2025, 2, 11), Price: 2.00, Store: "Bonpreu"}},
+		{Name: "PAN DE MOLDE", Record: models.PriceRecord{Date: date(2025, 2, 12), Price: 3.00, Store: "Bonpreu"}},
+	}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("UpsertPriceRecordBatch: %v", err)
+	}
+	for id, cat := range map[string]string{
+		"leche-entera":  "Lácteos y huevos > Leche",
+		"yogur-natural": "Lácteos y huevos > Yogures",
+	} {
+		if err := s.SetProductCategoryManual(id, cat); err != nil {
+			t.Fatalf("SetProductCategoryManual: %v", err)
+		}
+	}
+
+	b, err := s.GetSpendingBreakdown(uid, date(2025, 2, 1), date(2025, 3, 3))
+	if err != nil {
+		t.Fatalf("GetSpendingBreakdown: %v", err)
+	}
+	if b.PreviousFrom != "2025-01-01" || b.PreviousTo != "2025-01-31" {
+		t.Errorf("previous period: want 2025-01-01..2025-01-31, got %s..%s", b.PreviousFrom, b.PreviousTo)
+	}
+	if b.Total != 8 || b.PreviousTotal != 2 || b.ChangePercent == nil || *b.ChangePercent != 300 {
+		t.Errorf("totals: want 8 vs 2 (+300%%), got %+v", b)
+	}
+
+	if len(b.ByCategory) != 2 {
+		t.Fatalf("want 2 top-level categories, got %+v", b.ByCategory)
+	}
+	dairy := b.ByCategory[0]
+	if dairy.Name != "Lácteos y huevos" || dairy.Total != 5 || dairy.SharePercent != 62.5 {
+		t.Errorf("dairy: want 5.00 (62.5%%), got %+v", dairy)
+	}
+	if len(dairy.Subcategories) != 2 || dairy.Subcategories[0].Name != "Leche" || *dairy.Subcategories[0].ChangePercent != 50 {
+		t.Errorf("dairy subcategories: want Leche first at +50%%, got %+v", dairy.Subcategories)
+	}
+	uncategorised := b.ByCategory[1]
+	if uncategorised.Name != "" || uncategorised.Total != 3 || uncategorised.ChangePercent != nil || uncategorised.Subcategories != nil {
+		t.Errorf("uncategorised: want 3.00 with no change and no subcategories, got %+v", uncategorised)
+	}
+
+	if len(b.ByStore) != 2 || b.ByStore[0].Name != "Bonpreu" || b.ByStore[0].Total != 5 {
+		t.Errorf("by store: want Bonpreu 5.00 first, got %+v", b.ByStore)
+	}
+}
+
+func TestGetSpendingBreakdown_NoPurchases_ReturnsEmptySlices(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	b, err := s.GetSpendingBreakdown(uid, date(2025, 2, 1), date(2025, 2, 28))
+	if err != nil {
+		t.Fatalf("GetSpendingBreakdown: %v", err)
+	}
+	if b.ByCategory == nil || b.ByStore == nil || b.Total != 0 || b.ChangePercent != nil {
+		t.Errorf("want zero totals and empty non-nil slices, got %+v", b)
+	}
+}
@@ -0,0 +1,173 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Basket replay ----------
+
+// ErrInvalidBasket is returned (wrapped) by GetBasketReplay for an empty
+// basket, a non-positive quantity or an unknown product.
+var ErrInvalidBasket = errors.New("invalid basket")
+
+// GetBasketReplay prices a fixed basket at the end of every day, week or
+// month between from and to, using userID's household price history and
+// carrying each product's last known price forward. A zero from starts the
+// series at the earliest price of any product in the basket. Repeated
+// products are merged by adding up their quantities. Records flagged for
+// review are left out.
+func (s *SQLiteStore) GetBasketReplay(userID int64, items []models.BasketItem, from, to time.Time, g Granularity) (models.BasketReplay, error) {
+	result := models.BasketReplay{
+		Granularity: string(g),
+		To:          to.Format(time.DateOnly),
+		Items:       []models.BasketReplayItem{},
+		Points:      []models.BasketReplayPoint{},
+	}
+	if g != GranularityDay && g != GranularityWeek && g != GranularityMonth {
+		return result, fmt.Errorf("unsupported granularity %q for basket replay", g)
+	}
+	if len(items) == 0 {
+		return result, fmt.Errorf("%w: no products", ErrInvalidBasket)
+	}
+
+	byID := make(map[string]int)
+	var productIDs []any
+	for _, it := range items {
+		if it.Quantity <= 0 {
+			return result, fmt.Errorf("%w: quantity of %q must be positive", ErrInvalidBasket, it.ProductID)
+		}
+		if i, ok := byID[it.ProductID]; ok {
+			result.Items[i].Quantity += it.Quantity
+			continue
+		}
+		byID[it.ProductID] = len(result.Items)
+		result.Items = append(result.Items, models.BasketReplayItem{ProductID: it.ProductID, Quantity: it.Quantity})
+		productIDs = append(productIDs, it.ProductID)
+	}
+	in := "(" + strings.TrimSuffix(strings.Repeat("?,", len(productIDs)), ",") + ")"
+
+	if err := s.basketProductNames(result.Items, byID, in, productIDs); err != nil {
+		return result, err
+	}
+
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return result, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	args := append(append(append([]any{}, baseArgs...), productIDs...), to.Format(time.DateOnly))
+	rows, err := s.db.Query(`
+		SELECT product_id, date, price
+		FROM price_records
+		WHERE `+clause+` AND anomaly IS NULL AND product_id IN `+in+` AND date <= ?
+		ORDER BY date, id
+	`, args...)
+	if err != nil {
+		return result, fmt.Errorf("get basket prices: %w", err)
+	}
+	defer rows.Close()
+
+	histories := make([][]pricePoint, len(result.Items))
+	for rows.Next() {
+		var productID string
+		var p pricePoint
+		if err := rows.Scan(&productID, &p.date, &p.price); err != nil {
+			return result, fmt.Errorf("scan basket price: %w", err)
+		}
+		i := byID[productID]
+		histories[i] = append(histories[i], p)
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate basket prices: %w", err)
+	}
+
+	var earliest string
+	for i, h := range histories {
+		if len(h) == 0 {
+			continue
+		}
+		result.Items[i].FirstPurchase = h[0].date
+		result.Items[i].LatestPrice = h[len(h)-1].price
+		if earliest == "" || h[0].date < earliest {
+			earliest = h[0].date
+		}
+	}
+	if from.IsZero() {
+		if earliest == "" {
+			result.From = result.To
+			return result, nil
+		}
+		if from, err = time.Parse(time.DateOnly, earliest); err != nil {
+			return result, fmt.Errorf("parse date %q: %w", earliest, err)
+		}
+	}
+	result.From = from.Format(time.DateOnly)
+
+	// Walk the periods, advancing each product's cursor to its last price on
+	// or before the period's closing day.
+	next := make([]int, len(histories))
+	firstComplete := -1
+	for p := PeriodStart(from, g); !p.After(to); p = nextPeriod(p, g) {
+		asOf := nextPeriod(p, g).AddDate(0, 0, -1)
+		if asOf.After(to) {
+			asOf = to
+		}
+		day := asOf.Format(time.DateOnly)
+		point := models.BasketReplayPoint{Period: p.Format(time.DateOnly), Date: day}
+		for i, h := range histories {
+			for next[i] < len(h) && h[next[i]].date <= day {
+				next[i]++
+			}
+			if next[i] == 0 {
+				point.Missing = append(point.Missing, result.Items[i].ProductID)
+				continue
+			}
+			point.Cost += h[next[i]-1].price * result.Items[i].Quantity
+		}
+		point.Cost = roundCents(point.Cost)
+		if firstComplete < 0 && len(point.Missing) == 0 {
+			firstComplete = len(result.Points)
+		}
+		result.Points = append(result.Points, point)
+	}
+
+	if firstComplete >= 0 && firstComplete < len(result.Points)-1 {
+		first, last := result.Points[firstComplete].Cost, result.Points[len(result.Points)-1].Cost
+		if first > 0 {
+			pct := roundCents((last - first) / first * 100)
+			result.ChangePercent = &pct
+		}
+	}
+	return result, nil
+}
+
+// basketProductNames fills in the names of items, failing with
+// ErrInvalidBasket when one of the products does not exist.
+func (s *SQLiteStore) basketProductNames(items []models.BasketReplayItem, byID map[string]int, in string, productIDs []any) error {
+	rows, err := s.db.Query(`SELECT id, name FROM products WHERE id IN `+in, productIDs...)
+	if err != nil {
+		return fmt.Errorf("get basket products: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return fmt.Errorf("scan basket product: %w", err)
+		}
+		items[byID[id]].Name = name
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate basket products: %w", err)
+	}
+	for _, it := range items {
+		if it.Name == "" {
+			return fmt.Errorf("%w: unknown product %q", ErrInvalidBasket, it.ProductID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func TestGetBasketReplay_CarriesLastPriceForward(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "LECHE",
+		models.PriceRecord{Date: date(2025, 1, 7), Price: 1.00, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 2, 20), Price: 1.20, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 4, 2), Price: 9.99, Store: "Mercadona"}, // after 'to'
+	)
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 2, 3), Price: 0.50, Store: "Mercadona"})
+
+	basket := []models.BasketItem{
+		{ProductID: "leche", Quantity: 2},
+		{ProductID: "pan", Quantity: 1},
+		{ProductID: "leche", Quantity: 1}, // merged into the first line
+	}
+	got, err := s.GetBasketReplay(uid, basket, time.Time{}, date(2025, 3, 15), store.GranularityMonth)
+	if err != nil {
+		t.Fatalf("GetBasketReplay: %v", err)
+	}
+	if got.From != "2025-01-07" || len(got.Items) != 2 || got.Items[0].Quantity != 3 ||
+		got.Items[0].LatestPrice != 1.20 || got.Items[1].FirstPurchase != "2025-02-03" {
+		t.Errorf("unexpected replay header %+v", got)
+	}
+	want := []models.BasketReplayPoint{
+		{Period: "2025-01-01", Date: "2025-01-31", Cost: 3.00, Missing: []string{"pan"}},
+		{Period: "2025-02-01", Date: "2025-02-28", Cost: 4.10},
+		{Period: "2025-03-01", Date: "2025-03-15", Cost: 4.10},
+	}
+	if len(got.Points) != len(want) {
+		t.Fatalf("want %d points, got %+v", len(want), got.Points)
+	}
+	for i, w := range want {
+		p := got.Points[i]
+		if p.Period != w.Period || p.Date != w.Date || p.Cost != w.Cost || len(p.Missing) != len(w.Missing) {
+			t.Errorf("point %d: want %+v, got %+v", i, w, p)
+		}
+	}
+	// The first complete point is February, so there is no change yet.
+	if got.ChangePercent == nil || *got.ChangePercent != 0 {
+		t.Errorf("want 0%% change, got %v", got.ChangePercent)
+	}
+}
+
+func TestGetBasketReplay_WeeklyChange(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "LECHE",
+		models.PriceRecord{Date: date(2025, 3, 4), Price: 1.00, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 3, 18), Price: 1.10, Store: "Mercadona"},
+	)
+
+	got, err := s.GetBasketReplay(uid, []models.BasketItem{{ProductID: "leche", Quantity: 1}},
+		date(2025, 3, 3), date(2025, 3, 23), store.GranularityWeek)
+	if err != nil {
+		t.Fatalf("GetBasketReplay: %v", err)
+	}
+	costs := []float64{1.00, 1.00, 1.10}
+	if len(got.Points) != len(costs) {
+		t.Fatalf("want %d weekly points, got %+v", len(costs), got.Points)
+	}
+	for i, c := range costs {
+		if got.Points[i].Cost != c {
+			t.Errorf("week %d: want %v, got %v", i, c, got.Points[i].Cost)
+		}
+	}
+	if got.ChangePercent == nil || *got.ChangePercent != 10 {
+		t.Errorf("want +10%% change, got %v", got.ChangePercent)
+	}
+}
+
+func TestGetBasketReplay_InvalidBasket(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+	}})
+	tests := []struct {
+		name  string
+		items []models.BasketItem
+	}{
+		{"empty", nil},
+		{"zero quantity", []models.BasketItem{{ProductID: "leche"}}},
+		{"unknown product", []models.BasketItem{{ProductID: "nope", Quantity: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.GetBasketReplay(uid, tt.items, time.Time{}, date(2025, 2, 1), store.GranularityWeek)
+			if !errors.Is(err, store.ErrInvalidBasket) {
+				t.Errorf("want ErrInvalidBasket, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Budgets ----------
+
+var (
+	// ErrInvalidBudget is returned (wrapped) by SetBudget for a limit that is
+	// not positive or a category that is not a known top-level category.
+	ErrInvalidBudget = errors.New("invalid budget")
+	// ErrBudgetNotFound is returned when a budget does not exist or belongs
+	// to another household.
+	ErrBudgetNotFound = errors.New("budget not found")
+)
+
+// Budget statuses reported by GetBudgetReport, and the alert kinds raised
+// when an import takes a budget past the matching threshold.
+const (
+	BudgetOK       = "ok"
+	BudgetWarning  = "warning"
+	BudgetExceeded = "exceeded"
+
+	AlertBudgetWarning  = "budget_warning"
+	AlertBudgetExceeded = "budget_exceeded"
+)
+
+// budgetWarningPercent is the share of a budget at which it turns to warning.
+const budgetWarningPercent = 80
+
+// budgetThresholds maps each alerted percentage to its alert kind.
+var budgetThresholds = []struct {
+	percent int
+	kind    string
+}{
+	{budgetWarningPercent, AlertBudgetWarning},
+	{100, AlertBudgetExceeded},
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// budgetKeyExpr is the household_key of the user row it is selected from:
+// its household, or the user alone when they have none.
+const budgetKeyExpr = `CASE WHEN household_id IS NULL THEN 'u:' || id ELSE 'h:' || household_id END`
+
+// SetBudget sets the monthly limit of userID's household for category (empty
+// for the overall budget), replacing the limit if the household already has
+// a budget for it. Thresholds already alerted this month are forgotten when
+// the limit changes. Spend is tracked per top-level category, so category
+// must be the first level of some product's category path.
+func (s *SQLiteStore) SetBudget(userID int64, category string, limit float64) (*models.Budget, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("%w: the monthly limit must be positive", ErrInvalidBudget)
+	}
+	if category != "" {
+		var known bool
+		if !strings.Contains(category, categorySeparator) {
+			if err := s.db.QueryRow(
+				`SELECT EXISTS (SELECT 1 FROM products WHERE category = ? OR category LIKE ? ESCAPE '\')`,
+				category, escapeLike(category+categorySeparator)+"%",
+			).Scan(&known); err != nil {
+				return nil, fmt.Errorf("check category %q: %w", category, err)
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: %q is not a top-level category", ErrInvalidBudget, category)
+		}
+	}
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+	var budgetID int64
+	if err := tx.QueryRow(`
+		INSERT INTO budgets (user_id, household_key, category, monthly_limit, created_at, updated_at)
+		SELECT id, `+budgetKeyExpr+`, ?, ?, ?, ? FROM users WHERE id = ?
+		ON CONFLICT (household_key, category) DO UPDATE SET
+			monthly_limit = excluded.monthly_limit,
+			updated_at    = excluded.updated_at
+		RETURNING id
+	`, category, limit, now, now, userID).Scan(&budgetID); err != nil {
+		return nil, fmt.Errorf("set budget %q: %w", category, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM budget_crossings WHERE budget_id = ?`, budgetID); err != nil {
+		return nil, fmt.Errorf("reset budget %d alerts: %w", budgetID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit budget %q: %w", category, err)
+	}
+
+	budgets, err := householdBudgets(s.db, clause, baseArgs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range budgets {
+		if budgets[i].ID == budgetID {
+			return &budgets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("budget %d vanished", budgetID)
+}
+
+// rekeyBudgets moves the budgets created by userID to the household userID
+// belongs to now. Where that household already budgets a category, its
+// budget is kept and userID's is dropped.
+func rekeyBudgets(tx *sql.Tx, userID int64) error {
+	var key string
+	if err := tx.QueryRow(`SELECT `+budgetKeyExpr+` FROM users WHERE id = ?`, userID).Scan(&key); err != nil {
+		return fmt.Errorf("get budget key of user %d: %w", userID, err)
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM budgets
+		WHERE user_id = ? AND household_key <> ?
+		  AND category IN (SELECT category FROM budgets WHERE household_key = ?)
+	`, userID, key, key); err != nil {
+		return fmt.Errorf("drop budgets of user %d: %w", userID, err)
+	}
+	if _, err := tx.Exec(`UPDATE budgets SET household_key = ? WHERE user_id = ?`, key, userID); err != nil {
+		return fmt.Errorf("rekey budgets of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// DeleteBudget deletes one of the budgets of userID's household.
+func (s *SQLiteStore) DeleteBudget(userID, budgetID int64) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	res, err := s.db.Exec(`DELETE FROM budgets WHERE id = ? AND `+clause, append([]any{budgetID}, baseArgs...)...)
+	if err != nil {
+		return fmt.Errorf("delete budget %d: %w", budgetID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// GetBudgetReport returns the budgets of userID's household with the spend
+// of the month containing asOf up to asOf, and the month's projected spend
+// at the same daily rate. For a month already over, asOf is its last day.
+// Budgets are listed overall first, then by category.
+func (s *SQLiteStore) GetBudgetReport(userID int64, asOf time.Time) (models.BudgetReport, error) {
+	monthStart := PeriodStart(asOf, GranularityMonth)
+	monthEnd := nextPeriod(monthStart, GranularityMonth).AddDate(0, 0, -1)
+	if asOf.After(monthEnd) {
+		asOf = monthEnd
+	}
+	report := models.BudgetReport{
+		Month:       monthStart.Format("2006-01"),
+		AsOf:        asOf.Format(time.DateOnly),
+		DaysElapsed: asOf.Day(),
+		DaysInMonth: monthEnd.Day(),
+		Budgets:     []models.BudgetStatus{},
+	}
+
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return report, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	budgets, err := householdBudgets(s.db, clause, baseArgs)
+	if err != nil || len(budgets) == 0 {
+		return report, err
+	}
+	total, byCategory, err := monthSpend(s.db, clause, baseArgs, monthStart.Format(time.DateOnly), report.AsOf)
+	if err != nil {
+		return report, err
+	}
+
+	for _, b := range budgets {
+		st := models.BudgetStatus{Budget: b, Spent: total}
+		if b.Category != "" {
+			st.Spent = byCategory[b.Category]
+		}
+		st.Spent = roundCents(st.Spent)
+		st.Remaining = roundCents(b.MonthlyLimit - st.Spent)
+		st.UsedPercent = roundCents(st.Spent / b.MonthlyLimit * 100)
+		st.ProjectedSpend = roundCents(st.Spent / float64(report.DaysElapsed) * float64(report.DaysInMonth))
+		st.ProjectedPercent = roundCents(st.ProjectedSpend / b.MonthlyLimit * 100)
+		st.Status = budgetStatus(st.Spent, b.MonthlyLimit)
+		report.Budgets = append(report.Budgets, st)
+	}
+	return report, nil
+}
+
+// budgetStatus classifies spent against limit.
+func budgetStatus(spent, limit float64) string {
+	switch {
+	case spent >= limit:
+		return BudgetExceeded
+	case spent >= limit*budgetWarningPercent/100:
+		return BudgetWarning
+	}
+	return BudgetOK
+}
+
+// householdBudgets loads the budgets owned by the members in clause, overall
+// first and then by category.
+func householdBudgets(q queryer, clause string, baseArgs []any) ([]models.Budget, error) {
+	rows, err := q.Query(`
+		SELECT b.id, b.category, b.monthly_limit, u.username, b.updated_at
+		FROM budgets b
+		JOIN users u ON u.id = b.user_id
+		WHERE b.`+clause+`
+		ORDER BY b.category, b.id
+	`, baseArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("get budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := []models.Budget{}
+	for rows.Next() {
+		var b models.Budget
+		var updatedAt string
+		if err := rows.Scan(&b.ID, &b.Category, &b.MonthlyLimit, &b.CreatedBy, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan budget: %w", err)
+		}
+		if b.UpdatedAt, err = time.Parse(time.RFC3339, updatedAt); err != nil {
+			return nil, fmt.Errorf("parse budget updated_at: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+// monthSpend returns what the members in clause spent between from and to
+// inclusive (YYYY-MM-DD), in total and by top-level category.
+func monthSpend(q queryer, clause string, baseArgs []any, from, to string) (float64, map[string]float64, error) {
+	rows, err := q.Query(`
+		SELECT p.category, SUM(pr.price * pr.quantity)
+		FROM price_records pr
+		JOIN products p ON p.id = pr.product_id
+		WHERE pr.`+clause+` AND pr.date BETWEEN ? AND ?
+		GROUP BY p.category
+	`, append(append([]any{}, baseArgs...), from, to)...)
+	if err != nil {
+		return 0, nil, fmt.Errorf("get month spend: %w", err)
+	}
+	defer rows.Close()
+
+	var total float64
+	byCategory := make(map[string]float64)
+	for rows.Next() {
+		var category string
+		var spent float64
+		if err := rows.Scan(&category, &spent); err != nil {
+			return 0, nil, fmt.Errorf("scan month spend: %w", err)
+		}
+		top, _, _ := strings.Cut(category, categorySeparator)
+		byCategory[top] += spent
+		total += spent
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("iterate month spend: %w", err)
+	}
+	return total, byCategory, nil
+}
+
+// evaluateBudgets raises an alert for every member of the household in
+// memberIDs each time the spend of a month touched by an import crosses 80%
+// or 100% of one of its budgets, and returns them. days maps each month
+// ("YYYY-MM") to the latest purchase date of the import within it. Months
+// before the household's latest purchase (back-filled history) raise no
+// alerts.
+func evaluateBudgets(tx *sql.Tx, memberIDs []int64, days map[string]string) ([]firedAlert, error) {
+	clause, baseArgs := userIDsInClause(memberIDs)
+	budgets, err := householdBudgets(tx, clause, baseArgs)
+	if err != nil || len(budgets) == 0 {
+		return nil, err
+	}
+
+	months := make([]string, 0, len(days))
+	for m := range days {
+		months = append(months, m)
+	}
+	sort.Strings(months)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	var fired []firedAlert
+	for _, month := range months {
+		from, err := time.Parse("2006-01", month)
+		if err != nil {
+			return nil, fmt.Errorf("parse month %q: %w", month, err)
+		}
+		to := nextPeriod(from, GranularityMonth).AddDate(0, 0, -1).Format(time.DateOnly)
+		var newer bool
+		if err := tx.QueryRow(
+			`SELECT EXISTS (SELECT 1 FROM price_records WHERE `+clause+` AND date > ?)`,
+			append(append([]any{}, baseArgs...), to)...,
+		).Scan(&newer); err != nil {
+			return nil, fmt.Errorf("check newer records: %w", err)
+		}
+		if newer {
+			continue
+		}
+		total, byCategory, err := monthSpend(tx, clause, baseArgs, from.Format(time.DateOnly), to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range budgets {
+			spent := total
+			if b.Category != "" {
+				spent = byCategory[b.Category]
+			}
+			spent = roundCents(spent)
+			for _, th := range budgetThresholds {
+				if spent < b.MonthlyLimit*float64(th.percent)/100 {
+					continue
+				}
+				res, err := tx.Exec(
+					`INSERT OR IGNORE INTO budget_crossings (budget_id, month, percent) VALUES (?, ?, ?)`,
+					b.ID, month, th.percent,
+				)
+				if err != nil {
+					return nil, fmt.Errorf("record budget %d crossing: %w", b.ID, err)
+				}
+				if !rowsInserted(res) {
+					continue
+				}
+				for _, memberID := range memberIDs {
+					a := models.Alert{
+						Kind: th.kind, BudgetID: &b.ID, Category: b.Category, Threshold: &b.MonthlyLimit,
+						Spent: &spent, Date: days[month], CreatedAt: now,
+					}
+					res, err := tx.Exec(`
+						INSERT INTO alerts (user_id, kind, budget_id, category, threshold, spent, date, created_at)
+						VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+					`, memberID, a.Kind, b.ID, b.Category, b.MonthlyLimit, spent, a.Date, now.Format(time.RFC3339))
+					if err != nil {
+						return nil, fmt.Errorf("insert alert for budget %d: %w", b.ID, err)
+					}
+					if a.ID, err = res.LastInsertId(); err != nil {
+						return nil, fmt.Errorf("get last insert id: %w", err)
+					}
+					fired = append(fired, firedAlert{userID: memberID, alert: a})
+				}
+			}
+		}
+	}
+	return fired, nil
+}
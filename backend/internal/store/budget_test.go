@@ -0,0 +1,285 @@
+package store_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// seedCategory adds a product "leche" of uid in category, so that budgets
+// may name the category's top level.
+func seedCategory(t *testing.T, s *store.SQLiteStore, uid int64, category string) {
+	t.Helper()
+	importRecords(t, s, uid, "LECHE", models.PriceRecord{Date: date(2025, 1, 10), Price: 1, Store: "Mercadona"})
+	if err := s.SetProductCategoryManual("leche", category); err != nil {
+		t.Fatalf("SetProductCategoryManual: %v", err)
+	}
+}
+
+func TestSetBudget_UpsertsPerCategory(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+
+	if _, err := s.SetBudget(uid, "", 0); !errors.Is(err, store.ErrInvalidBudget) {
+		t.Errorf("want ErrInvalidBudget for a zero limit, got %v", err)
+	}
+	first, err := s.SetBudget(uid, "", 300)
+	if err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	second, err := s.SetBudget(uid, "", 250)
+	if err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	if second.ID != first.ID || second.MonthlyLimit != 250 || second.CreatedBy != "testuser" {
+		t.Errorf("want budget %d updated to 250, got %+v", first.ID, second)
+	}
+
+	other := createTestUser2(t, s, "other")
+	if err := s.DeleteBudget(other, first.ID); !errors.Is(err, store.ErrBudgetNotFound) {
+		t.Errorf("want ErrBudgetNotFound for another household, got %v", err)
+	}
+	if err := s.DeleteBudget(uid, first.ID); err != nil {
+		t.Fatalf("DeleteBudget: %v", err)
+	}
+	report, err := s.GetBudgetReport(uid, date(2025, 3, 10))
+	if err != nil {
+		t.Fatalf("GetBudgetReport: %v", err)
+	}
+	if len(report.Budgets) != 0 {
+		t.Errorf("want no budgets after delete, got %+v", report.Budgets)
+	}
+}
+
+func TestSetBudget_RejectsUnknownCategory(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedCategory(t, s, uid, "Lácteos y huevos > Leche")
+	for _, category := range []string{"Lácteos y huevos > Leche", "Lácteos", "Bebidas"} {
+		if _, err := s.SetBudget(uid, category, 50); !errors.Is(err, store.ErrInvalidBudget) {
+			t.Errorf("want ErrInvalidBudget for category %q, got %v", category, err)
+		}
+	}
+	if _, err := s.SetBudget(uid, "Lácteos y huevos", 50); err != nil {
+		t.Errorf("SetBudget for a top-level category: %v", err)
+	}
+}
+
+func TestSetBudget_ConcurrentMembersShareOneBudget(t *testing.T) {
+	s := newTestStore(t)
+	uid, partner := newHousehold(t, s)
+	seedCategory(t, s, uid, "Lácteos > Leche")
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			member := uid
+			if i%2 == 1 {
+				member = partner
+			}
+			if _, err := s.SetBudget(member, "Lácteos", float64(100+i)); err != nil {
+				t.Errorf("SetBudget: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report, err := s.GetBudgetReport(partner, date(2025, 3, 10))
+	if err != nil {
+		t.Fatalf("GetBudgetReport: %v", err)
+	}
+	if len(report.Budgets) != 1 {
+		t.Errorf("want one household budget, got %+v", report.Budgets)
+	}
+}
+
+func TestAddUserToHousehold_KeepsTheHouseholdBudget(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	joiner := createTestUser2(t, s, "joiner")
+	hid, err := s.CreateHousehold(uid)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	seedCategory(t, s, uid, "Lácteos > Leche")
+	kept, err := s.SetBudget(uid, "", 300)
+	if err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	if _, err := s.SetBudget(joiner, "", 200); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	if _, err := s.SetBudget(joiner, "Lácteos", 50); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+
+	if err := s.AddUserToHousehold(joiner, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+	report, err := s.GetBudgetReport(joiner, date(2025, 3, 10))
+	if err != nil {
+		t.Fatalf("GetBudgetReport: %v", err)
+	}
+	if len(report.Budgets) != 2 || report.Budgets[0].ID != kept.ID || report.Budgets[1].Category != "Lácteos" {
+		t.Fatalf("want the household overall budget and the joiner's Lácteos, got %+v", report.Budgets)
+	}
+	updated, err := s.SetBudget(joiner, "Lácteos", 60)
+	if err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	if updated.ID != report.Budgets[1].ID {
+		t.Errorf("want the joiner's budget updated in place, got %+v", updated)
+	}
+
+	if err := s.RemoveUserFromHousehold(joiner); err != nil {
+		t.Fatalf("RemoveUserFromHousehold: %v", err)
+	}
+	if report, err = s.GetBudgetReport(uid, date(2025, 3, 10)); err != nil {
+		t.Fatalf("GetBudgetReport: %v", err)
+	}
+	if len(report.Budgets) != 1 || report.Budgets[0].ID != kept.ID {
+		t.Errorf("want the joiner's budget to leave with them, got %+v", report.Budgets)
+	}
+	if _, err := s.SetBudget(joiner, "Lácteos", 70); err != nil {
+		t.Fatalf("SetBudget after leaving: %v", err)
+	}
+}
+
+func TestGetBudgetReport_SpendAndProjection(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "LECHE ENTERA",
+		models.PriceRecord{Date: date(2025, 2, 20), Price: 1.00, Quantity: 4, Store: "Mercadona"}, // previous month
+		models.PriceRecord{Date: date(2025, 3, 5), Price: 1.00, Quantity: 3, Store: "Mercadona"},
+	)
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 8), Price: 5.00, Store: "Mercadona"})
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 20), Price: 5.00, Store: "Mercadona"}) // after asOf
+	if err := s.SetProductCategoryManual("leche-entera", "Lácteos y huevos > Leche"); err != nil {
+		t.Fatalf("SetProductCategoryManual: %v", err)
+	}
+	if _, err := s.SetBudget(uid, "Lácteos y huevos", 10); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	if _, err := s.SetBudget(uid, "", 100); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+
+	got, err := s.GetBudgetReport(uid, date(2025, 3, 10))
+	if err != nil {
+		t.Fatalf("GetBudgetReport: %v", err)
+	}
+	if got.Month != "2025-03" || got.AsOf != "2025-03-10" || got.DaysElapsed != 10 || got.DaysInMonth != 31 {
+		t.Errorf("unexpected report period %+v", got)
+	}
+	if len(got.Budgets) != 2 {
+		t.Fatalf("want 2 budgets, got %+v", got.Budgets)
+	}
+	overall, dairy := got.Budgets[0], got.Budgets[1]
+	// 8 € in 10 days projects to 24.80 € over 31.
+	if overall.Category != "" || overall.Spent != 8 || overall.Remaining != 92 || overall.UsedPercent != 8 ||
+		overall.ProjectedSpend != 24.8 || overall.ProjectedPercent != 24.8 || overall.Status != store.BudgetOK {
+		t.Errorf("unexpected overall budget %+v", overall)
+	}
+	if dairy.Category != "Lácteos y huevos" || dairy.Spent != 3 || dairy.UsedPercent != 30 ||
+		dairy.ProjectedSpend != 9.3 || dairy.Status != store.BudgetOK {
+		t.Errorf("unexpected dairy budget %+v", dairy)
+	}
+
+	// A past month is reported as of its last day.
+	feb, err := s.GetBudgetReport(uid, date(2025, 2, 15))
+	if err != nil {
+		t.Fatalf("GetBudgetReport: %v", err)
+	}
+	if feb.DaysElapsed != 15 || feb.Budgets[0].Spent != 0 {
+		t.Errorf("unexpected February report %+v", feb)
+	}
+	apr, err := s.GetBudgetReport(uid, date(2025, 4, 2))
+	if err != nil {
+		t.Fatalf("GetBudgetReport: %v", err)
+	}
+	if apr.Month != "2025-04" || apr.Budgets[0].Spent != 0 || apr.Budgets[0].ProjectedSpend != 0 {
+		t.Errorf("unexpected April report %+v", apr)
+	}
+}
+
+func TestUpsertPriceRecordBatch_BudgetAlertsFireOncePerThreshold(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	partner := createTestUser2(t, s, "partner")
+	hid, err := s.CreateHousehold(uid)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := s.AddUserToHousehold(partner, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+	budget, err := s.SetBudget(uid, "", 10)
+	if err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	var heard []int64
+	s.SetAlertListener(func(userID int64, a models.Alert) { heard = append(heard, userID) })
+
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 5), Price: 7.00, Store: "Mercadona"})     // 70%
+	importRecords(t, s, partner, "PAN", models.PriceRecord{Date: date(2025, 3, 6), Price: 1.50, Store: "Lidl"})      // 85%
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 7), Price: 0.20, Store: "Mercadona"})     // 87%
+	importRecords(t, s, partner, "PAN", models.PriceRecord{Date: date(2025, 3, 8), Price: 2.00, Store: "Mercadona"}) // 107%
+
+	for _, member := range []int64{uid, partner} {
+		inbox, err := s.GetAlerts(member, false, 0)
+		if err != nil {
+			t.Fatalf("GetAlerts: %v", err)
+		}
+		if len(inbox.Alerts) != 2 {
+			t.Fatalf("member %d: want 2 budget alerts, got %+v", member, inbox.Alerts)
+		}
+		kinds := map[string]models.Alert{}
+		for _, a := range inbox.Alerts {
+			kinds[a.Kind] = a
+		}
+		warning, exceeded := kinds[store.AlertBudgetWarning], kinds[store.AlertBudgetExceeded]
+		if warning.BudgetID == nil || *warning.BudgetID != budget.ID || *warning.Spent != 8.5 ||
+			*warning.Threshold != 10 || warning.Date != "2025-03-06" {
+			t.Errorf("member %d: unexpected warning alert %+v", member, warning)
+		}
+		if exceeded.Spent == nil || *exceeded.Spent != 10.7 || exceeded.Date != "2025-03-08" {
+			t.Errorf("member %d: unexpected exceeded alert %+v", member, exceeded)
+		}
+	}
+	if len(heard) != 4 {
+		t.Errorf("want 4 alerts reported, got %v", heard)
+	}
+
+	// Raising the limit re-arms the thresholds.
+	if _, err := s.SetBudget(uid, "", 12); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 9), Price: 0.10, Store: "Mercadona"})
+	if inbox, _ := s.GetAlerts(uid, false, 0); len(inbox.Alerts) != 3 || inbox.Alerts[0].Kind != store.AlertBudgetWarning {
+		t.Errorf("want a new warning alert for the raised limit, got %+v", inbox.Alerts)
+	}
+}
+
+func TestUpsertPriceRecordBatch_BackfilledMonthRaisesNoBudgetAlerts(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 4, 2), Price: 1.00, Store: "Mercadona"})
+	if _, err := s.SetBudget(uid, "", 10); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 10), Price: 20.00, Store: "Mercadona"})
+
+	inbox, err := s.GetAlerts(uid, false, 0)
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	if len(inbox.Alerts) != 0 {
+		t.Errorf("want no alerts for a back-filled month, got %+v", inbox.Alerts)
+	}
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Store comparison ----------
+
+// typicalBasketDays is the window of purchases, ending at the comparison
+// date, that defines the household's typical weekly basket.
+const typicalBasketDays = 90
+
+// groupKey is the basket item key of product group id; ungrouped products
+// are keyed "p:<product ID>".
+func groupKey(id int64) string { return "g:" + strconv.FormatInt(id, 10) }
+
+// GetStoreComparison compares the stores userID's household buys from as of
+// asOf: for each product group, the latest price at every store and the
+// saving of buying at the cheapest one; and a ranking of stores by what the
+// typical weekly basket would cost there at their latest prices.
+func (s *SQLiteStore) GetStoreComparison(userID int64, asOf time.Time) (models.StoreComparison, error) {
+	basketFrom := asOf.AddDate(0, 0, 1-typicalBasketDays)
+	result := models.StoreComparison{
+		BasketFrom: basketFrom.Format(time.DateOnly),
+		BasketTo:   asOf.Format(time.DateOnly),
+		Groups:     []models.GroupComparison{},
+		Stores:     []models.StoreBasketCost{},
+	}
+
+	groups, err := s.GetProductGroups(userID)
+	if err != nil {
+		return result, err
+	}
+	// Linked products share one basket item keyed by their group.
+	groupOf := make(map[string]string)
+	for _, g := range groups {
+		for _, m := range g.Products {
+			groupOf[m.ProductID] = groupKey(g.ID)
+		}
+	}
+	keyOf := func(productID string) string {
+		if k, ok := groupOf[productID]; ok {
+			return k
+		}
+		return "p:" + productID
+	}
+
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return result, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+
+	// Latest price of each item at each store: for a group, the most recent
+	// purchase of any of its products there.
+	rows, err := s.db.Query(`
+		SELECT product_id, name, store, price, date FROM (
+			SELECT pr.product_id, p.name, pr.store, pr.price, pr.date, pr.id,
+			       ROW_NUMBER() OVER (PARTITION BY pr.product_id, pr.store ORDER BY pr.date DESC, pr.id DESC) AS rn
+			FROM price_records pr
+			JOIN products p ON p.id = pr.product_id
+			WHERE pr.`+clause+` AND pr.anomaly IS NULL AND pr.date <= ? AND pr.store != ''
+		)
+		WHERE rn = 1
+		ORDER BY date, id
+	`, append(append([]any{}, baseArgs...), result.BasketTo)...)
+	if err != nil {
+		return result, fmt.Errorf("get store prices: %w", err)
+	}
+	prices := make(map[string]map[string]models.StorePrice) // item → store → price
+	stores := make(map[string]bool)
+	for rows.Next() {
+		var p models.StorePrice
+		if err := rows.Scan(&p.ProductID, &p.ProductName, &p.Store, &p.Price, &p.Date); err != nil {
+			rows.Close()
+			return result, fmt.Errorf("scan store price: %w", err)
+		}
+		key := keyOf(p.ProductID)
+		if prices[key] == nil {
+			prices[key] = make(map[string]models.StorePrice)
+		}
+		prices[key][p.Store] = p // rows are in date order, so later ones win
+		stores[p.Store] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate store prices: %w", err)
+	}
+
+	// Units bought per item over the window, scaled to one week.
+	rows, err = s.db.Query(`
+		SELECT product_id, SUM(quantity)
+		FROM price_records
+		WHERE `+clause+` AND date BETWEEN ? AND ?
+		GROUP BY product_id
+	`, append(append([]any{}, baseArgs...), result.BasketFrom, result.BasketTo)...)
+	if err != nil {
+		return result, fmt.Errorf("get typical basket: %w", err)
+	}
+	defer rows.Close()
+	weekly := make(map[string]float64)
+	for rows.Next() {
+		var productID string
+		var units float64
+		if err := rows.Scan(&productID, &units); err != nil {
+			return result, fmt.Errorf("scan typical basket: %w", err)
+		}
+		weekly[keyOf(productID)] += units
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate typical basket: %w", err)
+	}
+	for key, units := range weekly {
+		weekly[key] = math.Round(units*7/typicalBasketDays*100) / 100
+	}
+	result.BasketItems = len(weekly)
+
+	for _, g := range groups {
+		byStore := prices[groupKey(g.ID)]
+		if len(byStore) == 0 {
+			continue
+		}
+		c := models.GroupComparison{GroupID: g.ID, Name: g.Name, Prices: []models.StorePrice{}}
+		for _, p := range byStore {
+			c.Prices = append(c.Prices, p)
+		}
+		sort.Slice(c.Prices, func(i, j int) bool {
+			if c.Prices[i].Price != c.Prices[j].Price {
+				return c.Prices[i].Price < c.Prices[j].Price
+			}
+			return c.Prices[i].Store < c.Prices[j].Store
+		})
+		c.CheapestStore = c.Prices[0].Store
+		c.UnitSaving = roundCents(c.Prices[len(c.Prices)-1].Price - c.Prices[0].Price)
+		c.BasketQuantity = weekly[groupKey(g.ID)]
+		c.BasketSaving = roundCents(c.UnitSaving * c.BasketQuantity)
+		result.Groups = append(result.Groups, c)
+	}
+	sort.SliceStable(result.Groups, func(i, j int) bool {
+		a, b := result.Groups[i], result.Groups[j]
+		if a.BasketSaving != b.BasketSaving {
+			return a.BasketSaving > b.BasketSaving
+		}
+		return a.UnitSaving > b.UnitSaving
+	})
+
+	for store := range stores {
+		cost := models.StoreBasketCost{Store: store}
+		for key, qty := range weekly {
+			p, ok := prices[key][store]
+			if !ok {
+				cost.MissingItems++
+				continue
+			}
+			cost.PricedItems++
+			cost.Cost += p.Price * qty
+		}
+		cost.Cost = roundCents(cost.Cost)
+		result.Stores = append(result.Stores, cost)
+	}
+	sort.Slice(result.Stores, func(i, j int) bool {
+		a, b := result.Stores[i], result.Stores[j]
+		if a.MissingItems != b.MissingItems {
+			return a.MissingItems < b.MissingItems
+		}
+		if a.Cost != b.Cost {
+			return a.Cost < b.Cost
+		}
+		return a.Store < b.Store
+	})
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package store_test
+
+import (
+	"testing"
+
+	"basket-cost/internal/models"
+)
+
+func TestGetStoreComparison(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "LECHE HACENDADO",
+		models.PriceRecord{Date: date(2024, 6, 1), Price: 0.70, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 2, 1), Price: 0.90, Store: "Mercadona", Quantity: 6},
+	)
+	importRecords(t, s, uid, "LECHE LIDL", models.PriceRecord{Date: date(2025, 3, 1), Price: 0.80, Store: "Lidl", Quantity: 3})
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 10), Price: 1.00, Store: "Mercadona"})
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 4, 10), Price: 9.00, Store: "Lidl"}) // after asOf
+	groupID, err := s.CreateProductGroup(uid, "Leche", []string{"leche-hacendado", "leche-lidl"})
+	if err != nil {
+		t.Fatalf("CreateProductGroup: %v", err)
+	}
+
+	got, err := s.GetStoreComparison(uid, date(2025, 3, 31))
+	if err != nil {
+		t.Fatalf("GetStoreComparison: %v", err)
+	}
+	if got.BasketFrom != "2025-01-01" || got.BasketTo != "2025-03-31" || got.BasketItems != 2 {
+		t.Errorf("unexpected basket window %+v", got)
+	}
+
+	if len(got.Groups) != 1 {
+		t.Fatalf("want 1 group, got %+v", got.Groups)
+	}
+	g := got.Groups[0]
+	// 9 litres over 90 days is 0.7 a week; Lidl saves 0.10 each.
+	if g.GroupID != groupID || g.CheapestStore != "Lidl" || len(g.Prices) != 2 || g.Prices[1].Price != 0.90 ||
+		g.UnitSaving != 0.10 || g.BasketQuantity != 0.7 || g.BasketSaving != 0.07 {
+		t.Errorf("unexpected group comparison %+v", g)
+	}
+
+	want := []models.StoreBasketCost{
+		{Store: "Mercadona", Cost: 0.71, PricedItems: 2},
+		{Store: "Lidl", Cost: 0.56, PricedItems: 1, MissingItems: 1},
+	}
+	if len(got.Stores) != len(want) {
+		t.Fatalf("want %d stores, got %+v", len(want), got.Stores)
+	}
+	for i := range want {
+		if got.Stores[i] != want[i] {
+			t.Errorf("store %d: want %+v, got %+v", i, want[i], got.Stores[i])
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Digest ----------
+
+// digestPriceChanges caps the price changes listed in a digest.
+const digestPriceChanges = 10
+
+// DigestRange returns the first and last day of the last complete week
+// (Monday–Sunday) or month before ref.
+func DigestRange(ref time.Time, g Granularity) (from, to time.Time) {
+	current := PeriodStart(ref, g)
+	return PeriodStart(current.AddDate(0, 0, -1), g), current.AddDate(0, 0, -1)
+}
+
+// GetDigest summarises the last complete week or month before ref for
+// userID's household: spend against the previous period, tickets dated in
+// the period (as counted by GetShoppingPatterns), price changes, products bought for the first time and personal inflation
+// against IPC over the twelve months ending with the period.
+func (s *SQLiteStore) GetDigest(userID int64, g Granularity, ref time.Time) (models.Digest, error) {
+	if g != GranularityWeek && g != GranularityMonth {
+		return models.Digest{}, fmt.Errorf("unsupported granularity %q for digest", g)
+	}
+	from, to := DigestRange(ref, g)
+	prevFrom, _ := DigestRange(from, g)
+	d := models.Digest{
+		Period:       string(g),
+		From:         from.Format(time.DateOnly),
+		To:           to.Format(time.DateOnly),
+		PriceChanges: []models.PriceChangeEvent{},
+		NewProducts:  []models.DigestProduct{},
+	}
+
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return d, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+
+	if err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN date BETWEEN ? AND ? THEN price * quantity END), 0),
+		       COALESCE(SUM(CASE WHEN date < ? THEN price * quantity END), 0)
+		FROM price_records
+		WHERE `+clause+` AND date BETWEEN ? AND ?
+	`, append(append([]any{d.From, d.To, d.From}, baseArgs...), prevFrom.Format(time.DateOnly), d.To)...,
+	).Scan(&d.TotalSpent, &d.PreviousTotalSpent); err != nil {
+		return d, fmt.Errorf("get digest spend: %w", err)
+	}
+	d.TotalSpent = roundCents(d.TotalSpent)
+	d.PreviousTotalSpent = roundCents(d.PreviousTotalSpent)
+	if d.PreviousTotalSpent > 0 {
+		pct := roundCents((d.TotalSpent - d.PreviousTotalSpent) / d.PreviousTotalSpent * 100)
+		d.SpendChangePercent = &pct
+	}
+
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM tickets WHERE `+clause+` AND date BETWEEN ? AND ?`,
+		append(append([]any{}, baseArgs...), d.From, d.To)...,
+	).Scan(&d.TicketsImported); err != nil {
+		return d, fmt.Errorf("count digest tickets: %w", err)
+	}
+
+	changes, err := s.GetPriceChanges(userID, PriceChangeFilter{AnalyticsFilter: AnalyticsFilter{From: from, To: to}})
+	if err != nil {
+		return d, err
+	}
+	for _, c := range changes {
+		if c.ChangePercent > 0 {
+			d.PriceIncreases++
+		} else {
+			d.PriceDecreases++
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return math.Abs(changes[i].ChangePercent) > math.Abs(changes[j].ChangePercent)
+	})
+	d.PriceChanges = append(d.PriceChanges, truncate(changes, digestPriceChanges)...)
+
+	if d.NewProducts, err = s.newProducts(clause, baseArgs, d.From, d.To); err != nil {
+		return d, err
+	}
+
+	// Inflation over the twelve months ending with the period.
+	infFrom := PeriodStart(to, GranularityMonth).AddDate(0, -11, 0)
+	inflation, err := s.GetPersonalInflation(userID, infFrom, to, GranularityMonth, HeadlineIPC)
+	if err != nil {
+		return d, err
+	}
+	d.Inflation = models.DigestInflation{
+		From:                  inflation.From,
+		To:                    inflation.To,
+		PersonalChangePercent: inflation.PersonalChangePercent,
+		IPCChangePercent:      inflation.IPCChangePercent,
+	}
+	return d, nil
+}
+
+// newProducts returns the products whose first purchase by the members in
+// clause falls between from and to, in purchase order.
+func (s *SQLiteStore) newProducts(clause string, baseArgs []any, from, to string) ([]models.DigestProduct, error) {
+	rows, err := s.db.Query(`
+		SELECT product_id, name, price, store, date FROM (
+			SELECT pr.product_id, p.name, pr.price, pr.store, pr.date,
+			       ROW_NUMBER() OVER (PARTITION BY pr.product_id ORDER BY pr.date, pr.id) AS rn
+			FROM price_records pr
+			JOIN products p ON p.id = pr.product_id
+			WHERE pr.`+clause+` AND pr.date <= ?
+		)
+		WHERE rn = 1 AND date >= ?
+		ORDER BY date, name
+	`, append(append([]any{}, baseArgs...), to, from)...)
+	if err != nil {
+		return nil, fmt.Errorf("get new products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.DigestProduct{}
+	for rows.Next() {
+		var p models.DigestProduct
+		if err := rows.Scan(&p.ProductID, &p.Name, &p.Price, &p.Store, &p.Date); err != nil {
+			return nil, fmt.Errorf("scan new product: %w", err)
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate new products: %w", err)
+	}
+	return products, nil
+}
+
+// GetHouseholdGroups returns every registered user grouped by household,
+// ordered by user ID. Users without a household form a group of their own.
+func (s *SQLiteStore) GetHouseholdGroups() ([][]models.User, error) {
+	rows, err := s.db.Query(`
+		SELECT id, username, email, created_at, COALESCE(household_id, 0)
+		FROM users
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("get users: %w", err)
+	}
+	defer rows.Close()
+
+	groups := [][]models.User{}
+	byHousehold := make(map[int64]int)
+	for rows.Next() {
+		var u models.User
+		var createdAt string
+		var householdID int64
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &createdAt, &householdID); err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		if u.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("parse user created_at: %w", err)
+		}
+		if i, ok := byHousehold[householdID]; ok && householdID != 0 {
+			groups[i] = append(groups[i], u)
+			continue
+		}
+		byHousehold[householdID] = len(groups)
+		groups = append(groups, []models.User{u})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+	return groups, nil
+}
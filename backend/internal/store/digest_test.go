@@ -0,0 +1,112 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func TestDigestRange_LastCompletePeriod(t *testing.T) {
+	tests := []struct {
+		ref      time.Time
+		g        store.Granularity
+		from, to time.Time
+	}{
+		{date(2025, 3, 12), store.GranularityWeek, date(2025, 3, 3), date(2025, 3, 9)},
+		{date(2025, 3, 10), store.GranularityWeek, date(2025, 3, 3), date(2025, 3, 9)}, // Monday
+		{date(2025, 3, 12), store.GranularityMonth, date(2025, 2, 1), date(2025, 2, 28)},
+		{date(2025, 1, 1), store.GranularityMonth, date(2024, 12, 1), date(2024, 12, 31)},
+	}
+	for _, tt := range tests {
+		from, to := store.DigestRange(tt.ref, tt.g)
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("%s before %s: want %s–%s, got %s–%s", tt.g, tt.ref.Format(time.DateOnly),
+				tt.from.Format(time.DateOnly), tt.to.Format(time.DateOnly), from.Format(time.DateOnly), to.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestGetDigest_Weekly(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	entries := []models.PriceRecordEntry{
+		{Name: "ACEITE", Record: models.PriceRecord{Date: date(2025, 1, 10), Price: 8.00, Store: "Mercadona"}},
+		{Name: "LECHE", Record: models.PriceRecord{Date: date(2025, 2, 25), Price: 1.00, Store: "Mercadona"}},
+		{Name: "LECHE", Record: models.PriceRecord{Date: date(2025, 3, 4), Price: 1.10, Store: "Mercadona"}},
+		{Name: "PAN", Record: models.PriceRecord{Date: date(2025, 3, 6), Price: 1.50, Quantity: 2, Store: "Bonpreu"}},
+	}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("UpsertPriceRecordBatch: %v", err)
+	}
+	// Tickets count by their date, not when they were uploaded.
+	importTicket(t, s, uid, "Mercadona", date(2025, 3, 5), line("ACEITE", 7.60, 1))
+	importTicket(t, s, uid, "Bonpreu", date(2025, 3, 12), line("PAN", 1.60, 1)) // after the week
+
+	d, err := s.GetDigest(uid, store.GranularityWeek, date(2025, 3, 12))
+	if err != nil {
+		t.Fatalf("GetDigest: %v", err)
+	}
+	if d.Period != "week" || d.From != "2025-03-03" || d.To != "2025-03-09" {
+		t.Errorf("unexpected range %s %s–%s", d.Period, d.From, d.To)
+	}
+	// 1.10 + 7.60 + 2 × 1.50 against 1.00 the week before.
+	if d.TotalSpent != 11.70 || d.PreviousTotalSpent != 1.00 || d.SpendChangePercent == nil || *d.SpendChangePercent != 1070 {
+		t.Errorf("spend: got %v / %v / %v", d.TotalSpent, d.PreviousTotalSpent, d.SpendChangePercent)
+	}
+	if d.TicketsImported != 1 {
+		t.Errorf("want 1 ticket imported, got %d", d.TicketsImported)
+	}
+	if d.PriceIncreases != 1 || d.PriceDecreases != 1 || len(d.PriceChanges) != 2 {
+		t.Fatalf("price changes: got +%d −%d %+v", d.PriceIncreases, d.PriceDecreases, d.PriceChanges)
+	}
+	// Largest change first.
+	if d.PriceChanges[0].Name != "LECHE" || d.PriceChanges[1].ChangePercent != -5 {
+		t.Errorf("want LECHE +10%% then ACEITE −5%%, got %+v", d.PriceChanges)
+	}
+	want := []models.DigestProduct{{ProductID: "pan", Name: "PAN", Price: 1.50, Store: "Bonpreu", Date: "2025-03-06"}}
+	if len(d.NewProducts) != 1 || d.NewProducts[0] != want[0] {
+		t.Errorf("new products: want %+v, got %+v", want, d.NewProducts)
+	}
+	// Twelve months back from March, clamped to the first purchase month.
+	if d.Inflation.From != "2025-01-01" || d.Inflation.To != "2025-03-09" {
+		t.Errorf("inflation window: got %s–%s", d.Inflation.From, d.Inflation.To)
+	}
+}
+
+func TestGetDigest_EmptyPeriod(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+
+	d, err := s.GetDigest(uid, store.GranularityMonth, date(2025, 3, 12))
+	if err != nil {
+		t.Fatalf("GetDigest: %v", err)
+	}
+	if d.TotalSpent != 0 || d.SpendChangePercent != nil || d.PriceChanges == nil || d.NewProducts == nil {
+		t.Errorf("want an empty digest with non-nil lists, got %+v", d)
+	}
+}
+
+func TestGetHouseholdGroups(t *testing.T) {
+	s := newTestStore(t)
+	alice := createTestUser2(t, s, "alice")
+	bob := createTestUser2(t, s, "bob")
+	carol := createTestUser2(t, s, "carol")
+	hid, err := s.CreateHousehold(alice)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := s.AddUserToHousehold(carol, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+
+	groups, err := s.GetHouseholdGroups()
+	if err != nil {
+		t.Fatalf("GetHouseholdGroups: %v", err)
+	}
+	if len(groups) != 2 || len(groups[0]) != 2 || groups[0][0].ID != alice || groups[0][1].ID != carol ||
+		len(groups[1]) != 1 || groups[1][0].ID != bob {
+		t.Errorf("want [[alice carol] [bob]], got %+v", groups)
+	}
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Price forecast ----------
+
+// ErrInvalidForecast is returned (wrapped) by ForecastPriceHistory for a
+// horizon outside [MinForecastMonths, MaxForecastMonths].
+var ErrInvalidForecast = errors.New("invalid forecast")
+
+// Horizons accepted by ForecastPriceHistory, in months.
+const (
+	MinForecastMonths = 3
+	MaxForecastMonths = 12
+)
+
+// Methods reported by ForecastPriceHistory.
+const (
+	ForecastTrend = "trend" // fitted to the product's history, pulled toward IPC
+	ForecastIPC   = "ipc"   // too little history: the IPC trend alone
+	ForecastFlat  = "flat"  // too little history and no IPC data
+)
+
+const (
+	// forecastMinPurchases and forecastMinSpanDays are the history needed to
+	// fit a product's own trend.
+	forecastMinPurchases = 3
+	forecastMinSpanDays  = 60
+	// forecastIPCWeight is how many purchases the IPC trend is worth when it
+	// is blended with the fitted one: short histories lean on IPC, long ones
+	// on the product itself.
+	forecastIPCWeight = 4.0
+	// forecastIPCYears is the number of latest ipc_rates years averaged into
+	// the IPC trend.
+	forecastIPCYears = 3
+	// forecastMinSigma is the smallest monthly spread of log prices assumed,
+	// so that a perfectly regular history still gets a band.
+	forecastMinSigma = 0.02
+	// forecastZ is the normal quantile of the 95% band.
+	forecastZ = 1.96
+	// daysPerMonth converts day differences into months.
+	daysPerMonth = 365.25 / 12
+)
+
+// ForecastPriceHistory projects the price of a product for the months
+// following asOf's month, counted from its latest purchase. With at least
+// three purchases over 60 days it fits an exponential trend (least squares on
+// log prices) and blends it with the IPC trend of the latest years, weighting
+// IPC as four purchases; the band is the regression's 95% prediction
+// interval. Otherwise the IPC trend is applied to the latest price with a band
+// that widens with the horizon. Records flagged for review are skipped.
+// Returns nil when history has no unflagged positive prices.
+func (s *SQLiteStore) ForecastPriceHistory(history []models.PriceRecord, months int, asOf time.Time) (*models.PriceForecast, error) {
+	if months < MinForecastMonths || months > MaxForecastMonths {
+		return nil, fmt.Errorf("%w: months must be between %d and %d", ErrInvalidForecast, MinForecastMonths, MaxForecastMonths)
+	}
+	history = unflaggedRecords(history)
+	var first, latest *models.PriceRecord
+	for i := range history {
+		rec := &history[i]
+		if rec.Price <= 0 {
+			continue
+		}
+		if first == nil || rec.Date.Before(first.Date) {
+			first = rec
+		}
+		if latest == nil || !rec.Date.Before(latest.Date) {
+			latest = rec
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+
+	ipcSlope, hasIPC, err := s.ipcMonthlyTrend()
+	if err != nil {
+		return nil, err
+	}
+
+	// Months since the first purchase against log price.
+	monthsSince := func(t time.Time) float64 { return t.Sub(first.Date).Hours() / 24 / daysPerMonth }
+	var ts, ys []float64
+	var meanT, meanY float64
+	for _, rec := range history {
+		if rec.Price > 0 {
+			ts = append(ts, monthsSince(rec.Date))
+			ys = append(ys, math.Log(rec.Price))
+			meanT += ts[len(ts)-1]
+			meanY += ys[len(ys)-1]
+		}
+	}
+	n := float64(len(ts))
+	meanT, meanY = meanT/n, meanY/n
+	var sxx, sxy float64
+	for i := range ts {
+		sxx += (ts[i] - meanT) * (ts[i] - meanT)
+		sxy += (ts[i] - meanT) * (ys[i] - meanY)
+	}
+	fitted := len(ts) >= forecastMinPurchases && latest.Date.Sub(first.Date) >= forecastMinSpanDays*24*time.Hour && sxx > 0
+
+	fc := &models.PriceForecast{
+		Method:    ForecastFlat,
+		BaseDate:  latest.Date.Format(time.DateOnly),
+		BasePrice: latest.Price,
+		Points:    make([]models.ForecastPoint, 0, months),
+	}
+	slope, sigma := 0.0, forecastMinSigma
+	switch {
+	case fitted:
+		fc.Method = ForecastTrend
+		slope = sxy / sxx
+		var ssr float64
+		for i := range ts {
+			r := ys[i] - (meanY + slope*(ts[i]-meanT))
+			ssr += r * r
+		}
+		sigma = max(sigma, math.Sqrt(ssr/(n-2)))
+		if hasIPC {
+			w := n / (n + forecastIPCWeight)
+			slope = w*slope + (1-w)*ipcSlope
+		}
+	case hasIPC:
+		fc.Method = ForecastIPC
+		slope = ipcSlope
+	}
+	fc.AnnualChangePercent = roundCents((math.Exp(slope*12) - 1) * 100)
+
+	// The horizon starts at asOf's month (never before the latest purchase);
+	// h counts months from the latest purchase.
+	latestT := monthsSince(latest.Date)
+	base := PeriodStart(latest.Date, GranularityMonth)
+	start := PeriodStart(asOf, GranularityMonth)
+	if start.Before(base) {
+		start = base
+	}
+	skip := (start.Year()-base.Year())*12 + int(start.Month()-base.Month())
+	for i := 1; i <= months; i++ {
+		h := float64(skip + i)
+		spread := forecastZ * sigma * math.Sqrt(1+h)
+		if fitted {
+			t := latestT + h
+			spread = forecastZ * sigma * math.Sqrt(1+1/n+(t-meanT)*(t-meanT)/sxx)
+		}
+		price := latest.Price * math.Exp(slope*h)
+		fc.Points = append(fc.Points, models.ForecastPoint{
+			Month: start.AddDate(0, i, 0).Format("2006-01"),
+			Price: roundCents(price),
+			Low:   roundCents(price * math.Exp(-spread)),
+			High:  roundCents(price * math.Exp(spread)),
+		})
+	}
+	return fc, nil
+}
+
+// ipcMonthlyTrend returns the monthly log growth of IPC over the latest
+// forecastIPCYears years, and false when there is no IPC data. It reads the
+// imported monthly FoodIPC series, the closest to a grocery basket, then the
+// monthly HeadlineIPC one, and falls back to the average yearly ipc_rates.
+func (s *SQLiteStore) ipcMonthlyTrend() (float64, bool, error) {
+	for _, series := range []IPCSeries{FoodIPC, HeadlineIPC} {
+		values, err := s.ipcMonthlyIndex(series)
+		if err != nil {
+			return 0, false, err
+		}
+		if slope, ok := monthlyIndexTrend(values); ok {
+			return slope, true, nil
+		}
+	}
+
+	rates, err := s.ipcRatesByYear()
+	if err != nil || len(rates) == 0 {
+		return 0, false, err
+	}
+	last := math.MinInt
+	for year := range rates {
+		last = max(last, year)
+	}
+	var sum float64
+	var years int
+	for year := last; year > last-forecastIPCYears; year-- {
+		if rate, ok := rates[year]; ok {
+			sum += math.Log(1 + rate)
+			years++
+		}
+	}
+	return sum / float64(years) / 12, true, nil
+}
+
+// monthlyIndexTrend returns the monthly log growth between the latest level
+// in values ("YYYY-MM" → index) and the earliest within forecastIPCYears
+// before it, and false when they are not at least a month apart.
+func monthlyIndexTrend(values map[string]float64) (float64, bool) {
+	var last string
+	for period := range values {
+		last = max(last, period)
+	}
+	end, err := time.Parse("2006-01", last)
+	if err != nil {
+		return 0, false
+	}
+	from := end.AddDate(-forecastIPCYears, 0, 0).Format("2006-01")
+	first := last
+	for period := range values {
+		if period >= from && period < first {
+			first = period
+		}
+	}
+	begin, _ := time.Parse("2006-01", first)
+	span := (end.Year()-begin.Year())*12 + int(end.Month()-begin.Month())
+	if span < 1 || values[first] <= 0 || values[last] <= 0 {
+		return 0, false
+	}
+	return math.Log(values[last]/values[first]) / float64(span), true
+}
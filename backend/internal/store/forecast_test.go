@@ -0,0 +1,115 @@
+package store_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func TestForecastPriceHistory_FitsTrendPulledTowardIPC(t *testing.T) {
+	s := newTestStore(t)
+	// 2% a month for half a year (about +27% a year).
+	var history []models.PriceRecord
+	for m := 1; m <= 6; m++ {
+		history = append(history, models.PriceRecord{Date: date(2025, m, 1), Price: math.Round(100*math.Pow(1.02, float64(m-1))) / 100})
+	}
+
+	fc, err := s.ForecastPriceHistory(history, 12, date(2025, 6, 1))
+	if err != nil {
+		t.Fatalf("ForecastPriceHistory: %v", err)
+	}
+	if fc.Method != store.ForecastTrend || fc.BaseDate != "2025-06-01" || fc.BasePrice != 1.10 || len(fc.Points) != 12 {
+		t.Fatalf("unexpected forecast %+v", fc)
+	}
+	// Six purchases against IPC worth four: 60% of the product's own trend.
+	if fc.AnnualChangePercent < 15 || fc.AnnualChangePercent > 20 {
+		t.Errorf("want an annual change between IPC and the product's trend, got %v", fc.AnnualChangePercent)
+	}
+	if first := fc.Points[0]; first.Month != "2025-07" || first.Price <= 1.10 {
+		t.Errorf("unexpected first point %+v", first)
+	}
+	for i, p := range fc.Points {
+		if p.Low >= p.Price || p.High <= p.Price {
+			t.Errorf("point %d: price outside its band %+v", i, p)
+		}
+		if i > 0 && p.High-p.Low < fc.Points[i-1].High-fc.Points[i-1].Low {
+			t.Errorf("point %d: band narrower than the month before %+v", i, p)
+		}
+	}
+	if fc.Points[11].Month != "2026-06" {
+		t.Errorf("last month: want 2026-06, got %s", fc.Points[11].Month)
+	}
+}
+
+func TestForecastPriceHistory_ShortHistoryFollowsIPC(t *testing.T) {
+	s := newTestStore(t)
+	history := []models.PriceRecord{{Date: date(2025, 1, 10), Price: 1.00}}
+
+	fc, err := s.ForecastPriceHistory(history, 3, date(2025, 1, 10))
+	if err != nil {
+		t.Fatalf("ForecastPriceHistory: %v", err)
+	}
+	// Seeded IPC 2023–2025: 3.5%, 2.8%, 2.5% → 2.93% a year on average.
+	if fc.Method != store.ForecastIPC || fc.AnnualChangePercent != 2.93 {
+		t.Errorf("want the IPC trend, got %+v", fc)
+	}
+	want := models.ForecastPoint{Month: "2025-04", Price: 1.01, Low: 0.93, High: 1.09}
+	if len(fc.Points) != 3 || fc.Points[2] != want {
+		t.Errorf("want last point %+v, got %+v", want, fc.Points)
+	}
+}
+
+func TestForecastPriceHistory_StartsAtCurrentMonth(t *testing.T) {
+	s := newTestStore(t)
+	history := []models.PriceRecord{{Date: date(2025, 1, 10), Price: 1.00}}
+
+	// A year after the latest purchase the horizon still lies ahead.
+	fc, err := s.ForecastPriceHistory(history, 3, date(2026, 1, 15))
+	if err != nil {
+		t.Fatalf("ForecastPriceHistory: %v", err)
+	}
+	if len(fc.Points) != 3 || fc.Points[0].Month != "2026-02" || fc.Points[2].Month != "2026-04" {
+		t.Fatalf("want points for 2026-02 to 2026-04, got %+v", fc.Points)
+	}
+	// Fifteen months of 2.93% a year from the latest purchase.
+	if last := fc.Points[2]; last.Price != 1.04 || last.High-last.Low <= 0.16 {
+		t.Errorf("unexpected last point %+v", last)
+	}
+}
+
+func TestForecastPriceHistory_PrefersMonthlyFoodIPC(t *testing.T) {
+	s := newTestStore(t)
+	jan24, jan25 := 100.0, 106.0
+	if err := s.UpsertIPCObservations([]models.IPCObservation{
+		{Region: store.FoodIPC.Region, Subindex: store.FoodIPC.Subindex, Period: "2024-01", IndexValue: &jan24},
+		{Region: store.FoodIPC.Region, Subindex: store.FoodIPC.Subindex, Period: "2025-01", IndexValue: &jan25},
+	}); err != nil {
+		t.Fatalf("UpsertIPCObservations: %v", err)
+	}
+	history := []models.PriceRecord{{Date: date(2025, 1, 10), Price: 1.00}}
+
+	fc, err := s.ForecastPriceHistory(history, 3, date(2025, 1, 10))
+	if err != nil {
+		t.Fatalf("ForecastPriceHistory: %v", err)
+	}
+	if fc.Method != store.ForecastIPC || fc.AnnualChangePercent != 6 {
+		t.Errorf("want the 6%% food IPC trend, got %+v", fc)
+	}
+}
+
+func TestForecastPriceHistory_RejectsHorizon_NilWithoutHistory(t *testing.T) {
+	s := newTestStore(t)
+	history := []models.PriceRecord{{Date: date(2025, 1, 10), Price: 1.00}}
+	for _, months := range []int{2, 13} {
+		if _, err := s.ForecastPriceHistory(history, months, date(2025, 1, 10)); !errors.Is(err, store.ErrInvalidForecast) {
+			t.Errorf("%d months: want ErrInvalidForecast, got %v", months, err)
+		}
+	}
+	fc, err := s.ForecastPriceHistory(nil, 6, date(2025, 1, 10))
+	if err != nil || fc != nil {
+		t.Errorf("want nil forecast without history, got %+v, %v", fc, err)
+	}
+}
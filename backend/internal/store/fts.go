@@ -0,0 +1,110 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"basket-cost/internal/textnorm"
+)
+
+// ---------- Full-text search ----------
+
+// minPrefixLength is the shortest query token matched as a word prefix.
+// Shorter tokens must match a whole word, so that "pa" finds "PA DE MOTLLO"
+// without also matching every "pasta" and "patata".
+const minPrefixLength = 3
+
+// aliasSeparator separates entries in products_fts.aliases. The FTS5 tokenizer
+// treats it as whitespace, so aliases are searchable word by word.
+const aliasSeparator = "\n"
+
+// ftsMatchQuery turns free-text user input into an FTS5 MATCH expression in
+// which every token must appear, either as typed or as one of its synonyms.
+// Input is normalised with the same rules as the index, so "Llet" matches
+// "LLET" and "platano" matches "PLÁTANO".
+// Returns "" when the input contains no searchable token.
+func ftsMatchQuery(query string, synonyms map[string][]string) string {
+	tokens := strings.Fields(textnorm.Normalise(query))
+	groups := make([]string, len(tokens))
+	for i, tok := range tokens {
+		alts := []string{ftsTerm(tok)}
+		for _, syn := range synonyms[tok] {
+			// A synonym stands in for exactly one token; skip anything else.
+			if fields := strings.Fields(textnorm.Normalise(syn)); len(fields) == 1 {
+				alts = append(alts, ftsTerm(fields[0]))
+			}
+		}
+		if len(alts) == 1 {
+			groups[i] = alts[0]
+		} else {
+			groups[i] = "(" + strings.Join(alts, " OR ") + ")"
+		}
+	}
+	return strings.Join(groups, " AND ")
+}
+
+// ftsTerm quotes a normalised token for use in a MATCH expression, adding the
+// prefix operator when the token is long enough.
+func ftsTerm(tok string) string {
+	// Normalised tokens hold only letters and digits, so quoting is safe and
+	// stops FTS5 from reading words like "AND" or "NOT" as operators.
+	term := `"` + tok + `"`
+	if len(tok) >= minPrefixLength {
+		term += "*"
+	}
+	return term
+}
+
+// syncProductFTS keeps products_fts in step with a product upsert inside tx.
+// inserted reports whether the upsert created the product. When an existing
+// product is seen under a differently-spelled name (e.g. with or without
+// accents), that spelling is recorded as an alias.
+func syncProductFTS(tx *sql.Tx, id, name string, inserted bool) error {
+	norm := textnorm.Normalise(name)
+	if inserted {
+		if _, err := tx.Exec(
+			`INSERT INTO products_fts (product_id, name, aliases) VALUES (?, ?, '')`, id, norm,
+		); err != nil {
+			return fmt.Errorf("index product %s: %w", id, err)
+		}
+		return nil
+	}
+
+	var stored string
+	if err := tx.QueryRow(`SELECT name FROM products WHERE id = ?`, id).Scan(&stored); err != nil {
+		return fmt.Errorf("get product name %s: %w", id, err)
+	}
+	if textnorm.Normalise(stored) == norm {
+		return nil
+	}
+
+	var aliases string
+	err := tx.QueryRow(`SELECT aliases FROM products_fts WHERE product_id = ?`, id).Scan(&aliases)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := tx.Exec(
+			`INSERT INTO products_fts (product_id, name, aliases) VALUES (?, ?, ?)`,
+			id, textnorm.Normalise(stored), norm,
+		); err != nil {
+			return fmt.Errorf("index product %s: %w", id, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get aliases for product %s: %w", id, err)
+	}
+	if aliases != "" && slices.Contains(strings.Split(aliases, aliasSeparator), norm) {
+		return nil
+	}
+	if aliases != "" {
+		aliases += aliasSeparator
+	}
+	if _, err := tx.Exec(
+		`UPDATE products_fts SET aliases = ? WHERE product_id = ?`, aliases+norm, id,
+	); err != nil {
+		return fmt.Errorf("add alias for product %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package store_test
+
+import (
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// seedNames inserts one purchase per name for userID.
+func seedNames(t *testing.T, s *store.SQLiteStore, userID int64, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		insertProductForUser(t, s, userID, models.Product{
+			Name:         name,
+			PriceHistory: []models.PriceRecord{{Date: date(2025, 1, 1), Price: 1.00, Store: "Mercadona"}},
+		})
+	}
+}
+
+func searchIDs(t *testing.T, s *store.SQLiteStore, userID int64, query string) []string {
+	t.Helper()
+	results, err := s.SearchProducts(userID, store.SearchOptions{Query: query})
+	if err != nil {
+		t.Fatalf("SearchProducts(%q): %v", query, err)
+	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// ---------- SearchProducts (full-text) ----------
+
+func TestSearchProducts_AccentInsensitive(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedNames(t, s, uid, "PLÁTANO DE CANARIAS", "LLET SEMIDESNATADA")
+
+	for _, q := range []string{"platano", "PLÁTANO", "plátano"} {
+		if ids := searchIDs(t, s, uid, q); len(ids) != 1 {
+			t.Errorf("query %q: want 1 result, got %v", q, ids)
+		}
+	}
+}
+
+func TestSearchProducts_PrefixMatching(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedNames(t, s, uid, "LLET SEMIDESNATADA", "PA DE MOTLLO", "PASTA FRESCA", "PATATA")
+
+	if ids := searchIDs(t, s, uid, "llet semi"); len(ids) != 1 || ids[0] != "llet-semidesnatada" {
+		t.Errorf("query %q: want [llet-semidesnatada], got %v", "llet semi", ids)
+	}
+	// Two-letter tokens match whole words only.
+	if ids := searchIDs(t, s, uid, "pa"); len(ids) != 1 || ids[0] != "pa-de-motllo" {
+		t.Errorf("query %q: want [pa-de-motllo], got %v", "pa", ids)
+	}
+	if ids := searchIDs(t, s, uid, "pas"); len(ids) != 1 || ids[0] != "pasta-fresca" {
+		t.Errorf("query %q: want [pasta-fresca], got %v", "pas", ids)
+	}
+}
+
+func TestSearchProducts_RankedByRelevance(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	// The longer name mentions "leche" once among many other words, so it
+	// should rank below the short, focused name.
+	seedNames(t, s, uid, "GALLETAS RELLENAS DE CREMA SABOR LECHE Y CACAO", "LECHE ENTERA")
+
+	ids := searchIDs(t, s, uid, "leche")
+	if len(ids) != 2 || ids[0] != "leche-entera" {
+		t.Errorf("want leche-entera first, got %v", ids)
+	}
+}
+
+func TestSearchProducts_PunctuationOnlyQuery_ReturnsEmpty(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedNames(t, s, uid, "LECHE ENTERA")
+
+	if ids := searchIDs(t, s, uid, `"*(`); len(ids) != 0 {
+		t.Errorf("want no results, got %v", ids)
+	}
+}
+
+func TestSearchProducts_MatchesAliasSpelling(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	// A ticket whose PDF text lost the accented letter and a later ticket
+	// that kept it both slugify to "caf-molido"; the second spelling is
+	// indexed as an alias of the first product.
+	seedNames(t, s, uid, "CAF MOLIDO", "CAFÉ MOLIDO", "CAFÉ MOLIDO")
+
+	if ids := searchIDs(t, s, uid, "cafe"); len(ids) != 1 || ids[0] != "caf-molido" {
+		t.Errorf("want [caf-molido], got %v", ids)
+	}
+}
+
+func TestSearchProducts_SynonymsMatchEitherLanguage(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedNames(t, s, uid, "LLET SENCERA", "LECHE DESNATADA", "PA DE MOTLLO")
+
+	results, err := s.SearchProducts(uid, store.SearchOptions{
+		Query:    "leche",
+		Synonyms: map[string][]string{"leche": {"llet"}},
+	})
+	if err != nil {
+		t.Fatalf("SearchProducts: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("want both milks, got %+v", results)
+	}
+
+	// Every token must still match: "leche entera" needs an "entera" or "sencera".
+	results, err = s.SearchProducts(uid, store.SearchOptions{
+		Query:    "leche entera",
+		Synonyms: map[string][]string{"leche": {"llet"}, "entera": {"sencera"}},
+	})
+	if err != nil {
+		t.Fatalf("SearchProducts: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "llet-sencera" {
+		t.Errorf("want [llet-sencera], got %+v", results)
+	}
+}
@@ -0,0 +1,353 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Personal inflation ----------
+
+// ErrInvalidBaseYear is returned (wrapped) by DeflatePriceHistory when the
+// requested base year is outside the years covered by ipc_rates.
+var ErrInvalidBaseYear = errors.New("invalid base year")
+
+// Verdicts reported by DeflatePriceHistory.
+const (
+	VerdictFaster = "faster" // the product rose faster than IPC
+	VerdictSlower = "slower" // the product rose slower than IPC (or got cheaper)
+	VerdictInLine = "in_line"
+)
+
+// inflationVerdictTolerance is the gap, in percentage points, between a
+// product's price change and IPC below which the two are considered in line.
+const inflationVerdictTolerance = 1.0
+
+// ipcRatesByYear loads ipc_rates into a map of year → annual rate (decimal).
+func (s *SQLiteStore) ipcRatesByYear() (map[int]float64, error) {
+	rows, err := s.db.Query(`SELECT year, rate FROM ipc_rates`)
+	if err != nil {
+		return nil, fmt.Errorf("query ipc_rates: %w", err)
+	}
+	defer rows.Close()
+	rates := make(map[int]float64)
+	for rows.Next() {
+		var year int
+		var rate float64
+		if err := rows.Scan(&year, &rate); err != nil {
+			return nil, fmt.Errorf("scan ipc_rates: %w", err)
+		}
+		rates[year] = rate
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ipc_rates: %w", err)
+	}
+	return rates, nil
+}
+
+// ipcIndexSeries returns the official price index for each period, equal to
+// 100 at periods[0], from the monthly series when one has been imported and
+// from ipc_rates otherwise. It also returns the series actually used.
+func (s *SQLiteStore) ipcIndexSeries(periods []time.Time, g Granularity, series IPCSeries) ([]*float64, IPCSeries, error) {
+	monthly, err := s.ipcMonthlyIndex(series)
+	if err != nil {
+		return nil, series, err
+	}
+	if len(monthly) > 0 {
+		return monthlyIndexSeries(monthly, periods, g), series, nil
+	}
+	out, err := s.annualIndexSeries(periods, g)
+	return out, HeadlineIPC, err
+}
+
+// monthlyIndexSeries rebases the monthly index levels in values ("YYYY-MM" →
+// level) onto periods. Yearly periods use the average of the months
+// available. An entry is nil once the series has no data for its period.
+func monthlyIndexSeries(values map[string]float64, periods []time.Time, g Granularity) []*float64 {
+	out := make([]*float64, len(periods))
+	level := func(p time.Time) (float64, bool) {
+		if g == GranularityMonth {
+			v, ok := values[p.Format("2006-01")]
+			return v, ok
+		}
+		var sum float64
+		var n int
+		for m := time.January; m <= time.December; m++ {
+			if v, ok := values[fmt.Sprintf("%04d-%02d", p.Year(), m)]; ok {
+				sum += v
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, false
+		}
+		return sum / float64(n), true
+	}
+	base, ok := level(periods[0])
+	if !ok || base == 0 {
+		return out
+	}
+	for i, p := range periods {
+		v, ok := level(p)
+		if !ok {
+			break
+		}
+		index := math.Round(v/base*100*100) / 100
+		out[i] = &index
+	}
+	return out
+}
+
+// annualIndexSeries returns the official price index for each period, chained
+// from ipc_rates and equal to 100 at periods[0]. Monthly steps spread the
+// year's rate evenly ((1+rate)^(1/12) per month). An entry is nil when the
+// rate for its year (or any earlier step) is unknown.
+func (s *SQLiteStore) annualIndexSeries(periods []time.Time, g Granularity) ([]*float64, error) {
+	out := make([]*float64, len(periods))
+	if len(periods) == 0 {
+		return out, nil
+	}
+	rates, err := s.ipcRatesByYear()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rates[periods[0].Year()]; !ok {
+		return out, nil
+	}
+	index := 100.0
+	for i, p := range periods {
+		if i > 0 {
+			rate, ok := rates[p.Year()]
+			if !ok {
+				break
+			}
+			if g == GranularityMonth {
+				index *= math.Pow(1+rate, 1.0/12)
+			} else {
+				index *= 1 + rate
+			}
+		}
+		v := math.Round(index*100) / 100
+		out[i] = &v
+	}
+	return out, nil
+}
+
+// priceObservation is a product's average unit price and total spend within
+// one period of an inflation series.
+type priceObservation struct {
+	price float64
+	spend float64
+}
+
+// GetPersonalInflation computes a chained Laspeyres price index over the
+// products userID's household bought between from and to, for monthly or
+// yearly periods, next to the official IPC index for the same periods.
+//
+// Each link compares a product's average unit price in a period with its
+// price at its previous purchase, weighted by what the household spent on it
+// then. Products bought only once do not contribute, and records flagged for
+// review are left out. A zero from starts the series at the first purchase.
+//
+// The IPC index comes from the monthly series when it has been imported and
+// otherwise falls back to the yearly headline rates; the response names the
+// series used.
+func (s *SQLiteStore) GetPersonalInflation(userID int64, from, to time.Time, g Granularity, series IPCSeries) (models.InflationComparison, error) {
+	result := models.InflationComparison{
+		Granularity: string(g),
+		IPCRegion:   series.Region,
+		IPCSubindex: series.Subindex,
+		From:        from.Format(time.DateOnly),
+		To:          to.Format(time.DateOnly),
+		Points:      []models.InflationPoint{},
+	}
+	if g != GranularityMonth && g != GranularityYear {
+		return result, fmt.Errorf("unsupported granularity %q for inflation", g)
+	}
+	period, err := periodStartSQL(g)
+	if err != nil {
+		return result, err
+	}
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return result, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	args := append(append([]any{}, baseArgs...), from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	rows, err := s.db.Query(`
+		SELECT product_id, `+period+` AS period, AVG(price), SUM(price * quantity)
+		FROM price_records
+		WHERE `+clause+` AND anomaly IS NULL AND date >= ? AND date <= ?
+		GROUP BY product_id, period
+		ORDER BY period
+	`, args...)
+	if err != nil {
+		return result, fmt.Errorf("get personal inflation: %w", err)
+	}
+	defer rows.Close()
+
+	type row struct {
+		productID, period string
+		price, spend      float64
+	}
+	var data []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.productID, &r.period, &r.price, &r.spend); err != nil {
+			return result, fmt.Errorf("scan personal inflation row: %w", err)
+		}
+		data = append(data, r)
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate personal inflation rows: %w", err)
+	}
+	if len(data) == 0 {
+		return result, nil
+	}
+
+	first, err := time.Parse(time.DateOnly, data[0].period)
+	if err != nil {
+		return result, fmt.Errorf("parse period %q: %w", data[0].period, err)
+	}
+	var periods []time.Time
+	periodIndex := make(map[string]int)
+	for p := first; !p.After(to); p = nextPeriod(p, g) {
+		periodIndex[p.Format(time.DateOnly)] = len(periods)
+		periods = append(periods, p)
+	}
+	result.From = periods[0].Format(time.DateOnly)
+
+	// Rows arrive ordered by period, so each product's observations are in
+	// chronological order.
+	last := make(map[string]priceObservation)
+	links := make([]struct{ num, den float64 }, len(periods))
+	matched := make([]int, len(periods))
+	for _, r := range data {
+		idx := periodIndex[r.period]
+		obs := priceObservation{price: r.price, spend: r.spend}
+		if prev, ok := last[r.productID]; ok && prev.price > 0 {
+			links[idx].num += prev.spend * (obs.price / prev.price)
+			links[idx].den += prev.spend
+			matched[idx]++
+		}
+		last[r.productID] = obs
+	}
+
+	ipc, used, err := s.ipcIndexSeries(periods, g, series)
+	if err != nil {
+		return result, err
+	}
+	result.IPCRegion, result.IPCSubindex = used.Region, used.Subindex
+
+	index := 100.0
+	var lastIPC *float64
+	for i, p := range periods {
+		if links[i].den > 0 {
+			index *= links[i].num / links[i].den
+		}
+		result.Points = append(result.Points, models.InflationPoint{
+			Period:          p.Format(time.DateOnly),
+			PersonalIndex:   math.Round(index*100) / 100,
+			IPCIndex:        ipc[i],
+			MatchedProducts: matched[i],
+		})
+		if ipc[i] != nil {
+			lastIPC = ipc[i]
+		}
+	}
+	result.PersonalChangePercent = math.Round((index-100)*100) / 100
+	if lastIPC != nil {
+		v := math.Round((*lastIPC-100)*100) / 100
+		result.IPCChangePercent = &v
+	}
+	return result, nil
+}
+
+// ipcPriceLevels turns ipc_rates into a price level per year, relative to the
+// year before the first rate (level 1). Years missing inside the covered range
+// carry the previous level forward. Returns nil when ipc_rates is empty.
+func (s *SQLiteStore) ipcPriceLevels() (levels map[int]float64, first, last int, err error) {
+	rates, err := s.ipcRatesByYear()
+	if err != nil || len(rates) == 0 {
+		return nil, 0, 0, err
+	}
+	first, last = math.MaxInt, math.MinInt
+	for year := range rates {
+		first, last = min(first, year), max(last, year)
+	}
+	levels = make(map[int]float64, last-first+1)
+	level := 1.0
+	for year := first; year <= last; year++ {
+		level *= 1 + rates[year]
+		levels[year] = level
+	}
+	return levels, first, last, nil
+}
+
+// DeflatePriceHistory expresses history in constant euros of baseYear using
+// ipc_rates, and compares the product's change since its first purchase with
+// IPC over the same years. Purchases outside the years IPC covers use the
+// nearest known year. A zero baseYear selects the latest year with data.
+// Records flagged for review are skipped. Returns nil when history has no
+// unflagged records or ipc_rates is empty.
+func (s *SQLiteStore) DeflatePriceHistory(history []models.PriceRecord, baseYear int) (*models.InflationAdjustment, error) {
+	history = unflaggedRecords(history)
+	levels, first, last, err := s.ipcPriceLevels()
+	if err != nil {
+		return nil, err
+	}
+	if baseYear == 0 {
+		baseYear = last
+	}
+	if levels != nil && (baseYear < first || baseYear > last) {
+		return nil, fmt.Errorf("%w: IPC covers %d to %d", ErrInvalidBaseYear, first, last)
+	}
+	if len(history) == 0 || levels == nil {
+		return nil, nil
+	}
+	levelAt := func(t time.Time) float64 {
+		return levels[min(max(t.Year(), first), last)]
+	}
+
+	adj := &models.InflationAdjustment{
+		BaseYear: baseYear,
+		History:  make([]models.DeflatedPrice, 0, len(history)),
+	}
+	oldest, newest := history[0], history[0]
+	for _, rec := range history {
+		adj.History = append(adj.History, models.DeflatedPrice{
+			Date:      rec.Date,
+			Price:     rec.Price,
+			RealPrice: roundCents(rec.Price * levels[baseYear] / levelAt(rec.Date)),
+		})
+		if rec.Date.Before(oldest.Date) {
+			oldest = rec
+		}
+		if rec.Date.After(newest.Date) {
+			newest = rec
+		}
+	}
+	if oldest.Price <= 0 {
+		adj.Verdict = VerdictInLine
+		return adj, nil
+	}
+
+	nominal := newest.Price/oldest.Price - 1
+	ipc := levelAt(newest.Date)/levelAt(oldest.Date) - 1
+	adj.NominalChangePercent = roundCents(nominal * 100)
+	adj.IPCChangePercent = roundCents(ipc * 100)
+	adj.RealChangePercent = roundCents(((1+nominal)/(1+ipc) - 1) * 100)
+	switch gap := (nominal - ipc) * 100; {
+	case gap > inflationVerdictTolerance:
+		adj.Verdict = VerdictFaster
+	case gap < -inflationVerdictTolerance:
+		adj.Verdict = VerdictSlower
+	default:
+		adj.Verdict = VerdictInLine
+	}
+	return adj, nil
+}
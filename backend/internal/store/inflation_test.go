@@ -0,0 +1,176 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// ---------- GetPersonalInflation ----------
+
+func seedInflation(t *testing.T, s *store.SQLiteStore, uid int64) {
+	t.Helper()
+	entries := []models.PriceRecordEntry{
+		{Name: "LECHE ENTERA", Record: models.PriceRecord{Date: date(2023, 3, 1), Price: 1.00, Store: "Mercadona"}},
+		{Name: "CAFE MOLIDO", Record: models.PriceRecord{Date: date(2023, 3, 1), Price: 2.00, Quantity: 2, Store: "Mercadona"}},
+		{Name: "LECHE ENTERA", Record: models.PriceRecord{Date: date(2024, 3, 1), Price: 1.10, Store: "Mercadona"}},
+		{Name: "CAFE MOLIDO", Record: models.PriceRecord{Date: date(2024, 3, 1), Price: 2.00, Store: "Mercadona"}},
+		{Name: "LECHE ENTERA", Record: models.PriceRecord{Date: date(2025, 3, 1), Price: 1.21, Store: "Mercadona"}},
+		{Name: "PAN DE MOLDE", Record: models.PriceRecord{Date: date(2025, 3, 1), Price: 1.50, Store: "Mercadona"}}, // bought once
+	}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("UpsertPriceRecordBatch: %v", err)
+	}
+}
+
+func TestGetPersonalInflation_Yearly_WeightsBySpend(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedInflation(t, s, uid)
+
+	got, err := s.GetPersonalInflation(uid, time.Time{}, date(2026, 6, 30), store.GranularityYear, store.HeadlineIPC)
+	if err != nil {
+		t.Fatalf("GetPersonalInflation: %v", err)
+	}
+	if got.From != "2023-01-01" || len(got.Points) != 4 {
+		t.Fatalf("want 4 yearly points from 2023, got %+v", got)
+	}
+
+	// 2024: milk +10% weighted 1.00, coffee flat weighted 4.00 → +2%.
+	// 2025: only milk, +10% → 102 × 1.1.
+	wantPersonal := []float64{100, 102, 112.2, 112.2}
+	wantMatched := []int{0, 2, 1, 0}
+	for i, p := range got.Points {
+		if p.PersonalIndex != wantPersonal[i] || p.MatchedProducts != wantMatched[i] {
+			t.Errorf("point %d: want index %.2f over %d products, got %+v", i, wantPersonal[i], wantMatched[i], p)
+		}
+	}
+
+	// Seeded IPC: 2024 = 2.8%, 2025 = 2.5%, 2026 unknown.
+	if p := got.Points[2]; p.IPCIndex == nil || *p.IPCIndex != 105.37 {
+		t.Errorf("2025 IPC index: want 105.37, got %v", p.IPCIndex)
+	}
+	if got.Points[3].IPCIndex != nil {
+		t.Errorf("2026 IPC index: want null, got %v", *got.Points[3].IPCIndex)
+	}
+	if got.PersonalChangePercent != 12.2 {
+		t.Errorf("personal change: want 12.2, got %v", got.PersonalChangePercent)
+	}
+	if got.IPCChangePercent == nil || *got.IPCChangePercent != 5.37 {
+		t.Errorf("IPC change: want 5.37, got %v", got.IPCChangePercent)
+	}
+}
+
+func TestGetPersonalInflation_Monthly_SpreadsAnnualIPC(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedInflation(t, s, uid)
+
+	got, err := s.GetPersonalInflation(uid, date(2024, 1, 1), date(2025, 3, 31), store.GranularityMonth, store.HeadlineIPC)
+	if err != nil {
+		t.Fatalf("GetPersonalInflation: %v", err)
+	}
+	// Series starts at the first purchase in range (March 2024).
+	if got.From != "2024-03-01" || len(got.Points) != 13 {
+		t.Fatalf("want 13 monthly points from 2024-03, got %d from %s", len(got.Points), got.From)
+	}
+	if last := got.Points[12]; last.PersonalIndex != 110 || last.MatchedProducts != 1 {
+		t.Errorf("March 2025: want milk +10%%, got %+v", last)
+	}
+	// Nine monthly steps at 2.8% a year, then three at 2.5%.
+	if ipc := got.Points[12].IPCIndex; ipc == nil || *ipc != 102.72 {
+		t.Errorf("March 2025 IPC index: want 102.72, got %v", ipc)
+	}
+}
+
+func TestGetPersonalInflation_NoPurchases_ReturnsEmptyPoints(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+
+	got, err := s.GetPersonalInflation(uid, date(2024, 1, 1), date(2024, 12, 31), store.GranularityYear, store.HeadlineIPC)
+	if err != nil {
+		t.Fatalf("GetPersonalInflation: %v", err)
+	}
+	if got.Points == nil || len(got.Points) != 0 || got.IPCChangePercent != nil {
+		t.Errorf("want empty series, got %+v", got)
+	}
+}
+
+// ---------- DeflatePriceHistory ----------
+
+func TestDeflatePriceHistory_ConstantEurosAndVerdict(t *testing.T) {
+	s := newTestStore(t)
+	history := []models.PriceRecord{
+		{Date: date(2024, 5, 1), Price: 1.10},
+		{Date: date(2022, 5, 1), Price: 1.00},
+	}
+
+	adj, err := s.DeflatePriceHistory(history, 2024)
+	if err != nil {
+		t.Fatalf("DeflatePriceHistory: %v", err)
+	}
+	if adj == nil || adj.BaseYear != 2024 || len(adj.History) != 2 {
+		t.Fatalf("unexpected adjustment: %+v", adj)
+	}
+	// Seeded IPC: 2023 = 3.5%, 2024 = 2.8% → 2022 euros × 1.064 in 2024 euros.
+	if got := adj.History[1].RealPrice; got != 1.06 {
+		t.Errorf("2022 price in 2024 euros: want 1.06, got %v", got)
+	}
+	if got := adj.History[0].RealPrice; got != 1.10 {
+		t.Errorf("base-year price must be unchanged, got %v", got)
+	}
+	if adj.NominalChangePercent != 10 || adj.IPCChangePercent != 6.4 || adj.Verdict != store.VerdictFaster {
+		t.Errorf("want +10%% vs IPC +6.4%% (faster), got %+v", adj)
+	}
+	if adj.RealChangePercent != 3.39 {
+		t.Errorf("real change: want 3.39, got %v", adj.RealChangePercent)
+	}
+}
+
+func TestDeflatePriceHistory_Verdicts(t *testing.T) {
+	s := newTestStore(t)
+	tests := []struct {
+		name  string
+		later float64
+		want  string
+	}{
+		{"cheaper", 0.95, store.VerdictSlower},
+		{"tracks ipc", 1.06, store.VerdictInLine},
+		{"outpaces ipc", 1.20, store.VerdictFaster},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := []models.PriceRecord{
+				{Date: date(2022, 5, 1), Price: 1.00},
+				{Date: date(2024, 5, 1), Price: tt.later},
+			}
+			adj, err := s.DeflatePriceHistory(history, 0)
+			if err != nil {
+				t.Fatalf("DeflatePriceHistory: %v", err)
+			}
+			if adj.Verdict != tt.want {
+				t.Errorf("verdict: want %s, got %+v", tt.want, adj)
+			}
+		})
+	}
+}
+
+func TestDeflatePriceHistory_DefaultsToLatestYear_RejectsUncovered(t *testing.T) {
+	s := newTestStore(t)
+	history := []models.PriceRecord{{Date: date(2025, 1, 10), Price: 0.79}}
+
+	adj, err := s.DeflatePriceHistory(history, 0)
+	if err != nil {
+		t.Fatalf("DeflatePriceHistory: %v", err)
+	}
+	if adj.BaseYear != 2025 || adj.Verdict != store.VerdictInLine {
+		t.Errorf("want base year 2025 and in-line verdict, got %+v", adj)
+	}
+
+	if _, err := s.DeflatePriceHistory(history, 1990); !errors.Is(err, store.ErrInvalidBaseYear) {
+		t.Errorf("want ErrInvalidBaseYear for 1990, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"fmt"
+	"math"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- IPC series ----------
+
+// IPCSeries identifies an official monthly IPC series in ipc_monthly: a
+// region ("ES" or an ISO 3166-2:ES code) and a subindex ("general" or an
+// ECOICOP code such as "01" for food and non-alcoholic beverages).
+type IPCSeries struct {
+	Region   string
+	Subindex string
+}
+
+// HeadlineIPC is the all-items series for Catalonia, the one ipc_rates holds
+// yearly and SyncIPCRates refreshes.
+var HeadlineIPC = IPCSeries{Region: "CT", Subindex: "general"}
+
+// FoodIPC is the Catalan "food and non-alcoholic beverages" ECOICOP group, the
+// closest official match to a grocery basket.
+var FoodIPC = IPCSeries{Region: "CT", Subindex: "01"}
+
+// UpsertIPCObservations inserts or updates monthly IPC observations. A nil
+// value in obs leaves the stored value untouched, so index levels and annual
+// rates may be loaded from separate exports.
+func (s *SQLiteStore) UpsertIPCObservations(obs []models.IPCObservation) error {
+	if len(obs) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO ipc_monthly (region, subindex, period, index_value, annual_rate)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (region, subindex, period) DO UPDATE SET
+			index_value = COALESCE(excluded.index_value, index_value),
+			annual_rate = COALESCE(excluded.annual_rate, annual_rate)
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare ipc upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, o := range obs {
+		if _, err := stmt.Exec(o.Region, o.Subindex, o.Period, o.IndexValue, o.AnnualRate); err != nil {
+			return fmt.Errorf("upsert ipc %s/%s %s: %w", o.Region, o.Subindex, o.Period, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ipcMonthlyIndex returns the index levels of series keyed by "YYYY-MM".
+// Returns an empty map when the series has not been imported.
+func (s *SQLiteStore) ipcMonthlyIndex(series IPCSeries) (map[string]float64, error) {
+	rows, err := s.db.Query(
+		`SELECT period, index_value FROM ipc_monthly
+		 WHERE region = ? AND subindex = ? AND index_value IS NOT NULL`,
+		series.Region, series.Subindex,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query ipc_monthly: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]float64)
+	for rows.Next() {
+		var period string
+		var v float64
+		if err := rows.Scan(&period, &v); err != nil {
+			return nil, fmt.Errorf("scan ipc_monthly: %w", err)
+		}
+		values[period] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ipc_monthly: %w", err)
+	}
+	return values, nil
+}
+
+// SyncIPCRates recomputes ipc_rates from the imported monthly HeadlineIPC
+// series, so that the yearly figures follow the official data without a code
+// change. The rate for a year is the change in its average index over the
+// previous year's, which is how INE and IDESCAT define the annual average
+// rate; when index levels are missing it is the mean of the twelve monthly
+// annual rates. Only complete years are written. Returns the number of years
+// updated.
+func (s *SQLiteStore) SyncIPCRates() (int, error) {
+	rows, err := s.db.Query(`
+		SELECT CAST(substr(period, 1, 4) AS INTEGER) AS year,
+		       COUNT(index_value), AVG(index_value),
+		       COUNT(annual_rate), AVG(annual_rate)
+		FROM ipc_monthly
+		WHERE region = ? AND subindex = ?
+		GROUP BY year
+		ORDER BY year
+	`, HeadlineIPC.Region, HeadlineIPC.Subindex)
+	if err != nil {
+		return 0, fmt.Errorf("query ipc_monthly years: %w", err)
+	}
+
+	type yearStats struct {
+		year         int
+		indexCount   int
+		avgIndex     float64
+		rateCount    int
+		avgRate      float64
+		prevComplete bool
+		prevAvgIndex float64
+	}
+	var years []yearStats
+	for rows.Next() {
+		var y yearStats
+		var avgIndex, avgRate *float64
+		if err := rows.Scan(&y.year, &y.indexCount, &avgIndex, &y.rateCount, &avgRate); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan ipc_monthly years: %w", err)
+		}
+		if avgIndex != nil {
+			y.avgIndex = *avgIndex
+		}
+		if avgRate != nil {
+			y.avgRate = *avgRate
+		}
+		if n := len(years); n > 0 && years[n-1].year == y.year-1 && years[n-1].indexCount == 12 {
+			y.prevComplete, y.prevAvgIndex = true, years[n-1].avgIndex
+		}
+		years = append(years, y)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate ipc_monthly years: %w", err)
+	}
+
+	updated := 0
+	for _, y := range years {
+		var rate float64
+		switch {
+		case y.indexCount == 12 && y.prevComplete && y.prevAvgIndex > 0:
+			rate = y.avgIndex/y.prevAvgIndex - 1
+		case y.rateCount == 12:
+			rate = y.avgRate
+		default:
+			continue
+		}
+		if _, err := s.db.Exec(
+			`INSERT INTO ipc_rates (year, rate) VALUES (?, ?)
+			 ON CONFLICT (year) DO UPDATE SET rate = excluded.rate`,
+			y.year, roundRate(rate),
+		); err != nil {
+			return updated, fmt.Errorf("update ipc_rates %d: %w", y.year, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// roundRate rounds a decimal rate to the three decimals (0.1 percentage
+// points) that INE publishes.
+func roundRate(rate float64) float64 {
+	return math.Round(rate*1000) / 1000
+}
@@ -0,0 +1,120 @@
+package store_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+// monthlyIPC returns one observation per month of year for series, all at
+// the given index level.
+func monthlyIPC(series store.IPCSeries, year int, index float64) []models.IPCObservation {
+	obs := make([]models.IPCObservation, 0, 12)
+	for m := 1; m <= 12; m++ {
+		obs = append(obs, models.IPCObservation{
+			Region: series.Region, Subindex: series.Subindex,
+			Period: fmt.Sprintf("%d-%02d", year, m), IndexValue: ptr(index),
+		})
+	}
+	return obs
+}
+
+func TestGetPersonalInflation_UsesImportedMonthlySeries(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedInflation(t, s, uid)
+
+	obs := append(monthlyIPC(store.FoodIPC, 2024, 120), monthlyIPC(store.FoodIPC, 2025, 120)...)
+	obs[14].IndexValue = ptr(126) // 2025-03
+	if err := s.UpsertIPCObservations(obs); err != nil {
+		t.Fatalf("UpsertIPCObservations: %v", err)
+	}
+
+	got, err := s.GetPersonalInflation(uid, date(2024, 1, 1), date(2025, 3, 31), store.GranularityMonth, store.FoodIPC)
+	if err != nil {
+		t.Fatalf("GetPersonalInflation: %v", err)
+	}
+	if got.IPCRegion != "CT" || got.IPCSubindex != "01" {
+		t.Errorf("want the CT/01 series, got %s/%s", got.IPCRegion, got.IPCSubindex)
+	}
+	if last := got.Points[len(got.Points)-1]; last.IPCIndex == nil || *last.IPCIndex != 105 {
+		t.Errorf("March 2025 food IPC: want 105, got %v", last.IPCIndex)
+	}
+}
+
+func TestGetPersonalInflation_FallsBackToHeadlineRates(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedInflation(t, s, uid)
+
+	got, err := s.GetPersonalInflation(uid, date(2024, 1, 1), date(2025, 12, 31), store.GranularityYear, store.FoodIPC)
+	if err != nil {
+		t.Fatalf("GetPersonalInflation: %v", err)
+	}
+	if got.IPCRegion != store.HeadlineIPC.Region || got.IPCSubindex != store.HeadlineIPC.Subindex {
+		t.Errorf("want fallback to the headline series, got %s/%s", got.IPCRegion, got.IPCSubindex)
+	}
+	if got.IPCChangePercent == nil || *got.IPCChangePercent != 2.5 {
+		t.Errorf("want IPC +2.5%% from ipc_rates, got %v", got.IPCChangePercent)
+	}
+}
+
+func TestUpsertIPCObservations_KeepsValuesMissingFromLaterExports(t *testing.T) {
+	s := newTestStore(t)
+	indices := append(monthlyIPC(store.HeadlineIPC, 2023, 100), monthlyIPC(store.HeadlineIPC, 2024, 103)...)
+	if err := s.UpsertIPCObservations(indices); err != nil {
+		t.Fatalf("upsert indices: %v", err)
+	}
+	// A second export carrying only annual rates must not erase the indices.
+	rates := monthlyIPC(store.HeadlineIPC, 2024, 0)
+	for i := range rates {
+		rates[i].IndexValue, rates[i].AnnualRate = nil, ptr(0.05)
+	}
+	if err := s.UpsertIPCObservations(rates); err != nil {
+		t.Fatalf("upsert rates: %v", err)
+	}
+
+	if _, err := s.SyncIPCRates(); err != nil {
+		t.Fatalf("SyncIPCRates: %v", err)
+	}
+	// Indices still present → 2024 is derived from them (3%), not from the
+	// monthly rates (5%).
+	rate, _, err := s.GetAccumulatedIPC(2024)
+	if err != nil {
+		t.Fatalf("GetAccumulatedIPC: %v", err)
+	}
+	if want := 1.03*1.025 - 1; math.Abs(rate-want) > 1e-9 {
+		t.Errorf("want %.5f, got %.5f", want, rate)
+	}
+}
+
+func TestSyncIPCRates_FromCompleteYears(t *testing.T) {
+	s := newTestStore(t)
+	obs := append(monthlyIPC(store.HeadlineIPC, 2023, 100), monthlyIPC(store.HeadlineIPC, 2024, 103)...)
+	obs = append(obs, monthlyIPC(store.FoodIPC, 2024, 200)...) // other subindices are ignored
+	if err := s.UpsertIPCObservations(obs); err != nil {
+		t.Fatalf("UpsertIPCObservations: %v", err)
+	}
+
+	n, err := s.SyncIPCRates()
+	if err != nil {
+		t.Fatalf("SyncIPCRates: %v", err)
+	}
+	// 2023 has no previous year and no monthly rates, so only 2024 is derived.
+	if n != 1 {
+		t.Errorf("want 1 year updated, got %d", n)
+	}
+	rate, toYear, err := s.GetAccumulatedIPC(2024)
+	if err != nil {
+		t.Fatalf("GetAccumulatedIPC: %v", err)
+	}
+	// 2024 is now 3.0% (was 2.8%); 2025 keeps its seeded 2.5%.
+	if want := 1.03*1.025 - 1; math.Abs(rate-want) > 1e-9 || toYear != 2025 {
+		t.Errorf("want %.5f to 2025, got %.5f to %d", want, rate, toYear)
+	}
+}
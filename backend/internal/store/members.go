@@ -0,0 +1,73 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Spending by member ----------
+
+// GetMemberSpending splits what userID's household spent between from and to
+// inclusive by the member who bought, most spent first. Every member is
+// listed, including those who bought nothing. Anonymous callers get no
+// members.
+func (s *SQLiteStore) GetMemberSpending(userID int64, from, to time.Time) (models.MemberSpending, error) {
+	result := models.MemberSpending{
+		From:    from.Format(time.DateOnly),
+		To:      to.Format(time.DateOnly),
+		Members: []models.MemberSpend{},
+	}
+	if userID == 0 {
+		return result, nil
+	}
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return result, fmt.Errorf("resolve household: %w", err)
+	}
+	args := []any{result.From, result.To}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT u.id, u.username,
+		       COALESCE(SUM(pr.price * pr.quantity), 0),
+		       COUNT(pr.id),
+		       COUNT(DISTINCT pr.product_id),
+		       COUNT(DISTINCT pr.date || '|' || pr.store)
+		FROM users u
+		LEFT JOIN price_records pr ON pr.user_id = u.id AND pr.date BETWEEN ? AND ?
+		WHERE u.id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		GROUP BY u.id
+		ORDER BY 3 DESC, u.username ASC
+	`, args...)
+	if err != nil {
+		return result, fmt.Errorf("get member spending: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.MemberSpend
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Spent, &m.Purchases, &m.Products, &m.Trips); err != nil {
+			return result, fmt.Errorf("scan member spending: %w", err)
+		}
+		result.Total += m.Spent
+		result.Members = append(result.Members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate member spending: %w", err)
+	}
+
+	for i := range result.Members {
+		m := &result.Members[i]
+		if result.Total > 0 {
+			m.SharePercent = roundCents(m.Spent / result.Total * 100)
+		}
+		m.Spent = roundCents(m.Spent)
+	}
+	result.Total = roundCents(result.Total)
+	return result, nil
+}
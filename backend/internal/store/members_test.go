@@ -0,0 +1,95 @@
+package store_test
+
+import (
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// newHousehold creates testuser and partner sharing a household.
+func newHousehold(t *testing.T, s *store.SQLiteStore) (uid, partner int64) {
+	t.Helper()
+	uid = createTestUser(t, s)
+	partner = createTestUser2(t, s, "partner")
+	hid, err := s.CreateHousehold(uid)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := s.AddUserToHousehold(partner, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+	return uid, partner
+}
+
+func TestGetMemberSpending_SplitsByBuyer(t *testing.T) {
+	s := newTestStore(t)
+	uid, partner := newHousehold(t, s)
+	createTestUser2(t, s, "idle")
+	importRecords(t, s, uid, "LECHE",
+		models.PriceRecord{Date: date(2025, 3, 3), Price: 1.00, Quantity: 6, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 3, 10), Price: 1.00, Quantity: 6, Store: "Mercadona"},
+	)
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 10), Price: 3.00, Store: "Mercadona"})
+	importRecords(t, s, partner, "PAN", models.PriceRecord{Date: date(2025, 3, 12), Price: 3.00, Store: "Lidl"})
+	importRecords(t, s, partner, "PAN", models.PriceRecord{Date: date(2025, 4, 2), Price: 3.00, Store: "Lidl"}) // out of range
+
+	got, err := s.GetMemberSpending(partner, date(2025, 3, 1), date(2025, 3, 31))
+	if err != nil {
+		t.Fatalf("GetMemberSpending: %v", err)
+	}
+	if got.From != "2025-03-01" || got.To != "2025-03-31" || got.Total != 18 {
+		t.Errorf("unexpected totals %+v", got)
+	}
+	want := []models.MemberSpend{
+		{UserID: uid, Username: "testuser", Spent: 15, SharePercent: 83.33, Purchases: 3, Products: 2, Trips: 2},
+		{UserID: partner, Username: "partner", Spent: 3, SharePercent: 16.67, Purchases: 1, Products: 1, Trips: 1},
+	}
+	if len(got.Members) != len(want) {
+		t.Fatalf("want %d members, got %+v", len(want), got.Members)
+	}
+	for i := range want {
+		if got.Members[i] != want[i] {
+			t.Errorf("member %d: want %+v, got %+v", i, want[i], got.Members[i])
+		}
+	}
+
+	empty, err := s.GetMemberSpending(partner, date(2025, 5, 1), date(2025, 5, 31))
+	if err != nil {
+		t.Fatalf("GetMemberSpending: %v", err)
+	}
+	if len(empty.Members) != 2 || empty.Total != 0 || empty.Members[0].SharePercent != 0 {
+		t.Errorf("want both members listed with nothing spent, got %+v", empty)
+	}
+}
+
+func TestOwnPurchasesOnly(t *testing.T) {
+	s := newTestStore(t)
+	uid, partner := newHousehold(t, s)
+	importRecords(t, s, uid, "LECHE", models.PriceRecord{Date: date(2025, 3, 3), Price: 1.00, Store: "Mercadona"})
+	importRecords(t, s, partner, "LECHE", models.PriceRecord{Date: date(2025, 3, 10), Price: 1.20, Store: "Lidl"})
+	importRecords(t, s, partner, "PAN", models.PriceRecord{Date: date(2025, 3, 10), Price: 3.00, Store: "Lidl"})
+
+	shared, err := s.GetProductByID(uid, "leche")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if len(shared.PriceHistory) != 2 || shared.PriceHistory[1].BoughtBy != "partner" || shared.CurrentPrice != 1.20 {
+		t.Errorf("want the household history attributed to its buyers, got %+v", shared.PriceHistory)
+	}
+	own, err := s.GetOwnProductByID(uid, "leche")
+	if err != nil {
+		t.Fatalf("GetOwnProductByID: %v", err)
+	}
+	if len(own.PriceHistory) != 1 || own.PriceHistory[0].BoughtBy != "testuser" || own.CurrentPrice != 1.00 {
+		t.Errorf("want only testuser's purchase, got %+v", own.PriceHistory)
+	}
+
+	results, err := s.SearchProducts(uid, store.SearchOptions{MineOnly: true})
+	if err != nil {
+		t.Fatalf("SearchProducts: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "leche" || results[0].CurrentPrice != 1.00 || results[0].PurchaseCount != 1 {
+		t.Errorf("want only LECHE at testuser's price, got %+v", results)
+	}
+}
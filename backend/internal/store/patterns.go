@@ -0,0 +1,185 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Shopping patterns ----------
+
+// Parts of the month reported by GetShoppingPatterns, by day of month.
+const (
+	MonthPartStart  = "start"  // days 1-10
+	MonthPartMiddle = "middle" // days 11-20
+	MonthPartEnd    = "end"    // days 21-31
+)
+
+// bigShopQuantile is the share of tickets below the big-shop threshold.
+const bigShopQuantile = 0.75
+
+// patternTicket is a ticket row read by GetShoppingPatterns.
+type patternTicket struct {
+	date  time.Time
+	store string
+	total float64
+	lines int
+}
+
+// GetShoppingPatterns aggregates the tickets of userID's household between
+// from and to inclusive by weekday (Monday first), day of month, part of the
+// month and store; stores are sorted by number of tickets, most first. A big
+// shop is a ticket whose total reaches the top quarter of the range, so that
+// ByMonthPart tells whether the big shops cluster at the start of the month.
+// Visit frequency counts distinct shopping days per store.
+func (s *SQLiteStore) GetShoppingPatterns(userID int64, from, to time.Time) (models.ShoppingPatterns, error) {
+	result := models.ShoppingPatterns{
+		From:         from.Format(time.DateOnly),
+		To:           to.Format(time.DateOnly),
+		ByWeekday:    make([]models.PatternBucket, 7),
+		ByDayOfMonth: make([]models.PatternBucket, 31),
+		ByMonthPart: []models.PatternBucket{
+			{Label: MonthPartStart}, {Label: MonthPartMiddle}, {Label: MonthPartEnd},
+		},
+		ByStore: []models.StorePattern{},
+	}
+	for i := range result.ByWeekday {
+		result.ByWeekday[i].Label = time.Weekday((i + 1) % 7).String()
+	}
+	for i := range result.ByDayOfMonth {
+		result.ByDayOfMonth[i].Label = strconv.Itoa(i + 1)
+	}
+
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return result, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	rows, err := s.db.Query(`
+		SELECT date, store, total, lines
+		FROM tickets
+		WHERE `+clause+` AND date BETWEEN ? AND ?
+		ORDER BY date ASC, id ASC
+	`, append(baseArgs, result.From, result.To)...)
+	if err != nil {
+		return result, fmt.Errorf("get shopping patterns: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []patternTicket
+	for rows.Next() {
+		var t patternTicket
+		var day string
+		if err := rows.Scan(&day, &t.store, &t.total, &t.lines); err != nil {
+			return result, fmt.Errorf("scan ticket: %w", err)
+		}
+		if t.date, err = time.Parse(time.DateOnly, day); err != nil {
+			return result, fmt.Errorf("parse ticket date %q: %w", day, err)
+		}
+		tickets = append(tickets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("iterate tickets: %w", err)
+	}
+	if len(tickets) == 0 {
+		return result, nil
+	}
+
+	totals := make([]float64, len(tickets))
+	for i, t := range tickets {
+		totals[i] = t.total
+	}
+	sort.Float64s(totals)
+	threshold := totals[int(math.Ceil(bigShopQuantile*float64(len(totals))))-1]
+	result.BigShopThreshold = roundCents(threshold)
+
+	var lines int
+	stores := make(map[string]*models.StorePattern)
+	storeLines := make(map[string]int)
+	storeDays := make(map[string][]time.Time) // distinct, ascending
+	for _, t := range tickets {
+		big := t.total >= threshold
+		day := t.date.Day()
+		part := (min(day, 30) - 1) / 10
+		for _, b := range []*models.PatternBucket{
+			&result.ByWeekday[(int(t.date.Weekday())+6)%7],
+			&result.ByDayOfMonth[day-1],
+			&result.ByMonthPart[part],
+		} {
+			addToBucket(b, t, big)
+		}
+
+		result.Tickets++
+		result.Total += t.total
+		lines += t.lines
+
+		sp, ok := stores[t.store]
+		if !ok {
+			sp = &models.StorePattern{Store: t.store}
+			stores[t.store] = sp
+		}
+		sp.Tickets++
+		sp.Total += t.total
+		storeLines[t.store] += t.lines
+		if days := storeDays[t.store]; len(days) == 0 || !days[len(days)-1].Equal(t.date) {
+			storeDays[t.store] = append(days, t.date)
+		}
+	}
+
+	result.AverageBasket = roundCents(result.Total / float64(result.Tickets))
+	result.AverageLines = roundCents(float64(lines) / float64(result.Tickets))
+	result.Total = roundCents(result.Total)
+	for _, buckets := range [][]models.PatternBucket{result.ByWeekday, result.ByDayOfMonth, result.ByMonthPart} {
+		for i := range buckets {
+			finishBucket(&buckets[i])
+		}
+	}
+
+	months := (to.Sub(from).Hours()/24 + 1) / daysPerMonth
+	for name, sp := range stores {
+		sp.AverageBasket = roundCents(sp.Total / float64(sp.Tickets))
+		sp.AverageLines = roundCents(float64(storeLines[name]) / float64(sp.Tickets))
+		sp.Total = roundCents(sp.Total)
+		days := storeDays[name]
+		if months > 0 {
+			sp.VisitsPerMonth = roundCents(float64(len(days)) / months)
+		}
+		if len(days) >= 2 {
+			gap := roundCents(days[len(days)-1].Sub(days[0]).Hours() / 24 / float64(len(days)-1))
+			sp.AverageDaysBetween = &gap
+		}
+		result.ByStore = append(result.ByStore, *sp)
+	}
+	sort.Slice(result.ByStore, func(i, j int) bool {
+		a, b := result.ByStore[i], result.ByStore[j]
+		if a.Tickets != b.Tickets {
+			return a.Tickets > b.Tickets
+		}
+		return a.Store < b.Store
+	})
+	return result, nil
+}
+
+// addToBucket counts t in b; finishBucket computes the averages afterwards.
+func addToBucket(b *models.PatternBucket, t patternTicket, big bool) {
+	b.Tickets++
+	b.Total += t.total
+	b.AverageLines += float64(t.lines)
+	if big {
+		b.BigShops++
+	}
+}
+
+// finishBucket turns the sums accumulated by addToBucket into averages.
+func finishBucket(b *models.PatternBucket) {
+	if b.Tickets == 0 {
+		return
+	}
+	b.AverageBasket = roundCents(b.Total / float64(b.Tickets))
+	b.AverageLines = roundCents(b.AverageLines / float64(b.Tickets))
+	b.Total = roundCents(b.Total)
+}
@@ -0,0 +1,128 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// importTicket persists a ticket of uid at store on day whose lines are
+// (name, record) pairs; the header total and line count come from the lines.
+func importTicket(t *testing.T, s *store.SQLiteStore, uid int64, shop string, day time.Time, entries ...models.PriceRecordEntry) {
+	t.Helper()
+	header := models.TicketHeader{Store: shop, Date: day, Lines: len(entries)}
+	for i := range entries {
+		e := &entries[i]
+		e.Record.Date, e.Record.Store = day, shop
+		if e.Record.Quantity == 0 {
+			e.Record.Quantity = 1
+		}
+		header.Total += e.Record.Price * float64(e.Record.Quantity)
+	}
+	if err := s.UpsertTicket(uid, header, entries); err != nil {
+		t.Fatalf("UpsertTicket: %v", err)
+	}
+}
+
+// line is a ticket line of qty units of name at price.
+func line(name string, price float64, qty int) models.PriceRecordEntry {
+	return models.PriceRecordEntry{Name: name, Record: models.PriceRecord{Price: price, Quantity: qty}}
+}
+
+func TestUpsertTicket_PersistsRecords(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importTicket(t, s, uid, "Mercadona", date(2025, 3, 3), line("LECHE", 1.00, 6), line("PAN", 3.00, 1))
+
+	p, err := s.GetProductByID(uid, "leche")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if p == nil || len(p.PriceHistory) != 1 || p.PriceHistory[0].Quantity != 6 {
+		t.Fatalf("expected the ticket line in the price history, got %+v", p)
+	}
+}
+
+func TestGetShoppingPatterns_Aggregates(t *testing.T) {
+	s := newTestStore(t)
+	uid, partner := newHousehold(t, s)
+	importTicket(t, s, uid, "Mercadona", date(2025, 3, 3), line("LECHE", 1.00, 6), line("PAN", 3.00, 1)) // Monday, 9.00
+	importTicket(t, s, uid, "Mercadona", date(2025, 3, 10), line("ACEITE", 20.00, 1))                    // Monday, 20.00
+	importTicket(t, s, partner, "Lidl", date(2025, 3, 15), line("PAN", 2.00, 2))                         // Saturday, 4.00
+	importTicket(t, s, partner, "Mercadona", date(2025, 3, 24), line("PAN", 5.00, 1))                    // Monday, 5.00
+	importTicket(t, s, uid, "Mercadona", date(2025, 4, 1), line("PAN", 3.00, 1))                         // out of range
+	// Prices added outside a ticket are not visits.
+	importRecords(t, s, uid, "HUEVOS", models.PriceRecord{Date: date(2025, 3, 5), Price: 2.50, Store: "Mercadona"})
+
+	got, err := s.GetShoppingPatterns(partner, date(2025, 3, 1), date(2025, 3, 31))
+	if err != nil {
+		t.Fatalf("GetShoppingPatterns: %v", err)
+	}
+	if got.From != "2025-03-01" || got.To != "2025-03-31" || got.Tickets != 4 || got.Total != 38 ||
+		got.AverageBasket != 9.5 || got.AverageLines != 1.25 || got.BigShopThreshold != 9 {
+		t.Errorf("unexpected totals %+v", got)
+	}
+
+	if len(got.ByWeekday) != 7 || len(got.ByDayOfMonth) != 31 || len(got.ByMonthPart) != 3 {
+		t.Fatalf("unexpected bucket counts %d/%d/%d", len(got.ByWeekday), len(got.ByDayOfMonth), len(got.ByMonthPart))
+	}
+	wantMonday := models.PatternBucket{Label: "Monday", Tickets: 3, Total: 34, AverageBasket: 11.33, AverageLines: 1.33, BigShops: 2}
+	if got.ByWeekday[0] != wantMonday {
+		t.Errorf("Monday: want %+v, got %+v", wantMonday, got.ByWeekday[0])
+	}
+	if sat := got.ByWeekday[5]; sat.Label != "Saturday" || sat.Tickets != 1 || sat.Total != 4 {
+		t.Errorf("unexpected Saturday %+v", sat)
+	}
+	if sun := got.ByWeekday[6]; sun.Label != "Sunday" || sun.Tickets != 0 {
+		t.Errorf("unexpected Sunday %+v", sun)
+	}
+	if d := got.ByDayOfMonth[2]; d.Label != "3" || d.Tickets != 1 || d.Total != 9 || d.AverageLines != 2 {
+		t.Errorf("unexpected day 3 %+v", d)
+	}
+
+	wantParts := []models.PatternBucket{
+		{Label: store.MonthPartStart, Tickets: 2, Total: 29, AverageBasket: 14.5, AverageLines: 1.5, BigShops: 2},
+		{Label: store.MonthPartMiddle, Tickets: 1, Total: 4, AverageBasket: 4, AverageLines: 1},
+		{Label: store.MonthPartEnd, Tickets: 1, Total: 5, AverageBasket: 5, AverageLines: 1},
+	}
+	for i, want := range wantParts {
+		if got.ByMonthPart[i] != want {
+			t.Errorf("month part %d: want %+v, got %+v", i, want, got.ByMonthPart[i])
+		}
+	}
+
+	if len(got.ByStore) != 2 {
+		t.Fatalf("want 2 stores, got %+v", got.ByStore)
+	}
+	merc, lidl := got.ByStore[0], got.ByStore[1]
+	if merc.Store != "Mercadona" || merc.Tickets != 3 || merc.Total != 34 || merc.AverageBasket != 11.33 ||
+		merc.AverageLines != 1.33 || merc.VisitsPerMonth != 2.95 {
+		t.Errorf("unexpected Mercadona %+v", merc)
+	}
+	if merc.AverageDaysBetween == nil || *merc.AverageDaysBetween != 10.5 {
+		t.Errorf("Mercadona AverageDaysBetween: want 10.5, got %v", merc.AverageDaysBetween)
+	}
+	if lidl.Store != "Lidl" || lidl.Tickets != 1 || lidl.AverageDaysBetween != nil {
+		t.Errorf("unexpected Lidl %+v", lidl)
+	}
+}
+
+func TestGetShoppingPatterns_NoTickets(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	other := createTestUser2(t, s, "other")
+	importTicket(t, s, other, "Mercadona", date(2025, 3, 3), line("PAN", 3.00, 1))
+
+	got, err := s.GetShoppingPatterns(uid, date(2025, 3, 1), date(2025, 3, 31))
+	if err != nil {
+		t.Fatalf("GetShoppingPatterns: %v", err)
+	}
+	if got.Tickets != 0 || got.ByStore == nil || len(got.ByStore) != 0 {
+		t.Errorf("expected no tickets from another household, got %+v", got)
+	}
+	if len(got.ByWeekday) != 7 || got.ByWeekday[0].Label != "Monday" || got.ByMonthPart[0].Tickets != 0 {
+		t.Errorf("expected empty labelled buckets, got %+v", got.ByWeekday)
+	}
+}
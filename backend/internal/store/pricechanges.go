@@ -0,0 +1,117 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Price change events ----------
+
+// ChangeDirection restricts GetPriceChanges to rises or drops.
+type ChangeDirection string
+
+const (
+	DirectionAny  ChangeDirection = ""
+	DirectionUp   ChangeDirection = "up"
+	DirectionDown ChangeDirection = "down"
+)
+
+// PriceChangeFilter narrows the events GetPriceChanges returns.
+//
+// From, To and Limit apply to the events (the date the new price was seen);
+// Store restricts the comparison to purchases in that store, so an event
+// compares two consecutive purchases there. MinPercent is a minimum absolute
+// change in percent. SinceLastShop keeps only the changes seen on the
+// household's most recent purchase date.
+type PriceChangeFilter struct {
+	AnalyticsFilter
+	Direction     ChangeDirection
+	MinPercent    float64
+	SinceLastShop bool
+}
+
+// GetPriceChanges derives price change events from consecutive price records
+// of each product bought by userID's household, newest first. Consecutive
+// records at the same price (to the cent) produce no event.
+func (s *SQLiteStore) GetPriceChanges(userID int64, f PriceChangeFilter) ([]models.PriceChangeEvent, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+
+	if f.SinceLastShop {
+		var last sql.NullString
+		if err := s.db.QueryRow(`SELECT MAX(date) FROM price_records WHERE `+clause, baseArgs...).Scan(&last); err != nil {
+			return nil, fmt.Errorf("get last shop date: %w", err)
+		}
+		if !last.Valid {
+			return []models.PriceChangeEvent{}, nil
+		}
+		day, err := time.Parse(time.DateOnly, last.String)
+		if err != nil {
+			return nil, fmt.Errorf("parse last shop date %q: %w", last.String, err)
+		}
+		f.From, f.To = day, day
+	}
+
+	// The store filter applies before pairing records; the date range after.
+	storeFilter, storeArgs := AnalyticsFilter{Store: f.Store}.recordClause("")
+	dateFilter, dateArgs := AnalyticsFilter{From: f.From, To: f.To}.recordClause("c.")
+	args := append(append([]any{}, baseArgs...), storeArgs...)
+	args = append(args, dateArgs...)
+
+	var where string
+	switch f.Direction {
+	case DirectionUp:
+		where += ` AND c.price > c.previous_price`
+	case DirectionDown:
+		where += ` AND c.price < c.previous_price`
+	}
+	if f.MinPercent > 0 {
+		where += ` AND ABS(c.price - c.previous_price) * 100 >= ? * c.previous_price`
+		args = append(args, f.MinPercent)
+	}
+	args = append(args, f.sqlLimit())
+
+	rows, err := s.db.Query(`
+		SELECT c.product_id, p.name, COALESCE(p.image_url, ''), c.store,
+		       c.previous_date, c.date, c.previous_price, c.price
+		FROM (
+			SELECT id, product_id, date, price, store, anomaly,
+			       LAG(price) OVER w AS previous_price,
+			       LAG(date)  OVER w AS previous_date
+			FROM price_records
+			WHERE `+clause+storeFilter+`
+			WINDOW w AS (PARTITION BY product_id ORDER BY date, id)
+		) c
+		JOIN products p ON p.id = c.product_id
+		WHERE c.previous_price IS NOT NULL
+		  AND c.previous_price > 0
+		  AND ABS(c.price - c.previous_price) >= 0.005`+dateFilter+where+`
+		ORDER BY c.date DESC, c.id DESC
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get price changes: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.PriceChangeEvent{}
+	for rows.Next() {
+		var e models.PriceChangeEvent
+		if err := rows.Scan(&e.ProductID, &e.Name, &e.ImageURL, &e.Store,
+			&e.PreviousDate, &e.Date, &e.OldPrice, &e.NewPrice); err != nil {
+			return nil, fmt.Errorf("scan price change: %w", err)
+		}
+		e.ChangePercent = roundCents((e.NewPrice - e.OldPrice) / e.OldPrice * 100)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate price changes: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,101 @@
+package store_test
+
+import (
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func seedPriceChanges(t *testing.T, s *store.SQLiteStore, uid int64) {
+	t.Helper()
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+		{Date: date(2025, 2, 10), Price: 1.00, Store: "Mercadona"}, // no change
+		{Date: date(2025, 3, 10), Price: 1.10, Store: "Mercadona"}, // +10%
+		{Date: date(2025, 3, 12), Price: 1.25, Store: "Bonpreu"},   // +13.64%
+	}})
+	insertProductForUser(t, s, uid, models.Product{Name: "ACEITE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 8.00, Store: "Mercadona"},
+		{Date: date(2025, 3, 12), Price: 7.80, Store: "Mercadona"}, // −2.5%
+	}})
+}
+
+func TestGetPriceChanges_ConsecutiveRecordsNewestFirst(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedPriceChanges(t, s, uid)
+
+	got, err := s.GetPriceChanges(uid, store.PriceChangeFilter{})
+	if err != nil {
+		t.Fatalf("GetPriceChanges: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("want 3 events, got %+v", got)
+	}
+	// Both 2025-03-12 events come first; ACEITE was inserted last.
+	if got[0].Name != "ACEITE" || got[0].ChangePercent != -2.5 || got[0].PreviousDate != "2025-01-10" {
+		t.Errorf("first: want ACEITE −2.5%%, got %+v", got[0])
+	}
+	want := models.PriceChangeEvent{
+		ProductID: "leche", Name: "LECHE", Store: "Bonpreu",
+		PreviousDate: "2025-03-10", Date: "2025-03-12",
+		OldPrice: 1.10, NewPrice: 1.25, ChangePercent: 13.64,
+	}
+	if got[1] != want {
+		t.Errorf("second: want %+v, got %+v", want, got[1])
+	}
+	if got[2].Date != "2025-03-10" || got[2].ChangePercent != 10 {
+		t.Errorf("third: want LECHE +10%% on 2025-03-10, got %+v", got[2])
+	}
+}
+
+func TestGetPriceChanges_Filters(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedPriceChanges(t, s, uid)
+
+	tests := []struct {
+		name string
+		f    store.PriceChangeFilter
+		want []float64 // ChangePercent of each event
+	}{
+		{"up only", store.PriceChangeFilter{Direction: store.DirectionUp}, []float64{13.64, 10}},
+		{"down only", store.PriceChangeFilter{Direction: store.DirectionDown}, []float64{-2.5}},
+		{"min magnitude", store.PriceChangeFilter{MinPercent: 11}, []float64{13.64}},
+		{"last shop", store.PriceChangeFilter{SinceLastShop: true}, []float64{-2.5, 13.64}},
+		{"date range", store.PriceChangeFilter{AnalyticsFilter: store.AnalyticsFilter{From: date(2025, 3, 1), To: date(2025, 3, 10)}}, []float64{10}},
+		// Within Bonpreu alone there is a single LECHE purchase, so no event.
+		{"store", store.PriceChangeFilter{AnalyticsFilter: store.AnalyticsFilter{Store: "bonpreu"}}, []float64{}},
+		{"limit", store.PriceChangeFilter{AnalyticsFilter: store.AnalyticsFilter{Limit: 1}}, []float64{-2.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetPriceChanges(uid, tt.f)
+			if err != nil {
+				t.Fatalf("GetPriceChanges: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %v, got %+v", tt.want, got)
+			}
+			for i, e := range got {
+				if e.ChangePercent != tt.want[i] {
+					t.Errorf("event %d: want %v%%, got %+v", i, tt.want[i], e)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPriceChanges_NoPurchases_ReturnsEmptySlice(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+
+	got, err := s.GetPriceChanges(uid, store.PriceChangeFilter{SinceLastShop: true})
+	if err != nil {
+		t.Fatalf("GetPriceChanges: %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("want empty non-nil slice, got %#v", got)
+	}
+}
@@ -0,0 +1,263 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Product groups ----------
+
+var (
+	// ErrInvalidProductGroup is returned (wrapped) when a group would have
+	// fewer than two products, an unknown product, or a product that is
+	// already in another group of the household.
+	ErrInvalidProductGroup = errors.New("invalid product group")
+	// ErrProductGroupNotFound is returned when a group does not exist or
+	// belongs to another household.
+	ErrProductGroupNotFound = errors.New("product group not found")
+)
+
+// CreateProductGroup links productIDs as equivalents for userID's household
+// and returns the new group's ID.
+func (s *SQLiteStore) CreateProductGroup(userID int64, name string, productIDs []string) (int64, error) {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, id := range productIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) < 2 {
+		return 0, fmt.Errorf("%w: at least two products are needed", ErrInvalidProductGroup)
+	}
+	for _, id := range unique {
+		if err := s.checkGroupable(userID, id); err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	res, err := tx.Exec(
+		`INSERT INTO product_groups (user_id, name, created_at) VALUES (?, ?, ?)`,
+		userID, name, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create product group %q: %w", name, err)
+	}
+	groupID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("get last insert id: %w", err)
+	}
+	for _, id := range unique {
+		if _, err := tx.Exec(
+			`INSERT INTO product_group_members (group_id, product_id) VALUES (?, ?)`, groupID, id,
+		); err != nil {
+			return 0, fmt.Errorf("add product %s to group %d: %w", id, groupID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return groupID, nil
+}
+
+// checkGroupable returns an error wrapping ErrInvalidProductGroup unless
+// productID exists and is not yet in one of the household's groups.
+func (s *SQLiteStore) checkGroupable(userID int64, productID string) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	var exists, grouped bool
+	if err := s.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM products WHERE id = ?),
+		       EXISTS (SELECT 1 FROM product_group_members m
+		               JOIN product_groups g ON g.id = m.group_id
+		               WHERE m.product_id = ? AND g.`+clause+`)
+	`, append([]any{productID, productID}, baseArgs...)...).Scan(&exists, &grouped); err != nil {
+		return fmt.Errorf("check product %s: %w", productID, err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: unknown product %q", ErrInvalidProductGroup, productID)
+	}
+	if grouped {
+		return fmt.Errorf("%w: product %q is already in a group", ErrInvalidProductGroup, productID)
+	}
+	return nil
+}
+
+// GetProductGroups returns the product groups of userID's household, by name.
+func (s *SQLiteStore) GetProductGroups(userID int64) ([]models.ProductGroup, error) {
+	return s.productGroups(userID, 0)
+}
+
+// GetProductGroup returns one group of userID's household, or nil if it does
+// not exist or belongs to another household.
+func (s *SQLiteStore) GetProductGroup(userID, groupID int64) (*models.ProductGroup, error) {
+	groups, err := s.productGroups(userID, groupID)
+	if err != nil || len(groups) == 0 {
+		return nil, err
+	}
+	return &groups[0], nil
+}
+
+// productGroups loads the household's groups, or only groupID when non-zero.
+func (s *SQLiteStore) productGroups(userID, groupID int64) ([]models.ProductGroup, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	only, onlyArgs := "", []any(nil)
+	if groupID != 0 {
+		only, onlyArgs = ` AND g.id = ?`, []any{groupID}
+	}
+
+	rows, err := s.db.Query(`
+		SELECT g.id, g.name, u.username, g.created_at
+		FROM product_groups g
+		JOIN users u ON u.id = g.user_id
+		WHERE g.`+clause+only+`
+		ORDER BY g.name, g.id
+	`, append(append([]any{}, baseArgs...), onlyArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("get product groups: %w", err)
+	}
+	groups := []models.ProductGroup{}
+	byID := make(map[int64]int)
+	for rows.Next() {
+		var g models.ProductGroup
+		var createdAt string
+		if err := rows.Scan(&g.ID, &g.Name, &g.CreatedBy, &createdAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan product group: %w", err)
+		}
+		if g.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("parse product group created_at: %w", err)
+		}
+		g.Products = []models.ProductGroupMember{}
+		byID[g.ID] = len(groups)
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product groups: %w", err)
+	}
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	// clause appears twice (latest prices + group ownership).
+	rows, err = s.db.Query(`
+		WITH latest AS (
+			SELECT product_id, price, store, date FROM (
+				SELECT product_id, price, store, date,
+				       ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY date DESC, id DESC) AS rn
+				FROM price_records
+				WHERE `+clause+` AND anomaly IS NULL
+			)
+			WHERE rn = 1
+		)
+		SELECT m.group_id, p.id, p.name, COALESCE(p.image_url, ''),
+		       COALESCE(lp.store, ''), COALESCE(lp.price, 0), COALESCE(lp.date, '')
+		FROM product_group_members m
+		JOIN product_groups g ON g.id = m.group_id
+		JOIN products p ON p.id = m.product_id
+		LEFT JOIN latest lp ON lp.product_id = m.product_id
+		WHERE g.`+clause+only+`
+		ORDER BY p.name
+	`, append(repeatArgs(baseArgs, 2), onlyArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("get product group members: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var m models.ProductGroupMember
+		if err := rows.Scan(&id, &m.ProductID, &m.Name, &m.ImageURL, &m.Store, &m.LatestPrice, &m.LatestDate); err != nil {
+			return nil, fmt.Errorf("scan product group member: %w", err)
+		}
+		g := &groups[byID[id]]
+		g.Products = append(g.Products, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product group members: %w", err)
+	}
+	return groups, nil
+}
+
+// checkProductGroup returns ErrProductGroupNotFound unless groupID belongs to
+// userID's household.
+func (s *SQLiteStore) checkProductGroup(userID, groupID int64) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	var ok bool
+	if err := s.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM product_groups WHERE id = ? AND `+clause+`)`,
+		append([]any{groupID}, baseArgs...)...,
+	).Scan(&ok); err != nil {
+		return fmt.Errorf("check product group %d: %w", groupID, err)
+	}
+	if !ok {
+		return ErrProductGroupNotFound
+	}
+	return nil
+}
+
+// AddProductGroupMember links productID into groupID.
+func (s *SQLiteStore) AddProductGroupMember(userID, groupID int64, productID string) error {
+	if err := s.checkProductGroup(userID, groupID); err != nil {
+		return err
+	}
+	if err := s.checkGroupable(userID, productID); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(
+		`INSERT INTO product_group_members (group_id, product_id) VALUES (?, ?)`, groupID, productID,
+	); err != nil {
+		return fmt.Errorf("add product %s to group %d: %w", productID, groupID, err)
+	}
+	return nil
+}
+
+// RemoveProductGroupMember unlinks productID from groupID.
+func (s *SQLiteStore) RemoveProductGroupMember(userID, groupID int64, productID string) error {
+	if err := s.checkProductGroup(userID, groupID); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(
+		`DELETE FROM product_group_members WHERE group_id = ? AND product_id = ?`, groupID, productID,
+	)
+	if err != nil {
+		return fmt.Errorf("remove product %s from group %d: %w", productID, groupID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrProductGroupNotFound
+	}
+	return nil
+}
+
+// DeleteProductGroup unlinks every product of groupID and deletes it.
+func (s *SQLiteStore) DeleteProductGroup(userID, groupID int64) error {
+	if err := s.checkProductGroup(userID, groupID); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM product_groups WHERE id = ?`, groupID); err != nil {
+		return fmt.Errorf("delete product group %d: %w", groupID, err)
+	}
+	return nil
+}
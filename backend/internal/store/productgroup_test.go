@@ -0,0 +1,85 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func TestProductGroups_LinkAndUnlink(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	stranger := createTestUser2(t, s, "stranger")
+	importRecords(t, s, uid, "LECHE HACENDADO", models.PriceRecord{Date: date(2025, 2, 1), Price: 0.90, Store: "Mercadona"})
+	importRecords(t, s, uid, "LECHE LIDL", models.PriceRecord{Date: date(2025, 3, 1), Price: 0.80, Store: "Lidl"})
+	importRecords(t, s, uid, "LECHE CARREFOUR", models.PriceRecord{Date: date(2025, 3, 2), Price: 0.85, Store: "Carrefour"})
+
+	groupID, err := s.CreateProductGroup(uid, "Leche", []string{"leche-hacendado", "leche-lidl", "leche-lidl"})
+	if err != nil {
+		t.Fatalf("CreateProductGroup: %v", err)
+	}
+	group, err := s.GetProductGroup(uid, groupID)
+	if err != nil || group == nil {
+		t.Fatalf("GetProductGroup: %v, %v", group, err)
+	}
+	if group.Name != "Leche" || group.CreatedBy != "testuser" || len(group.Products) != 2 {
+		t.Fatalf("unexpected group %+v", group)
+	}
+	if m := group.Products[1]; m.ProductID != "leche-lidl" || m.Store != "Lidl" || m.LatestPrice != 0.80 {
+		t.Errorf("unexpected member %+v", m)
+	}
+
+	if err := s.AddProductGroupMember(uid, groupID, "leche-carrefour"); err != nil {
+		t.Fatalf("AddProductGroupMember: %v", err)
+	}
+	if err := s.RemoveProductGroupMember(uid, groupID, "leche-hacendado"); err != nil {
+		t.Fatalf("RemoveProductGroupMember: %v", err)
+	}
+	group, _ = s.GetProductGroup(uid, groupID)
+	if len(group.Products) != 2 || group.Products[0].ProductID != "leche-carrefour" {
+		t.Errorf("unexpected members after edits %+v", group.Products)
+	}
+
+	if list, _ := s.GetProductGroups(stranger); len(list) != 0 {
+		t.Errorf("stranger should see no groups, got %+v", list)
+	}
+	if err := s.DeleteProductGroup(stranger, groupID); !errors.Is(err, store.ErrProductGroupNotFound) {
+		t.Errorf("stranger deleting: want ErrProductGroupNotFound, got %v", err)
+	}
+	if err := s.DeleteProductGroup(uid, groupID); err != nil {
+		t.Fatalf("DeleteProductGroup: %v", err)
+	}
+	if list, _ := s.GetProductGroups(uid); len(list) != 0 {
+		t.Errorf("want no groups left, got %+v", list)
+	}
+}
+
+func TestCreateProductGroup_Invalid(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "LECHE HACENDADO", models.PriceRecord{Date: date(2025, 2, 1), Price: 0.90, Store: "Mercadona"})
+	importRecords(t, s, uid, "LECHE LIDL", models.PriceRecord{Date: date(2025, 3, 1), Price: 0.80, Store: "Lidl"})
+	importRecords(t, s, uid, "LECHE DIA", models.PriceRecord{Date: date(2025, 3, 1), Price: 0.75, Store: "Dia"})
+	if _, err := s.CreateProductGroup(uid, "Leche", []string{"leche-hacendado", "leche-lidl"}); err != nil {
+		t.Fatalf("CreateProductGroup: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		products []string
+	}{
+		{"single product", []string{"leche-dia", "leche-dia"}},
+		{"unknown product", []string{"leche-dia", "nope"}},
+		{"already grouped", []string{"leche-dia", "leche-lidl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateProductGroup(uid, "Otra", tt.products)
+			if !errors.Is(err, store.ErrInvalidProductGroup) {
+				t.Errorf("want ErrInvalidProductGroup, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Price rankings ----------
+
+// minVolatilityRecords is the number of purchases a product needs before its
+// price variation is meaningful enough to rank.
+const minVolatilityRecords = 3
+
+// samePrice reports whether two prices are equal to the cent.
+func samePrice(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+// pricePoint is one price record within a productHistory.
+type pricePoint struct {
+	date  string
+	price float64
+}
+
+// productHistory is the chronological price history of one product.
+type productHistory struct {
+	id, name, imageURL string
+	points             []pricePoint
+}
+
+// filteredHistories returns the price history of every product userID's
+// household bought, restricted to the records that match f, oldest first.
+func (s *SQLiteStore) filteredHistories(userID int64, f AnalyticsFilter) ([]productHistory, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	filter, filterArgs := f.recordClause("pr.")
+	args := append(append([]any{}, baseArgs...), filterArgs...)
+
+	rows, err := s.db.Query(`
+		SELECT p.id, p.name, COALESCE(p.image_url, ''), pr.date, pr.price
+		FROM price_records pr
+		JOIN products p ON p.id = pr.product_id
+		WHERE pr.`+clause+filter+`
+		ORDER BY p.id, pr.date, pr.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get price histories: %w", err)
+	}
+	defer rows.Close()
+
+	var histories []productHistory
+	for rows.Next() {
+		var id, name, imageURL string
+		var pt pricePoint
+		if err := rows.Scan(&id, &name, &imageURL, &pt.date, &pt.price); err != nil {
+			return nil, fmt.Errorf("scan price history: %w", err)
+		}
+		if n := len(histories); n == 0 || histories[n-1].id != id {
+			histories = append(histories, productHistory{id: id, name: name, imageURL: imageURL})
+		}
+		h := &histories[len(histories)-1]
+		h.points = append(h.points, pt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate price histories: %w", err)
+	}
+	return histories, nil
+}
+
+// truncate returns the first limit elements of s, or s when limit <= 0.
+func truncate[T any](s []T, limit int) []T {
+	if limit > 0 && len(s) > limit {
+		return s[:limit]
+	}
+	return s
+}
+
+// GetPriceRankings returns the rankings of GetBiggestPriceDecreases,
+// GetMostVolatile and GetLongestStablePrices, reading the household's price
+// histories once for all three.
+func (s *SQLiteStore) GetPriceRankings(userID int64, f AnalyticsFilter) (models.PriceRankings, error) {
+	var rankings models.PriceRankings
+	histories, err := s.filteredHistories(userID, f)
+	if err != nil {
+		return rankings, err
+	}
+	if rankings.LongestStable, err = longestStablePrices(histories, f.Limit); err != nil {
+		return rankings, err
+	}
+	rankings.BiggestDecreases = biggestPriceDecreases(histories, f.Limit)
+	rankings.MostVolatile = mostVolatile(histories, f.Limit)
+	return rankings, nil
+}
+
+// GetBiggestPriceDecreases returns the top f.Limit products by percentage
+// price drop for userID's household, from the first to the latest record that
+// matches f. Only products with ≥2 such records and a strictly negative change
+// are included.
+func (s *SQLiteStore) GetBiggestPriceDecreases(userID int64, f AnalyticsFilter) ([]models.PriceDecreaseProduct, error) {
+	histories, err := s.filteredHistories(userID, f)
+	if err != nil {
+		return nil, err
+	}
+	return biggestPriceDecreases(histories, f.Limit), nil
+}
+
+// biggestPriceDecreases ranks histories for GetBiggestPriceDecreases.
+func biggestPriceDecreases(histories []productHistory, limit int) []models.PriceDecreaseProduct {
+	results := []models.PriceDecreaseProduct{}
+	for _, h := range histories {
+		first, last := h.points[0].price, h.points[len(h.points)-1].price
+		if len(h.points) < 2 || first <= 0 || last >= first || samePrice(first, last) {
+			continue
+		}
+		results = append(results, models.PriceDecreaseProduct{
+			ID:              h.id,
+			Name:            h.name,
+			ImageURL:        h.imageURL,
+			FirstPrice:      first,
+			CurrentPrice:    last,
+			DecreasePercent: roundCents((first - last) / first * 100),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].DecreasePercent != results[j].DecreasePercent {
+			return results[i].DecreasePercent > results[j].DecreasePercent
+		}
+		return results[i].Name < results[j].Name
+	})
+	return truncate(results, limit)
+}
+
+// GetMostVolatile returns the top f.Limit products of userID's household by
+// coefficient of variation of the prices that match f. Products with fewer
+// than three such records or a constant price are excluded.
+func (s *SQLiteStore) GetMostVolatile(userID int64, f AnalyticsFilter) ([]models.VolatileProduct, error) {
+	histories, err := s.filteredHistories(userID, f)
+	if err != nil {
+		return nil, err
+	}
+	return mostVolatile(histories, f.Limit), nil
+}
+
+// mostVolatile ranks histories for GetMostVolatile.
+func mostVolatile(histories []productHistory, limit int) []models.VolatileProduct {
+	results := []models.VolatileProduct{}
+	for _, h := range histories {
+		if len(h.points) < minVolatilityRecords {
+			continue
+		}
+		var sum, sumSq float64
+		minPrice, maxPrice := h.points[0].price, h.points[0].price
+		for _, pt := range h.points {
+			sum += pt.price
+			sumSq += pt.price * pt.price
+			minPrice, maxPrice = min(minPrice, pt.price), max(maxPrice, pt.price)
+		}
+		n := float64(len(h.points))
+		mean := sum / n
+		if mean <= 0 || samePrice(minPrice, maxPrice) {
+			continue
+		}
+		stddev := math.Sqrt(max(sumSq/n-mean*mean, 0))
+		results = append(results, models.VolatileProduct{
+			ID:               h.id,
+			Name:             h.name,
+			ImageURL:         h.imageURL,
+			PurchaseCount:    len(h.points),
+			MeanPrice:        roundCents(mean),
+			MinPrice:         minPrice,
+			MaxPrice:         maxPrice,
+			VariationPercent: roundCents(stddev / mean * 100),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].VariationPercent != results[j].VariationPercent {
+			return results[i].VariationPercent > results[j].VariationPercent
+		}
+		return results[i].Name < results[j].Name
+	})
+	return truncate(results, limit)
+}
+
+// GetLongestStablePrices returns the top f.Limit products of userID's
+// household whose latest price has held for the longest time: the span from
+// the first purchase of the current unbroken run at that price to the latest
+// purchase, among records that match f. The run must cover at least two
+// purchases on different days.
+func (s *SQLiteStore) GetLongestStablePrices(userID int64, f AnalyticsFilter) ([]models.StablePriceProduct, error) {
+	histories, err := s.filteredHistories(userID, f)
+	if err != nil {
+		return nil, err
+	}
+	return longestStablePrices(histories, f.Limit)
+}
+
+// longestStablePrices ranks histories for GetLongestStablePrices.
+func longestStablePrices(histories []productHistory, limit int) ([]models.StablePriceProduct, error) {
+	results := []models.StablePriceProduct{}
+	for _, h := range histories {
+		last := h.points[len(h.points)-1]
+		start := len(h.points) - 1
+		for start > 0 && samePrice(h.points[start-1].price, last.price) {
+			start--
+		}
+		since := h.points[start]
+		from, err := time.Parse(time.DateOnly, since.date)
+		if err != nil {
+			return nil, fmt.Errorf("parse date %q: %w", since.date, err)
+		}
+		to, err := time.Parse(time.DateOnly, last.date)
+		if err != nil {
+			return nil, fmt.Errorf("parse date %q: %w", last.date, err)
+		}
+		days := int(to.Sub(from).Hours() / 24)
+		if days == 0 {
+			continue
+		}
+		results = append(results, models.StablePriceProduct{
+			ID:               h.id,
+			Name:             h.name,
+			ImageURL:         h.imageURL,
+			CurrentPrice:     last.price,
+			StableSince:      since.date,
+			LastPurchaseDate: last.date,
+			StableDays:       days,
+			PurchaseCount:    len(h.points) - start,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].StableDays != results[j].StableDays {
+			return results[i].StableDays > results[j].StableDays
+		}
+		return results[i].Name < results[j].Name
+	})
+	return truncate(results, limit), nil
+}
@@ -0,0 +1,157 @@
+package store_test
+
+import (
+	"reflect"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// seedRankings inserts four products with distinct price shapes:
+//
+//	ACEITE  4.00 → 3.00          (−25%)
+//	LECHE   1.00 → 0.90 → 0.95   (−5%, mildly volatile)
+//	FRESAS  2.00 → 4.00 → 2.00   (very volatile)
+//	SAL     0.50 × 3 over a year (stable)
+func seedRankings(t *testing.T, s *store.SQLiteStore, uid int64) {
+	t.Helper()
+	histories := map[string][]models.PriceRecord{
+		"ACEITE": {
+			{Date: date(2025, 1, 10), Price: 4.00, Store: "Mercadona"},
+			{Date: date(2025, 3, 10), Price: 3.00, Store: "Mercadona"},
+		},
+		"LECHE": {
+			{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+			{Date: date(2025, 2, 10), Price: 0.90, Store: "Mercadona"},
+			{Date: date(2025, 3, 10), Price: 0.95, Store: "Mercadona"},
+		},
+		"FRESAS": {
+			{Date: date(2025, 1, 10), Price: 2.00, Store: "Bonpreu"},
+			{Date: date(2025, 2, 10), Price: 4.00, Store: "Bonpreu"},
+			{Date: date(2025, 3, 10), Price: 2.00, Store: "Bonpreu"},
+		},
+		"SAL": {
+			{Date: date(2024, 3, 10), Price: 0.45, Store: "Mercadona"},
+			{Date: date(2024, 4, 10), Price: 0.50, Store: "Mercadona"},
+			{Date: date(2024, 10, 10), Price: 0.50, Store: "Mercadona"},
+			{Date: date(2025, 4, 10), Price: 0.50, Store: "Mercadona"},
+		},
+	}
+	for name, history := range histories {
+		insertProductForUser(t, s, uid, models.Product{Name: name, PriceHistory: history})
+	}
+}
+
+func TestGetBiggestPriceDecreases_RankedByPercent(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedRankings(t, s, uid)
+
+	got, err := s.GetBiggestPriceDecreases(uid, store.AnalyticsFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("GetBiggestPriceDecreases: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want ACEITE and LECHE, got %+v", got)
+	}
+	if got[0].Name != "ACEITE" || got[0].DecreasePercent != 25 || got[0].CurrentPrice != 3.00 {
+		t.Errorf("first: want ACEITE −25%%, got %+v", got[0])
+	}
+	if got[1].Name != "LECHE" || got[1].DecreasePercent != 5 {
+		t.Errorf("second: want LECHE −5%%, got %+v", got[1])
+	}
+}
+
+func TestGetMostVolatile_CoefficientOfVariation(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedRankings(t, s, uid)
+
+	got, err := s.GetMostVolatile(uid, store.AnalyticsFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("GetMostVolatile: %v", err)
+	}
+	// ACEITE has only two records and is excluded.
+	if len(got) != 3 || got[0].Name != "FRESAS" {
+		t.Fatalf("want FRESAS first of 3, got %+v", got)
+	}
+	// FRESAS: mean 2.667, population stddev 0.943 → 35.36%.
+	if got[0].VariationPercent != 35.36 || got[0].MinPrice != 2 || got[0].MaxPrice != 4 || got[0].PurchaseCount != 3 {
+		t.Errorf("unexpected FRESAS stats: %+v", got[0])
+	}
+}
+
+func TestGetLongestStablePrices_CurrentRunOnly(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedRankings(t, s, uid)
+
+	got, err := s.GetLongestStablePrices(uid, store.AnalyticsFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("GetLongestStablePrices: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("want 1 result (limit), got %+v", got)
+	}
+	// SAL has been 0.50 since 2024-04-10; the earlier 0.45 breaks the run.
+	want := models.StablePriceProduct{
+		Name: "SAL", CurrentPrice: 0.50, StableSince: "2024-04-10",
+		LastPurchaseDate: "2025-04-10", StableDays: 365, PurchaseCount: 3,
+	}
+	got[0].ID = ""
+	if got[0] != want {
+		t.Errorf("want %+v, got %+v", want, got[0])
+	}
+}
+
+func TestRankings_RespectFilter(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedRankings(t, s, uid)
+
+	f := store.AnalyticsFilter{Store: "Bonpreu", Limit: 10}
+	decreases, err := s.GetBiggestPriceDecreases(uid, f)
+	if err != nil {
+		t.Fatalf("GetBiggestPriceDecreases: %v", err)
+	}
+	volatile, err := s.GetMostVolatile(uid, f)
+	if err != nil {
+		t.Fatalf("GetMostVolatile: %v", err)
+	}
+	stable, err := s.GetLongestStablePrices(uid, f)
+	if err != nil {
+		t.Fatalf("GetLongestStablePrices: %v", err)
+	}
+	if len(decreases) != 0 || len(volatile) != 1 || volatile[0].Name != "FRESAS" || len(stable) != 0 {
+		t.Errorf("Bonpreu only: got decreases=%+v volatile=%+v stable=%+v", decreases, volatile, stable)
+	}
+}
+
+func TestGetPriceRankings_MatchesTheSingleRankings(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedRankings(t, s, uid)
+
+	f := store.AnalyticsFilter{Limit: 10}
+	got, err := s.GetPriceRankings(uid, f)
+	if err != nil {
+		t.Fatalf("GetPriceRankings: %v", err)
+	}
+	decreases, err := s.GetBiggestPriceDecreases(uid, f)
+	if err != nil {
+		t.Fatalf("GetBiggestPriceDecreases: %v", err)
+	}
+	volatile, err := s.GetMostVolatile(uid, f)
+	if err != nil {
+		t.Fatalf("GetMostVolatile: %v", err)
+	}
+	stable, err := s.GetLongestStablePrices(uid, f)
+	if err != nil {
+		t.Fatalf("GetLongestStablePrices: %v", err)
+	}
+	want := models.PriceRankings{BiggestDecreases: decreases, MostVolatile: volatile, LongestStable: stable}
+	if !reflect.DeepEqual(got, want) || len(decreases) == 0 || len(volatile) == 0 || len(stable) == 0 {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Repurchase prediction ----------
+
+const (
+	// minRepurchaseDays is the number of distinct purchase days a product
+	// needs before its repurchase interval is predicted.
+	minRepurchaseDays = 3
+	// dueSoonFraction of the interval before the predicted date marks a
+	// product as due soon.
+	dueSoonFraction = 0.2
+	// abandonedIntervals is how many intervals past the predicted date a
+	// product may go unbought before it is assumed to be no longer bought.
+	abandonedIntervals = 3
+)
+
+// Repurchase statuses.
+const (
+	RepurchaseOK      = "ok"
+	RepurchaseDueSoon = "due_soon"
+	RepurchaseOverdue = "overdue"
+)
+
+// GetRepurchasePredictions predicts the next purchase of every product
+// userID's household has bought on at least three different days up to asOf,
+// most urgent first. Products unbought for more than three intervals past
+// their predicted date are left out as no longer bought.
+func (s *SQLiteStore) GetRepurchasePredictions(userID int64, asOf time.Time) ([]models.RepurchasePrediction, error) {
+	asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	histories, err := s.filteredHistories(userID, AnalyticsFilter{To: asOf})
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.RepurchasePrediction{}
+	for _, h := range histories {
+		days := purchaseDays(h.points)
+		if len(days) < minRepurchaseDays {
+			continue
+		}
+		intervals := make([]float64, len(days)-1)
+		for i := 1; i < len(days); i++ {
+			intervals[i-1] = days[i].Sub(days[i-1]).Hours() / 24
+		}
+		interval := median(intervals)
+		last := days[len(days)-1]
+		next := last.AddDate(0, 0, int(math.Round(interval)))
+		until := int(next.Sub(asOf).Hours() / 24)
+		if float64(-until) > abandonedIntervals*interval {
+			continue
+		}
+
+		status := RepurchaseOK
+		switch {
+		case until < 0:
+			status = RepurchaseOverdue
+		case float64(until) <= dueSoonFraction*interval:
+			status = RepurchaseDueSoon
+		}
+		results = append(results, models.RepurchasePrediction{
+			ProductID:    h.id,
+			Name:         h.name,
+			ImageURL:     h.imageURL,
+			Purchases:    len(days),
+			IntervalDays: math.Round(interval*10) / 10,
+			LastPurchase: last.Format(time.DateOnly),
+			NextPurchase: next.Format(time.DateOnly),
+			DaysUntil:    until,
+			Status:       status,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].DaysUntil != results[j].DaysUntil {
+			return results[i].DaysUntil < results[j].DaysUntil
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// purchaseDays returns the distinct days in points, which are ordered by date.
+// Several records on one day (one ticket, or two shops) count as one purchase.
+func purchaseDays(points []pricePoint) []time.Time {
+	var days []time.Time
+	for _, pt := range points {
+		d, err := time.Parse(time.DateOnly, pt.date)
+		if err != nil {
+			continue
+		}
+		if n := len(days); n > 0 && days[n-1].Equal(d) {
+			continue
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+// median returns the median of values, which must not be empty. values is
+// sorted in place.
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
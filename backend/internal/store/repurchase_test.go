@@ -0,0 +1,75 @@
+package store_test
+
+import (
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func TestGetRepurchasePredictions(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	var entries []models.PriceRecordEntry
+	add := func(name string, days ...[3]int) {
+		for _, d := range days {
+			entries = append(entries, models.PriceRecordEntry{
+				Name:   name,
+				Record: models.PriceRecord{Date: date(d[0], d[1], d[2]), Price: 1, Store: "Mercadona"},
+			})
+		}
+	}
+	add("LECHE", [3]int{2025, 3, 4}, [3]int{2025, 3, 11}, [3]int{2025, 3, 18}, [3]int{2025, 3, 25},
+		[3]int{2025, 4, 5}) // after asOf: ignored
+	add("ACEITE", [3]int{2025, 1, 1}, [3]int{2025, 1, 21}, [3]int{2025, 2, 10})
+	add("PAN", [3]int{2025, 3, 1}, [3]int{2025, 3, 15}, [3]int{2025, 3, 29}, [3]int{2025, 3, 29}) // same day twice
+	add("CAFE", [3]int{2024, 1, 1}, [3]int{2024, 1, 8}, [3]int{2024, 1, 15})                      // no longer bought
+	add("HUEVOS", [3]int{2025, 3, 1}, [3]int{2025, 3, 20})                                        // too few purchases
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("UpsertPriceRecordBatch: %v", err)
+	}
+
+	got, err := s.GetRepurchasePredictions(uid, date(2025, 4, 1))
+	if err != nil {
+		t.Fatalf("GetRepurchasePredictions: %v", err)
+	}
+	want := []models.RepurchasePrediction{
+		{ProductID: "aceite", Name: "ACEITE", Purchases: 3, IntervalDays: 20, LastPurchase: "2025-02-10",
+			NextPurchase: "2025-03-02", DaysUntil: -30, Status: store.RepurchaseOverdue},
+		{ProductID: "leche", Name: "LECHE", Purchases: 4, IntervalDays: 7, LastPurchase: "2025-03-25",
+			NextPurchase: "2025-04-01", DaysUntil: 0, Status: store.RepurchaseDueSoon},
+		{ProductID: "pan", Name: "PAN", Purchases: 3, IntervalDays: 14, LastPurchase: "2025-03-29",
+			NextPurchase: "2025-04-12", DaysUntil: 11, Status: store.RepurchaseOK},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %d predictions, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("prediction %d:\nwant %+v\ngot  %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestGetRepurchasePredictions_MedianIgnoresOneOffGap(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	var entries []models.PriceRecordEntry
+	for _, d := range [][3]int{{2025, 1, 1}, {2025, 1, 8}, {2025, 1, 15}, {2025, 2, 14}, {2025, 2, 21}} {
+		entries = append(entries, models.PriceRecordEntry{
+			Name: "LECHE", Record: models.PriceRecord{Date: date(d[0], d[1], d[2]), Price: 1},
+		})
+	}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("UpsertPriceRecordBatch: %v", err)
+	}
+
+	got, err := s.GetRepurchasePredictions(uid, date(2025, 2, 22))
+	if err != nil {
+		t.Fatalf("GetRepurchasePredictions: %v", err)
+	}
+	// Intervals 7, 7, 30, 7: the holiday gap does not stretch the prediction.
+	if len(got) != 1 || got[0].IntervalDays != 7 || got[0].NextPurchase != "2025-02-28" {
+		t.Errorf("want a 7-day interval, got %+v", got)
+	}
+}
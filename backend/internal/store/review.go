@@ -0,0 +1,186 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Price review ----------
+
+// ErrFlaggedRecordNotFound is returned when a price record does not exist,
+// belongs to another household or is not flagged for review.
+var ErrFlaggedRecordNotFound = errors.New("flagged record not found")
+
+// GetPriceBaselines returns, keyed by name, the median price userID's
+// household paid for each named product over its unflagged purchases.
+// Products never bought are left out.
+func (s *SQLiteStore) GetPriceBaselines(userID int64, names []string) (map[string]models.PriceBaseline, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	productIDs := make([]string, len(names))
+	for i, name := range names {
+		productIDs[i] = slugify(name)
+	}
+	byID, err := s.priceBaselines(clause, baseArgs, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	baselines := make(map[string]models.PriceBaseline, len(byID))
+	for i, name := range names {
+		if b, ok := byID[productIDs[i]]; ok {
+			baselines[name] = b
+		}
+	}
+	return baselines, nil
+}
+
+// priceBaselines returns the baseline of each of productIDs over the
+// unflagged records of the members in clause, keyed by product ID.
+func (s *SQLiteStore) priceBaselines(clause string, baseArgs []any, productIDs []string) (map[string]models.PriceBaseline, error) {
+	baselines := make(map[string]models.PriceBaseline)
+	if len(productIDs) == 0 {
+		return baselines, nil
+	}
+	args := append([]any{}, baseArgs...)
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+	rows, err := s.db.Query(`
+		SELECT product_id, price
+		FROM price_records
+		WHERE `+clause+` AND anomaly IS NULL
+		  AND product_id IN (?`+strings.Repeat(", ?", len(productIDs)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get price baselines: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[string][]float64)
+	for rows.Next() {
+		var productID string
+		var price float64
+		if err := rows.Scan(&productID, &price); err != nil {
+			return nil, fmt.Errorf("scan price baseline: %w", err)
+		}
+		prices[productID] = append(prices[productID], price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate price baselines: %w", err)
+	}
+	for productID, p := range prices {
+		baselines[productID] = models.PriceBaseline{Median: median(p), Purchases: len(p)}
+	}
+	return baselines, nil
+}
+
+// unflaggedRecords returns the records of history not flagged for review.
+func unflaggedRecords(history []models.PriceRecord) []models.PriceRecord {
+	kept := make([]models.PriceRecord, 0, len(history))
+	for _, rec := range history {
+		if rec.Anomaly == "" {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
+
+// GetFlaggedRecords returns the price records of userID's household awaiting
+// review, newest first, each with the median price of the product's
+// unflagged purchases.
+func (s *SQLiteStore) GetFlaggedRecords(userID int64) ([]models.FlaggedRecord, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	rows, err := s.db.Query(`
+		SELECT pr.id, pr.product_id, p.name, pr.date, pr.price, pr.quantity, pr.store, pr.anomaly
+		FROM price_records pr
+		JOIN products p ON p.id = pr.product_id
+		WHERE pr.`+clause+` AND pr.anomaly IS NOT NULL
+		ORDER BY pr.date DESC, pr.id DESC
+	`, baseArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("get flagged records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.FlaggedRecord{}
+	var productIDs []string
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var r models.FlaggedRecord
+		if err := rows.Scan(&r.RecordID, &r.ProductID, &r.ProductName, &r.Date, &r.Price, &r.Quantity, &r.Store, &r.Anomaly); err != nil {
+			return nil, fmt.Errorf("scan flagged record: %w", err)
+		}
+		records = append(records, r)
+		if !seen[r.ProductID] {
+			seen[r.ProductID] = true
+			productIDs = append(productIDs, r.ProductID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate flagged records: %w", err)
+	}
+	rows.Close()
+
+	baselines, err := s.priceBaselines(clause, baseArgs, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		if b, ok := baselines[records[i].ProductID]; ok {
+			m := roundCents(b.Median)
+			records[i].MedianPrice = &m
+		}
+	}
+	return records, nil
+}
+
+// ConfirmFlaggedRecord clears the review flag of a flagged price record of
+// userID's household, so that it counts in price analytics again.
+func (s *SQLiteStore) ConfirmFlaggedRecord(userID, recordID int64) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	res, err := s.db.Exec(
+		`UPDATE price_records SET anomaly = NULL WHERE id = ? AND anomaly IS NOT NULL AND `+clause,
+		append([]any{recordID}, baseArgs...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("confirm price record %d: %w", recordID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrFlaggedRecordNotFound
+	}
+	return nil
+}
+
+// DeleteFlaggedRecord deletes a flagged price record of userID's household.
+func (s *SQLiteStore) DeleteFlaggedRecord(userID, recordID int64) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	res, err := s.db.Exec(
+		`DELETE FROM price_records WHERE id = ? AND anomaly IS NOT NULL AND `+clause,
+		append([]any{recordID}, baseArgs...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("delete price record %d: %w", recordID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrFlaggedRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func TestGetPriceBaselines_MedianOfUnflaggedPurchases(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "PAN",
+		models.PriceRecord{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 2, 10), Price: 1.20, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 3, 10), Price: 1.10, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 4, 10), Price: 11.00, Store: "Mercadona", Anomaly: "outlier"},
+	)
+
+	got, err := s.GetPriceBaselines(uid, []string{"PAN", "LECHE"})
+	if err != nil {
+		t.Fatalf("GetPriceBaselines: %v", err)
+	}
+	if len(got) != 1 || got["PAN"] != (models.PriceBaseline{Median: 1.10, Purchases: 3}) {
+		t.Errorf("want PAN at a median of 1.10 over 3 purchases, got %+v", got)
+	}
+}
+
+func TestFlaggedRecords_ReviewAndAnalytics(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "PAN",
+		models.PriceRecord{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 2, 10), Price: 1.00, Store: "Mercadona"},
+	)
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 10), Price: 10.00, Store: "Mercadona", Anomaly: "outlier"})
+
+	flagged, err := s.GetFlaggedRecords(uid)
+	if err != nil {
+		t.Fatalf("GetFlaggedRecords: %v", err)
+	}
+	if len(flagged) != 1 || flagged[0].ProductID != "pan" || flagged[0].Price != 10 || flagged[0].Anomaly != "outlier" ||
+		flagged[0].Date != "2025-03-10" || flagged[0].MedianPrice == nil || *flagged[0].MedianPrice != 1 {
+		t.Fatalf("unexpected flagged records %+v", flagged)
+	}
+	recordID := flagged[0].RecordID
+
+	// Until reviewed, the flagged price does not count as an increase.
+	increases, err := s.GetBiggestPriceIncreases(uid, store.AnalyticsFilter{})
+	if err != nil {
+		t.Fatalf("GetBiggestPriceIncreases: %v", err)
+	}
+	if len(increases) != 0 {
+		t.Errorf("want no increases while the record is flagged, got %+v", increases)
+	}
+
+	other := createTestUser2(t, s, "other")
+	if err := s.ConfirmFlaggedRecord(other, recordID); !errors.Is(err, store.ErrFlaggedRecordNotFound) {
+		t.Errorf("want ErrFlaggedRecordNotFound for another household, got %v", err)
+	}
+	if err := s.ConfirmFlaggedRecord(uid, recordID); err != nil {
+		t.Fatalf("ConfirmFlaggedRecord: %v", err)
+	}
+	if err := s.DeleteFlaggedRecord(uid, recordID); !errors.Is(err, store.ErrFlaggedRecordNotFound) {
+		t.Errorf("a confirmed record is no longer under review, got %v", err)
+	}
+	increases, err = s.GetBiggestPriceIncreases(uid, store.AnalyticsFilter{})
+	if err != nil {
+		t.Fatalf("GetBiggestPriceIncreases: %v", err)
+	}
+	if len(increases) != 1 || increases[0].CurrentPrice != 10 {
+		t.Errorf("want the confirmed price as an increase, got %+v", increases)
+	}
+}
+
+func TestFlaggedRecords_LeftOutOfPriceViews(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "PAN",
+		models.PriceRecord{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 2, 10), Price: 1.00, Store: "Mercadona"},
+	)
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 3, 10), Price: 10.00, Store: "Mercadona", Anomaly: "outlier"})
+
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchProducts: %v", err)
+	}
+	if len(results) != 1 || results[0].CurrentPrice != 1 || results[0].MaxPrice != 1 || results[0].PurchaseCount != 2 {
+		t.Errorf("want PAN priced from unflagged records, got %+v", results)
+	}
+
+	series, err := s.GetSpendingSeries(uid, date(2025, 1, 1), date(2025, 3, 31), store.GranularityMonth)
+	if err != nil {
+		t.Fatalf("GetSpendingSeries: %v", err)
+	}
+	if series.Total != 2 || series.Points[2].Total != 0 {
+		t.Errorf("want 2.00 spent with nothing in March, got %+v", series)
+	}
+	breakdown, err := s.GetSpendingBreakdown(uid, date(2025, 1, 1), date(2025, 3, 31))
+	if err != nil {
+		t.Fatalf("GetSpendingBreakdown: %v", err)
+	}
+	if breakdown.Total != 2 {
+		t.Errorf("want a 2.00 breakdown, got %+v", breakdown)
+	}
+	if err := s.AddProductTag(uid, "pan", "desayuno"); err != nil {
+		t.Fatalf("AddProductTag: %v", err)
+	}
+	tagSpend, err := s.GetSpendByTag(uid)
+	if err != nil {
+		t.Fatalf("GetSpendByTag: %v", err)
+	}
+	if len(tagSpend) != 1 || tagSpend[0].TotalSpent != 2 || tagSpend[0].PurchaseCount != 2 {
+		t.Errorf("want 2.00 over 2 purchases for desayuno, got %+v", tagSpend)
+	}
+
+	replay, err := s.GetBasketReplay(uid, []models.BasketItem{{ProductID: "pan", Quantity: 1}},
+		time.Time{}, date(2025, 3, 31), store.GranularityMonth)
+	if err != nil {
+		t.Fatalf("GetBasketReplay: %v", err)
+	}
+	if n := len(replay.Points); n == 0 || replay.Points[n-1].Cost != 1 {
+		t.Errorf("want the basket at 1.00 in March, got %+v", replay.Points)
+	}
+
+	p, err := s.GetProductByID(uid, "pan")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if p.CurrentPrice != 1 {
+		t.Errorf("want the product detail at the unflagged 1.00, got %v", p.CurrentPrice)
+	}
+	fc, err := s.ForecastPriceHistory(p.PriceHistory, store.MinForecastMonths, date(2025, 3, 31))
+	if err != nil {
+		t.Fatalf("ForecastPriceHistory: %v", err)
+	}
+	if fc == nil || fc.BasePrice != 1 || fc.BaseDate != "2025-02-10" {
+		t.Errorf("want the forecast based on the February price, got %+v", fc)
+	}
+}
+
+func TestSearchProductsPage_FullyFlaggedProductsArePaged(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	for i, name := range []string{"ACEITE", "LECHE", "PAN"} {
+		importRecords(t, s, uid, name, models.PriceRecord{Date: date(2025, 1, 10+i), Price: float64(i + 1), Store: "Mercadona"})
+	}
+	for _, name := range []string{"ARROZ", "HUEVOS", "YOGUR"} {
+		importRecords(t, s, uid, name, models.PriceRecord{Date: date(2025, 2, 10), Price: 50, Store: "Mercadona", Anomaly: "outlier"})
+	}
+
+	for _, opts := range []store.SearchOptions{
+		{Limit: 2},
+		{Limit: 2, Sort: store.SortPrice},
+		{Limit: 2, Sort: store.SortPrice, Order: store.OrderDesc},
+	} {
+		var ids []string
+		for pages := 0; ; pages++ {
+			if pages > 6 {
+				t.Fatalf("%+v: cursor does not terminate", opts)
+			}
+			page, err := s.SearchProductsPage(uid, opts)
+			if err != nil {
+				t.Fatalf("SearchProductsPage(%+v): %v", opts, err)
+			}
+			if page.Total != 6 {
+				t.Errorf("%+v: want total 6, got %d", opts, page.Total)
+			}
+			ids = append(ids, pageIDs(page)...)
+			if page.NextCursor == "" {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+		if len(ids) != 6 || ids[3] != "arroz" || ids[4] != "huevos" || ids[5] != "yogur" {
+			t.Errorf("%+v: want every product, flagged ones last, got %v", opts, ids)
+		}
+	}
+}
+
+func TestUpsertPriceRecordBatch_FlaggedRecordRaisesNoWatchAlert(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"})
+	if _, err := s.AddWatch(uid, "pan", store.RuleAnyIncrease, nil); err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 2, 10), Price: 10.00, Store: "Mercadona", Anomaly: "outlier"})
+
+	inbox, err := s.GetAlerts(uid, false, 0)
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	if len(inbox.Alerts) != 0 {
+		t.Errorf("want no alerts for a flagged price, got %+v", inbox.Alerts)
+	}
+	flagged, err := s.GetFlaggedRecords(uid)
+	if err != nil {
+		t.Fatalf("GetFlaggedRecords: %v", err)
+	}
+	if err := s.DeleteFlaggedRecord(uid, flagged[0].RecordID); err != nil {
+		t.Fatalf("DeleteFlaggedRecord: %v", err)
+	}
+	if p, _ := s.GetProductByID(uid, "pan"); len(p.PriceHistory) != 1 {
+		t.Errorf("want the flagged record deleted, got %+v", p.PriceHistory)
+	}
+}
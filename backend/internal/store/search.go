@@ -0,0 +1,375 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Product search ----------
+
+// ErrInvalidSearch is returned (wrapped) by SearchProducts and
+// SearchProductsPage when SearchOptions holds an unknown sort or a cursor that
+// does not belong to the requested ordering.
+var ErrInvalidSearch = errors.New("invalid search options")
+
+// SearchSort names a SearchProducts ordering.
+type SearchSort string
+
+const (
+	// SortRelevance orders by full-text relevance. Without a query it falls
+	// back to SortLastPurchase.
+	SortRelevance     SearchSort = "relevance"
+	SortLastPurchase  SearchSort = "last"
+	SortName          SearchSort = "name"
+	SortPrice         SearchSort = "price"
+	SortPriceChange   SearchSort = "change"
+	SortPurchaseCount SearchSort = "count"
+)
+
+// categorySeparator joins the levels of a category path. It mirrors
+// enricher.CategorySeparator, which the store cannot import.
+const categorySeparator = " > "
+
+// SortOrder overrides the natural direction of a SearchSort.
+type SortOrder string
+
+const (
+	OrderDefault SortOrder = ""
+	OrderAsc     SortOrder = "asc"
+	OrderDesc    SortOrder = "desc"
+)
+
+// priceChangeExpr computes the first-to-current percentage change over the
+// columns of the inner search query.
+const priceChangeExpr = `CASE WHEN r.first_price > 0 THEN ROUND((r.current_price - r.first_price) / r.first_price * 100, 2) ELSE 0 END`
+
+// searchSorts maps each SearchSort to the expression it orders by and whether
+// it runs descending by default. current_price and last_date are NULL for a
+// product whose records are all flagged for review; such rows sort last in
+// either direction.
+var searchSorts = map[SearchSort]struct {
+	expr string
+	desc bool
+}{
+	SortRelevance:     {"r.rank", false}, // bm25: lower is better
+	SortLastPurchase:  {"r.last_date", true},
+	SortName:          {"r.name", false},
+	SortPrice:         {"r.current_price", false},
+	SortPriceChange:   {priceChangeExpr, true},
+	SortPurchaseCount: {"r.purchase_count", true},
+}
+
+// SearchOptions narrows and orders the results of SearchProducts.
+// The zero value returns every product visible to the user, most recently
+// purchased first (or most relevant first when Query is set).
+type SearchOptions struct {
+	// Query is free text matched against the full-text index of product names
+	// and aliases, ignoring case and accents. Tokens of three or more
+	// characters match as word prefixes.
+	Query string
+	// Synonyms maps normalised query tokens to alternative tokens that may
+	// match in their place, e.g. "leche" → ["llet"]. Optional.
+	Synonyms map[string][]string
+	// Tag restricts results to products carrying this household tag.
+	Tag string
+	// FavouritesOnly restricts results to products starred by the household.
+	FavouritesOnly bool
+	// MineOnly restricts results, and the prices and counts they report, to
+	// the purchases of the requesting user rather than the whole household.
+	MineOnly bool
+	// Category restricts results to a category path or any of its
+	// subcategories, e.g. "Lácteos y huevos" also matches
+	// "Lácteos y huevos > Leche y bebidas vegetales".
+	Category string
+	// Store restricts results to products bought at least once in this store
+	// (case-insensitive).
+	Store string
+	// MinPrice and MaxPrice bound the current price; zero means unbounded.
+	MinPrice float64
+	MaxPrice float64
+	// Since restricts results to products last purchased on or after this
+	// date; the zero time means no restriction.
+	Since time.Time
+
+	// Sort selects the ordering; empty means SortRelevance. Order overrides
+	// the sort's natural direction.
+	Sort  SearchSort
+	Order SortOrder
+	// Limit caps the number of results; zero returns every match.
+	Limit int
+	// Cursor continues a previous page, as returned in SearchPage.NextCursor.
+	Cursor string
+}
+
+// searchCursor is the decoded form of SearchPage.NextCursor: the sort value
+// and ID of the last row on the previous page, plus the ordering it belongs to.
+type searchCursor struct {
+	Sort  SearchSort `json:"s"`
+	Desc  bool       `json:"d"`
+	Value any        `json:"v"`
+	ID    string     `json:"id"`
+}
+
+func encodeSearchCursor(c searchCursor) (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeSearchCursor(raw string) (searchCursor, error) {
+	var c searchCursor
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidSearch)
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidSearch)
+	}
+	return c, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// SearchProducts returns products that have at least one price record belonging
+// to userID's household and that satisfy opts, in the order opts.Sort selects.
+// When userID == 0, returns products with user_id IS NULL (anonymous/seed data).
+func (s *SQLiteStore) SearchProducts(userID int64, opts SearchOptions) ([]models.SearchResult, error) {
+	page, err := s.SearchProductsPage(userID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// SearchProductsPage is SearchProducts with paging: it returns at most
+// opts.Limit results after opts.Cursor, the total number of matches, and the
+// cursor for the following page. Paging is keyset-based on (sort value, ID),
+// so pages sorted by a product attribute stay stable while new purchases are
+// imported. Relevance pages key on the bm25 score, which depends on the whole
+// full-text index: renaming or adding products between requests can shift
+// scores, so a relevance page may repeat or skip results near its boundary.
+func (s *SQLiteStore) SearchProductsPage(userID int64, opts SearchOptions) (models.SearchPage, error) {
+	empty := models.SearchPage{Results: []models.SearchResult{}}
+
+	sortKey := opts.Sort
+	if sortKey == "" {
+		sortKey = SortRelevance
+	}
+	query := strings.TrimSpace(opts.Query)
+	if sortKey == SortRelevance && query == "" {
+		sortKey = SortLastPurchase
+	}
+	sortDef, ok := searchSorts[sortKey]
+	if !ok {
+		return empty, fmt.Errorf("%w: unknown sort %q", ErrInvalidSearch, opts.Sort)
+	}
+	desc := sortDef.desc
+	switch opts.Order {
+	case OrderDefault:
+	case OrderAsc:
+		desc = false
+	case OrderDesc:
+		desc = true
+	default:
+		return empty, fmt.Errorf("%w: unknown order %q", ErrInvalidSearch, opts.Order)
+	}
+
+	var after *searchCursor
+	if opts.Cursor != "" {
+		c, err := decodeSearchCursor(opts.Cursor)
+		if err != nil {
+			return empty, err
+		}
+		if c.Sort != sortKey || c.Desc != desc {
+			return empty, fmt.Errorf("%w: cursor belongs to a different sort", ErrInvalidSearch)
+		}
+		after = &c
+	}
+
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return empty, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	// recClause scopes price records; tags and favourites stay household-wide.
+	recClause, recArgs := clause, baseArgs
+	if opts.MineOnly && userID != 0 {
+		recClause, recArgs = userIDsInClause([]int64{userID})
+	}
+	// Price columns leave out records flagged for review; products are still
+	// listed by any record so that one awaiting review can be found.
+	// recClause appears 6 times in the inner SELECT list and clause twice; the
+	// optional FTS MATCH argument follows, then recClause for the WHERE EXISTS.
+	args := append(repeatArgs(recArgs, 6), repeatArgs(baseArgs, 2)...)
+
+	from := `FROM products p`
+	rankExpr := `0.0`
+	if query != "" {
+		match := ftsMatchQuery(query, opts.Synonyms)
+		if match == "" {
+			// Nothing searchable (e.g. only punctuation): nothing can match.
+			return empty, nil
+		}
+		// bm25 weights: product_id (unindexed), name, aliases.
+		from += `
+			JOIN (
+				SELECT product_id, bm25(products_fts, 0.0, 10.0, 1.0) AS rank
+				FROM products_fts WHERE products_fts MATCH ?
+			) f ON f.product_id = p.id`
+		rankExpr = `f.rank`
+		args = append(args, match)
+	}
+	args = append(args, recArgs...)
+
+	inner := `
+		SELECT
+			p.id,
+			p.name,
+			p.category,
+			p.image_url,
+			(SELECT price FROM price_records WHERE product_id = p.id AND anomaly IS NULL AND ` + recClause + ` ORDER BY date DESC LIMIT 1) AS current_price,
+			(SELECT price FROM price_records WHERE product_id = p.id AND anomaly IS NULL AND ` + recClause + ` ORDER BY date ASC LIMIT 1)  AS first_price,
+			(SELECT MIN(price) FROM price_records WHERE product_id = p.id AND anomaly IS NULL AND ` + recClause + `)                        AS min_price,
+			(SELECT MAX(price) FROM price_records WHERE product_id = p.id AND anomaly IS NULL AND ` + recClause + `)                        AS max_price,
+			(SELECT MAX(date)  FROM price_records WHERE product_id = p.id AND anomaly IS NULL AND ` + recClause + `)                        AS last_date,
+			(SELECT COUNT(*)   FROM price_records WHERE product_id = p.id AND anomaly IS NULL AND ` + recClause + `)                        AS purchase_count,
+			(SELECT GROUP_CONCAT(tag) FROM (
+				SELECT DISTINCT tag FROM product_tags WHERE product_id = p.id AND ` + clause + ` ORDER BY tag
+			))                                                                                                   AS tags,
+			EXISTS (SELECT 1 FROM product_favourites WHERE product_id = p.id AND ` + clause + `)                  AS favourite,
+			` + rankExpr + `                                                                                     AS rank
+		` + from + `
+		WHERE EXISTS (SELECT 1 FROM price_records WHERE product_id = p.id AND ` + recClause + `)
+	`
+	if opts.Tag != "" {
+		inner += ` AND EXISTS (SELECT 1 FROM product_tags WHERE product_id = p.id AND tag = ? AND ` + clause + `)`
+		args = append(args, opts.Tag)
+		args = append(args, baseArgs...)
+	}
+	if opts.FavouritesOnly {
+		inner += ` AND EXISTS (SELECT 1 FROM product_favourites WHERE product_id = p.id AND ` + clause + `)`
+		args = append(args, baseArgs...)
+	}
+	if opts.Category != "" {
+		// Only whole path levels count: "Lácteos" must not match "Lácteos y huevos".
+		inner += ` AND (p.category = ? OR p.category LIKE ? ESCAPE '\')`
+		args = append(args, opts.Category, escapeLike(opts.Category+categorySeparator)+"%")
+	}
+	if opts.Store != "" {
+		inner += ` AND EXISTS (SELECT 1 FROM price_records WHERE product_id = p.id AND store = ? COLLATE NOCASE AND ` + recClause + `)`
+		args = append(args, opts.Store)
+		args = append(args, recArgs...)
+	}
+
+	var where []string
+	if opts.MinPrice > 0 {
+		where = append(where, `r.current_price >= ?`)
+		args = append(args, opts.MinPrice)
+	}
+	if opts.MaxPrice > 0 {
+		where = append(where, `r.current_price <= ?`)
+		args = append(args, opts.MaxPrice)
+	}
+	if !opts.Since.IsZero() {
+		where = append(where, `r.last_date >= ?`)
+		args = append(args, opts.Since.Format(time.DateOnly))
+	}
+
+	page := empty
+	filtered := `FROM (` + inner + `) r`
+	if len(where) > 0 {
+		filtered += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	if opts.Limit > 0 {
+		if err := s.db.QueryRow(`SELECT COUNT(*) `+filtered, args...).Scan(&page.Total); err != nil {
+			return empty, fmt.Errorf("count search results: %w", err)
+		}
+	}
+
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+	q := `SELECT r.id, r.name, r.category, r.image_url, r.current_price, r.min_price, r.max_price,
+			r.last_date, r.purchase_count, ` + priceChangeExpr + `, r.tags, r.favourite, ` + sortDef.expr + `
+		` + filtered
+	if after != nil {
+		var keyset string
+		if after.Value == nil {
+			keyset = `(` + sortDef.expr + ` IS NULL AND r.id > ?)`
+			args = append(args, after.ID)
+		} else {
+			keyset = `(` + sortDef.expr + ` IS NULL OR ` + sortDef.expr + ` ` + cmp + ` ? OR (` + sortDef.expr + ` = ? AND r.id > ?))`
+			args = append(args, after.Value, after.Value, after.ID)
+		}
+		if len(where) > 0 {
+			q += ` AND ` + keyset
+		} else {
+			q += ` WHERE ` + keyset
+		}
+	}
+	q += ` ORDER BY ` + sortDef.expr + ` IS NULL, ` + sortDef.expr + ` ` + dir + `, r.id ASC`
+	if opts.Limit > 0 {
+		// Fetch one extra row to learn whether another page follows.
+		q += ` LIMIT ?`
+		args = append(args, opts.Limit+1)
+	}
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return empty, fmt.Errorf("search products: %w", err)
+	}
+	defer rows.Close()
+
+	var lastSortValue any
+	for rows.Next() {
+		var r models.SearchResult
+		var category, imageURL, lastDate, tags sql.NullString
+		var currentPrice, minPrice, maxPrice sql.NullFloat64
+		var sortValue any
+		if err := rows.Scan(&r.ID, &r.Name, &category, &imageURL, &currentPrice, &minPrice, &maxPrice,
+			&lastDate, &r.PurchaseCount, &r.PriceChangePercent, &tags, &r.Favourite, &sortValue); err != nil {
+			return empty, fmt.Errorf("scan search result: %w", err)
+		}
+		if opts.Limit > 0 && len(page.Results) == opts.Limit {
+			// The extra row exists: point the cursor at the last row kept.
+			c, err := encodeSearchCursor(searchCursor{
+				Sort: sortKey, Desc: desc, Value: lastSortValue, ID: page.Results[len(page.Results)-1].ID,
+			})
+			if err != nil {
+				return empty, err
+			}
+			page.NextCursor = c
+			break
+		}
+		r.Category = category.String
+		r.ImageURL = imageURL.String
+		r.CurrentPrice = currentPrice.Float64
+		r.MinPrice = minPrice.Float64
+		r.MaxPrice = maxPrice.Float64
+		r.LastPurchaseDate = lastDate.String
+		r.Tags = splitTags(tags.String)
+		page.Results = append(page.Results, r)
+		lastSortValue = sortValue
+	}
+	if err := rows.Err(); err != nil {
+		return empty, fmt.Errorf("iterate search results: %w", err)
+	}
+
+	if opts.Limit <= 0 {
+		page.Total = len(page.Results)
+	}
+	return page, nil
+}
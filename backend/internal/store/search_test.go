@@ -0,0 +1,184 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// seedSearchFixture inserts three products with distinct prices, price
+// changes, purchase counts, stores and categories.
+func seedSearchFixture(t *testing.T, s *store.SQLiteStore, uid int64) {
+	t.Helper()
+	products := []models.Product{
+		{Name: "ACEITE OLIVA", PriceHistory: []models.PriceRecord{
+			{Date: date(2025, 1, 1), Price: 5.00, Store: "Mercadona"},
+			{Date: date(2025, 6, 1), Price: 8.00, Store: "Mercadona"}, // +60%
+		}},
+		{Name: "BARRA PAN", PriceHistory: []models.PriceRecord{
+			{Date: date(2025, 2, 1), Price: 0.50, Store: "Bonpreu"},
+			{Date: date(2025, 3, 1), Price: 0.50, Store: "Bonpreu"},
+			{Date: date(2025, 4, 1), Price: 0.55, Store: "Bonpreu"}, // +10%
+		}},
+		{Name: "CAFE MOLIDO", PriceHistory: []models.PriceRecord{
+			{Date: date(2026, 1, 1), Price: 3.00, Store: "Mercadona"},
+		}},
+	}
+	for _, p := range products {
+		insertProductForUser(t, s, uid, p)
+	}
+	if err := s.SetProductCategoryManual("aceite-oliva", "Aceite, especias y salsas > Aceite"); err != nil {
+		t.Fatalf("SetProductCategoryManual: %v", err)
+	}
+	if err := s.SetProductCategoryManual("cafe-molido", "Aceite de palma"); err != nil {
+		t.Fatalf("SetProductCategoryManual: %v", err)
+	}
+}
+
+func pageIDs(p models.SearchPage) []string {
+	ids := make([]string, len(p.Results))
+	for i, r := range p.Results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestSearchProductsPage_Sorts(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedSearchFixture(t, s, uid)
+
+	tests := []struct {
+		sort  store.SearchSort
+		order store.SortOrder
+		want  []string
+	}{
+		{store.SortName, store.OrderDefault, []string{"aceite-oliva", "barra-pan", "cafe-molido"}},
+		{store.SortName, store.OrderDesc, []string{"cafe-molido", "barra-pan", "aceite-oliva"}},
+		{store.SortPrice, store.OrderDefault, []string{"barra-pan", "cafe-molido", "aceite-oliva"}},
+		{store.SortPriceChange, store.OrderDefault, []string{"aceite-oliva", "barra-pan", "cafe-molido"}},
+		{store.SortPurchaseCount, store.OrderDefault, []string{"barra-pan", "aceite-oliva", "cafe-molido"}},
+		{store.SortLastPurchase, store.OrderAsc, []string{"barra-pan", "aceite-oliva", "cafe-molido"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.sort)+"/"+string(tt.order), func(t *testing.T) {
+			page, err := s.SearchProductsPage(uid, store.SearchOptions{Sort: tt.sort, Order: tt.order})
+			if err != nil {
+				t.Fatalf("SearchProductsPage: %v", err)
+			}
+			got := pageIDs(page)
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("want %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchProductsPage_CursorWalksAllPages(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedSearchFixture(t, s, uid)
+
+	opts := store.SearchOptions{Sort: store.SortPrice, Limit: 2}
+	first, err := s.SearchProductsPage(uid, opts)
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if first.Total != 3 || len(first.Results) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page: want 2 of 3 with cursor, got %v total=%d cursor=%q", pageIDs(first), first.Total, first.NextCursor)
+	}
+
+	opts.Cursor = first.NextCursor
+	second, err := s.SearchProductsPage(uid, opts)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if ids := pageIDs(second); len(ids) != 1 || ids[0] != "aceite-oliva" {
+		t.Errorf("second page: want [aceite-oliva], got %v", ids)
+	}
+	if second.NextCursor != "" {
+		t.Errorf("second page: want no cursor, got %q", second.NextCursor)
+	}
+	if second.Total != 3 {
+		t.Errorf("second page: want total 3, got %d", second.Total)
+	}
+}
+
+func TestSearchProductsPage_CursorFromOtherSort_Rejected(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedSearchFixture(t, s, uid)
+
+	first, err := s.SearchProductsPage(uid, store.SearchOptions{Sort: store.SortPrice, Limit: 1})
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	_, err = s.SearchProductsPage(uid, store.SearchOptions{Sort: store.SortName, Limit: 1, Cursor: first.NextCursor})
+	if !errors.Is(err, store.ErrInvalidSearch) {
+		t.Errorf("want ErrInvalidSearch, got %v", err)
+	}
+	_, err = s.SearchProductsPage(uid, store.SearchOptions{Sort: "colour"})
+	if !errors.Is(err, store.ErrInvalidSearch) {
+		t.Errorf("unknown sort: want ErrInvalidSearch, got %v", err)
+	}
+}
+
+func TestSearchProductsPage_Filters(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedSearchFixture(t, s, uid)
+
+	tests := []struct {
+		name string
+		opts store.SearchOptions
+		want []string
+	}{
+		// "Aceite" must not match the sibling path "Aceite de palma".
+		{"category prefix", store.SearchOptions{Category: "Aceite, especias y salsas"}, []string{"aceite-oliva"}},
+		{"category exact", store.SearchOptions{Category: "Aceite de palma"}, []string{"cafe-molido"}},
+		{"store", store.SearchOptions{Store: "bonpreu"}, []string{"barra-pan"}},
+		{"price range", store.SearchOptions{MinPrice: 1, MaxPrice: 5}, []string{"cafe-molido"}},
+		{"since", store.SearchOptions{Since: date(2025, 5, 1), Sort: store.SortName}, []string{"aceite-oliva", "cafe-molido"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.SearchProductsPage(uid, tt.opts)
+			if err != nil {
+				t.Fatalf("SearchProductsPage: %v", err)
+			}
+			got := pageIDs(page)
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("want %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchProducts_PopulatesCountAndChange(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	seedSearchFixture(t, s, uid)
+
+	results, err := s.SearchProducts(uid, store.SearchOptions{Query: "aceite"})
+	if err != nil {
+		t.Fatalf("SearchProducts: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("want 1 result, got %d", len(results))
+	}
+	if results[0].PurchaseCount != 2 || results[0].PriceChangePercent != 60 {
+		t.Errorf("want count 2 and +60%%, got count %d and %.2f%%", results[0].PurchaseCount, results[0].PriceChangePercent)
+	}
+}
@@ -0,0 +1,294 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Shopping lists ----------
+
+// ErrShoppingListNotFound is returned when a shopping list or one of its
+// items does not exist or belongs to another household.
+var ErrShoppingListNotFound = errors.New("shopping list not found")
+
+// CreateShoppingList creates an empty list named name for userID's household
+// and returns its ID.
+func (s *SQLiteStore) CreateShoppingList(userID int64, name string) (int64, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	res, err := s.db.Exec(
+		`INSERT INTO shopping_lists (user_id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+		userID, name, now, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create shopping list %q: %w", name, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("get last insert id: %w", err)
+	}
+	return id, nil
+}
+
+// GetShoppingLists returns the shopping lists of userID's household with
+// their items, most recently updated first.
+func (s *SQLiteStore) GetShoppingLists(userID int64) ([]models.ShoppingList, error) {
+	return s.shoppingLists(userID, 0)
+}
+
+// GetShoppingList returns one list of userID's household with its items, or
+// nil if it does not exist or belongs to another household.
+func (s *SQLiteStore) GetShoppingList(userID, listID int64) (*models.ShoppingList, error) {
+	lists, err := s.shoppingLists(userID, listID)
+	if err != nil || len(lists) == 0 {
+		return nil, err
+	}
+	return &lists[0], nil
+}
+
+// shoppingLists loads the household's lists, or only listID when non-zero.
+func (s *SQLiteStore) shoppingLists(userID, listID int64) ([]models.ShoppingList, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	only, onlyArgs := "", []any(nil)
+	if listID != 0 {
+		only, onlyArgs = ` AND sl.id = ?`, []any{listID}
+	}
+
+	rows, err := s.db.Query(`
+		SELECT sl.id, sl.name, u.username, sl.created_at, sl.updated_at
+		FROM shopping_lists sl
+		JOIN users u ON u.id = sl.user_id
+		WHERE sl.`+clause+only+`
+		ORDER BY sl.updated_at DESC, sl.id DESC
+	`, append(append([]any{}, baseArgs...), onlyArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("get shopping lists: %w", err)
+	}
+	lists := []models.ShoppingList{}
+	byID := make(map[int64]int)
+	for rows.Next() {
+		var l models.ShoppingList
+		var createdAt, updatedAt string
+		if err := rows.Scan(&l.ID, &l.Name, &l.CreatedBy, &createdAt, &updatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan shopping list: %w", err)
+		}
+		if l.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("parse shopping list created_at: %w", err)
+		}
+		if l.UpdatedAt, err = time.Parse(time.RFC3339, updatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("parse shopping list updated_at: %w", err)
+		}
+		l.Items = []models.ShoppingListItem{}
+		byID[l.ID] = len(lists)
+		lists = append(lists, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate shopping lists: %w", err)
+	}
+	if len(lists) == 0 {
+		return lists, nil
+	}
+
+	// Items with the price and store of the household's latest purchase.
+	// clause appears twice (latest prices + list ownership).
+	itemArgs := append(repeatArgs(baseArgs, 2), onlyArgs...)
+	rows, err = s.db.Query(`
+		WITH latest AS (
+			SELECT product_id, price, store FROM (
+				SELECT product_id, price, store,
+				       ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY date DESC, id DESC) AS rn
+				FROM price_records
+				WHERE `+clause+` AND anomaly IS NULL
+			)
+			WHERE rn = 1
+		)
+		SELECT i.list_id, i.id, i.product_id, p.name, COALESCE(p.image_url, ''),
+		       i.quantity, i.checked, COALESCE(lp.price, 0), COALESCE(lp.store, '')
+		FROM shopping_list_items i
+		JOIN shopping_lists sl ON sl.id = i.list_id
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN latest lp ON lp.product_id = i.product_id
+		WHERE sl.`+clause+only+`
+		ORDER BY i.checked, p.name
+	`, itemArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("get shopping list items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var listID int64
+		var it models.ShoppingListItem
+		if err := rows.Scan(&listID, &it.ID, &it.ProductID, &it.Name, &it.ImageURL,
+			&it.Quantity, &it.Checked, &it.CurrentPrice, &it.Store); err != nil {
+			return nil, fmt.Errorf("scan shopping list item: %w", err)
+		}
+		it.Subtotal = roundCents(it.CurrentPrice * it.Quantity)
+		l := &lists[byID[listID]]
+		l.Items = append(l.Items, it)
+		l.ItemCount++
+		l.EstimatedTotal += it.Subtotal
+		if !it.Checked {
+			l.RemainingTotal += it.Subtotal
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate shopping list items: %w", err)
+	}
+	for i := range lists {
+		lists[i].EstimatedTotal = roundCents(lists[i].EstimatedTotal)
+		lists[i].RemainingTotal = roundCents(lists[i].RemainingTotal)
+	}
+	return lists, nil
+}
+
+// checkShoppingList returns ErrShoppingListNotFound unless listID belongs to
+// userID's household.
+func (s *SQLiteStore) checkShoppingList(userID, listID int64) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	var ok bool
+	if err := s.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM shopping_lists WHERE id = ? AND `+clause+`)`,
+		append([]any{listID}, baseArgs...)...,
+	).Scan(&ok); err != nil {
+		return fmt.Errorf("check shopping list %d: %w", listID, err)
+	}
+	if !ok {
+		return ErrShoppingListNotFound
+	}
+	return nil
+}
+
+// touchShoppingList bumps the updated_at of listID.
+func (s *SQLiteStore) touchShoppingList(listID int64) error {
+	if _, err := s.db.Exec(
+		`UPDATE shopping_lists SET updated_at = ? WHERE id = ?`, time.Now().UTC().Format(time.RFC3339), listID,
+	); err != nil {
+		return fmt.Errorf("touch shopping list %d: %w", listID, err)
+	}
+	return nil
+}
+
+// RenameShoppingList renames listID.
+func (s *SQLiteStore) RenameShoppingList(userID, listID int64, name string) error {
+	if err := s.checkShoppingList(userID, listID); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(
+		`UPDATE shopping_lists SET name = ?, updated_at = ? WHERE id = ?`,
+		name, time.Now().UTC().Format(time.RFC3339), listID,
+	); err != nil {
+		return fmt.Errorf("rename shopping list %d: %w", listID, err)
+	}
+	return nil
+}
+
+// DeleteShoppingList deletes listID and its items.
+func (s *SQLiteStore) DeleteShoppingList(userID, listID int64) error {
+	if err := s.checkShoppingList(userID, listID); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM shopping_lists WHERE id = ?`, listID); err != nil {
+		return fmt.Errorf("delete shopping list %d: %w", listID, err)
+	}
+	return nil
+}
+
+// SetShoppingListItem puts quantity units of productID on listID. A product
+// already on the list gets the new quantity and is unchecked.
+func (s *SQLiteStore) SetShoppingListItem(userID, listID int64, productID string, quantity float64) error {
+	if err := s.checkShoppingList(userID, listID); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`
+		INSERT INTO shopping_list_items (list_id, product_id, quantity, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (list_id, product_id) DO UPDATE SET quantity = excluded.quantity, checked = 0
+	`, listID, productID, quantity, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("add product %s to shopping list %d: %w", productID, listID, err)
+	}
+	return s.touchShoppingList(listID)
+}
+
+// UpdateShoppingListItem changes the quantity and/or checked state of itemID
+// on listID; nil arguments are left unchanged.
+func (s *SQLiteStore) UpdateShoppingListItem(userID, listID, itemID int64, quantity *float64, checked *bool) error {
+	if err := s.checkShoppingList(userID, listID); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(`
+		UPDATE shopping_list_items
+		SET quantity = COALESCE(?, quantity), checked = COALESCE(?, checked)
+		WHERE id = ? AND list_id = ?
+	`, quantity, checked, itemID, listID)
+	if err != nil {
+		return fmt.Errorf("update shopping list item %d: %w", itemID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrShoppingListNotFound
+	}
+	return s.touchShoppingList(listID)
+}
+
+// DeleteShoppingListItem removes itemID from listID.
+func (s *SQLiteStore) DeleteShoppingListItem(userID, listID, itemID int64) error {
+	if err := s.checkShoppingList(userID, listID); err != nil {
+		return err
+	}
+	res, err := s.db.Exec(`DELETE FROM shopping_list_items WHERE id = ? AND list_id = ?`, itemID, listID)
+	if err != nil {
+		return fmt.Errorf("delete shopping list item %d: %w", itemID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrShoppingListNotFound
+	}
+	return s.touchShoppingList(listID)
+}
+
+// FillShoppingList adds to listID every staple the household is due to buy
+// again as of asOf (see GetRepurchasePredictions), one unit each, and returns
+// how many were added. Products already on the list are left as they are.
+func (s *SQLiteStore) FillShoppingList(userID, listID int64, asOf time.Time) (int, error) {
+	if err := s.checkShoppingList(userID, listID); err != nil {
+		return 0, err
+	}
+	predictions, err := s.GetRepurchasePredictions(userID, asOf)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	added := 0
+	for _, p := range predictions {
+		if p.Status == RepurchaseOK {
+			continue
+		}
+		res, err := s.db.Exec(
+			`INSERT OR IGNORE INTO shopping_list_items (list_id, product_id, quantity, created_at) VALUES (?, ?, 1, ?)`,
+			listID, p.ProductID, now,
+		)
+		if err != nil {
+			return added, fmt.Errorf("add product %s to shopping list %d: %w", p.ProductID, listID, err)
+		}
+		if rowsInserted(res) {
+			added++
+		}
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, s.touchShoppingList(listID)
+}
@@ -0,0 +1,160 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func TestShoppingList_ItemsAndTotals(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	importRecords(t, s, uid, "LECHE",
+		models.PriceRecord{Date: date(2025, 1, 10), Price: 0.90, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 2, 10), Price: 1.05, Store: "Lidl"},
+	)
+	importRecords(t, s, uid, "PAN", models.PriceRecord{Date: date(2025, 2, 1), Price: 1.20, Store: "Mercadona"})
+
+	listID, err := s.CreateShoppingList(uid, "Semana")
+	if err != nil {
+		t.Fatalf("CreateShoppingList: %v", err)
+	}
+	if err := s.SetShoppingListItem(uid, listID, "leche", 2); err != nil {
+		t.Fatalf("SetShoppingListItem leche: %v", err)
+	}
+	if err := s.SetShoppingListItem(uid, listID, "pan", 1); err != nil {
+		t.Fatalf("SetShoppingListItem pan: %v", err)
+	}
+
+	list, err := s.GetShoppingList(uid, listID)
+	if err != nil || list == nil {
+		t.Fatalf("GetShoppingList: %v, %v", list, err)
+	}
+	if list.Name != "Semana" || list.CreatedBy != "testuser" || list.ItemCount != 2 {
+		t.Errorf("unexpected list %+v", list)
+	}
+	leche := list.Items[0]
+	if leche.ProductID != "leche" || leche.CurrentPrice != 1.05 || leche.Store != "Lidl" || leche.Subtotal != 2.10 {
+		t.Errorf("unexpected item %+v", leche)
+	}
+	if list.EstimatedTotal != 3.30 || list.RemainingTotal != 3.30 {
+		t.Errorf("want totals 3.30/3.30, got %v/%v", list.EstimatedTotal, list.RemainingTotal)
+	}
+
+	// Checked items drop out of the remaining total and sort last.
+	checked := true
+	if err := s.UpdateShoppingListItem(uid, listID, leche.ID, nil, &checked); err != nil {
+		t.Fatalf("UpdateShoppingListItem: %v", err)
+	}
+	list, _ = s.GetShoppingList(uid, listID)
+	if list.RemainingTotal != 1.20 || list.Items[1].ProductID != "leche" || !list.Items[1].Checked {
+		t.Errorf("unexpected list after check %+v", list)
+	}
+
+	// Adding a product again replaces its quantity and unchecks it.
+	if err := s.SetShoppingListItem(uid, listID, "leche", 3); err != nil {
+		t.Fatalf("SetShoppingListItem again: %v", err)
+	}
+	list, _ = s.GetShoppingList(uid, listID)
+	if list.ItemCount != 2 || list.Items[0].Quantity != 3 || list.Items[0].Checked {
+		t.Errorf("unexpected list after re-adding %+v", list)
+	}
+
+	if err := s.DeleteShoppingListItem(uid, listID, leche.ID); err != nil {
+		t.Fatalf("DeleteShoppingListItem: %v", err)
+	}
+	if err := s.DeleteShoppingListItem(uid, listID, leche.ID); !errors.Is(err, store.ErrShoppingListNotFound) {
+		t.Errorf("deleting twice: want ErrShoppingListNotFound, got %v", err)
+	}
+}
+
+func TestShoppingList_SharedWithinHousehold(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	partner := createTestUser2(t, s, "partner")
+	stranger := createTestUser2(t, s, "stranger")
+	hid, err := s.CreateHousehold(uid)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := s.AddUserToHousehold(partner, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+	importRecords(t, s, partner, "LECHE", models.PriceRecord{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"})
+
+	listID, err := s.CreateShoppingList(uid, "Casa")
+	if err != nil {
+		t.Fatalf("CreateShoppingList: %v", err)
+	}
+	if err := s.SetShoppingListItem(partner, listID, "leche", 1); err != nil {
+		t.Fatalf("partner adding an item: %v", err)
+	}
+	lists, err := s.GetShoppingLists(partner)
+	if err != nil {
+		t.Fatalf("GetShoppingLists: %v", err)
+	}
+	if len(lists) != 1 || lists[0].Items[0].CurrentPrice != 1.00 {
+		t.Fatalf("want the shared list priced at the partner's purchase, got %+v", lists)
+	}
+
+	if list, _ := s.GetShoppingList(stranger, listID); list != nil {
+		t.Errorf("stranger should not see the list, got %+v", list)
+	}
+	if err := s.RenameShoppingList(stranger, listID, "Mía"); !errors.Is(err, store.ErrShoppingListNotFound) {
+		t.Errorf("stranger renaming: want ErrShoppingListNotFound, got %v", err)
+	}
+	if err := s.DeleteShoppingList(stranger, listID); !errors.Is(err, store.ErrShoppingListNotFound) {
+		t.Errorf("stranger deleting: want ErrShoppingListNotFound, got %v", err)
+	}
+	if err := s.DeleteShoppingList(partner, listID); err != nil {
+		t.Errorf("DeleteShoppingList: %v", err)
+	}
+	if lists, _ := s.GetShoppingLists(uid); len(lists) != 0 {
+		t.Errorf("want no lists left, got %+v", lists)
+	}
+}
+
+func TestFillShoppingList_AddsDueStaples(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	weekly := func(name string, price float64) {
+		var recs []models.PriceRecord
+		for _, d := range []int{4, 11, 18, 25} {
+			recs = append(recs, models.PriceRecord{Date: date(2025, 3, d), Price: price, Store: "Mercadona"})
+		}
+		importRecords(t, s, uid, name, recs...)
+	}
+	weekly("LECHE", 1.00) // due 2025-04-01
+	weekly("PAN", 0.50)
+	importRecords(t, s, uid, "ACEITE",
+		models.PriceRecord{Date: date(2025, 1, 1), Price: 5, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 2, 15), Price: 5, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 3, 30), Price: 5, Store: "Mercadona"},
+	) // not due for weeks as of 2025-04-01
+
+	listID, _ := s.CreateShoppingList(uid, "Auto")
+	if err := s.SetShoppingListItem(uid, listID, "pan", 4); err != nil {
+		t.Fatalf("SetShoppingListItem: %v", err)
+	}
+	added, err := s.FillShoppingList(uid, listID, date(2025, 4, 1))
+	if err != nil {
+		t.Fatalf("FillShoppingList: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("want 1 staple added, got %d", added)
+	}
+	list, _ := s.GetShoppingList(uid, listID)
+	if list.ItemCount != 2 || list.Items[0].ProductID != "leche" || list.Items[0].Quantity != 1 ||
+		list.Items[1].Quantity != 4 {
+		t.Errorf("unexpected filled list %+v", list.Items)
+	}
+	if list.EstimatedTotal != 3.00 {
+		t.Errorf("want estimated total 3.00, got %v", list.EstimatedTotal)
+	}
+
+	if _, err := s.FillShoppingList(uid, 9999, date(2025, 4, 1)); !errors.Is(err, store.ErrShoppingListNotFound) {
+		t.Errorf("unknown list: want ErrShoppingListNotFound, got %v", err)
+	}
+}
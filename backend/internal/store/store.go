@@ -24,12 +24,18 @@ type Store interface {
 	// UpdateUserPassword replaces the stored password hash for userID.
 	UpdateUserPassword(userID int64, passwordHash string) error
 
-	// SearchProducts returns products whose price records belong to userID.
-	// An empty query returns all matching products.
-	SearchProducts(userID int64, query string) ([]models.SearchResult, error)
+	// SearchProducts returns products whose price records belong to userID's
+	// household, narrowed by opts. The zero SearchOptions returns all products.
+	SearchProducts(userID int64, opts SearchOptions) ([]models.SearchResult, error)
+	// SearchProductsPage is SearchProducts with the total match count and a
+	// cursor for the next page when opts.Limit is set.
+	SearchProductsPage(userID int64, opts SearchOptions) (models.SearchPage, error)
 	// GetProductByID returns the product and its price history scoped to the
 	// household of userID. Pass userID=0 for anonymous (seed) access.
 	GetProductByID(userID int64, id string) (*models.Product, error)
+	// GetOwnProductByID is GetProductByID with the price history limited to
+	// userID's own purchases.
+	GetOwnProductByID(userID int64, id string) (*models.Product, error)
 	InsertProduct(p models.Product) error
 	// UpsertPriceRecord ensures the named product exists (creating it if needed)
 	// and appends a new price record scoped to userID.
@@ -40,6 +46,10 @@ type Store interface {
 	// UpsertPriceRecordBatch persists all (name, record) pairs inside a single
 	// transaction scoped to userID. Either every pair is committed or none is.
 	UpsertPriceRecordBatch(userID int64, entries []models.PriceRecordEntry) error
+	// UpsertTicket persists an imported ticket with its totals and its
+	// entries in a single transaction scoped to userID, linking every price
+	// record to the ticket. Either everything is committed or nothing is.
+	UpsertTicket(userID int64, header models.TicketHeader, entries []models.PriceRecordEntry) error
 	// UpdateProductImageURL sets the image URL for the product with the given ID.
 	// Used by the enricher; does not set the locked flag.
 	UpdateProductImageURL(id, imageURL string) error
@@ -49,15 +59,145 @@ type Store interface {
 	// GetProductsWithoutImage returns a lightweight list of products that have
 	// no image URL set yet and are not manually locked.
 	GetProductsWithoutImage() ([]models.SearchResult, error)
+	// UpdateProductCategory sets the category for the product with the given ID.
+	// Used by the enricher; it is a no-op when the category is manually locked.
+	UpdateProductCategory(id, category string) error
+	// SetProductCategoryManual sets a manually chosen category and marks the
+	// product as locked so the enricher will not overwrite it in future runs.
+	SetProductCategoryManual(id, category string) error
+	// GetProductsWithoutCategory returns a lightweight list of products that
+	// have no category set yet and are not manually locked.
+	GetProductsWithoutCategory() ([]models.SearchResult, error)
 	// IsFileProcessed returns true when filename has already been imported by userID.
 	IsFileProcessed(userID int64, filename string) (bool, error)
 	// MarkFileProcessed records filename as successfully imported by userID.
 	MarkFileProcessed(userID int64, filename string, importedAt time.Time) error
-	// GetMostPurchased returns the top N products by number of price records for userID.
-	GetMostPurchased(userID int64, limit int) ([]models.MostPurchasedProduct, error)
-	// GetBiggestPriceIncreases returns the top N products by percentage price increase
-	// for userID. Only products with at least 2 records and a positive increase are included.
-	GetBiggestPriceIncreases(userID int64, limit int) ([]models.PriceIncreaseProduct, error)
+	// GetMostPurchased returns the top f.Limit products by number of price
+	// records for userID, counting only records that match f.
+	GetMostPurchased(userID int64, f AnalyticsFilter) ([]models.MostPurchasedProduct, error)
+	// GetBiggestPriceIncreases returns the top f.Limit products by percentage price
+	// increase for userID, between the first and last records that match f. Only
+	// products with at least 2 such records and a positive increase are included.
+	GetBiggestPriceIncreases(userID int64, f AnalyticsFilter) ([]models.PriceIncreaseProduct, error)
+	// GetBiggestPriceDecreases is the mirror of GetBiggestPriceIncreases for
+	// products whose price dropped.
+	GetBiggestPriceDecreases(userID int64, f AnalyticsFilter) ([]models.PriceDecreaseProduct, error)
+	// GetMostVolatile returns the top f.Limit products by coefficient of
+	// variation of their prices (at least 3 records matching f).
+	GetMostVolatile(userID int64, f AnalyticsFilter) ([]models.VolatileProduct, error)
+	// GetLongestStablePrices returns the top f.Limit products whose current
+	// price has been unchanged for the longest span of purchases matching f.
+	GetLongestStablePrices(userID int64, f AnalyticsFilter) ([]models.StablePriceProduct, error)
+	// GetPriceRankings returns the three rankings above in one pass over the
+	// price records matching f.
+	GetPriceRankings(userID int64, f AnalyticsFilter) (models.PriceRankings, error)
+	// GetPriceChanges returns the price change events between consecutive
+	// purchases of each product, newest first, narrowed by f.
+	GetPriceChanges(userID int64, f PriceChangeFilter) ([]models.PriceChangeEvent, error)
+	// GetSpendingSeries returns the amount paid per period (price × quantity)
+	// between from and to inclusive, broken down by store, for userID's household.
+	GetSpendingSeries(userID int64, from, to time.Time, g Granularity) (models.SpendingSeries, error)
+	// GetSpendingBreakdown returns the amount paid between from and to
+	// inclusive by category and by store, compared with the previous period of
+	// the same length, for userID's household.
+	GetSpendingBreakdown(userID int64, from, to time.Time) (models.SpendingBreakdown, error)
+	// GetPersonalInflation returns a chained, spend-weighted price index over
+	// the products userID's household buys, per month or year, next to the
+	// given IPC series (or the yearly headline rates if it was not imported).
+	GetPersonalInflation(userID int64, from, to time.Time, g Granularity, series IPCSeries) (models.InflationComparison, error)
+	// GetRepurchasePredictions predicts when userID's household will next buy
+	// each regularly bought product, most urgent first, as of asOf.
+	GetRepurchasePredictions(userID int64, asOf time.Time) ([]models.RepurchasePrediction, error)
+	// GetBasketReplay returns what a fixed basket cost at the end of each
+	// day, week or month between from and to, carrying each product's last
+	// known household price forward. Returns an error wrapping
+	// ErrInvalidBasket for an empty basket, a non-positive quantity or an
+	// unknown product.
+	GetBasketReplay(userID int64, items []models.BasketItem, from, to time.Time, g Granularity) (models.BasketReplay, error)
+	// GetDigest summarises the last complete week or month (g) before ref for
+	// userID's household.
+	GetDigest(userID int64, g Granularity, ref time.Time) (models.Digest, error)
+
+	// AddWatch puts productID on userID's watchlist with rule, replacing the
+	// threshold if the rule is already set. Returns an error wrapping
+	// ErrInvalidWatch for an unknown rule or an unsuitable threshold.
+	AddWatch(userID int64, productID string, rule WatchRule, threshold *float64) (*models.Watch, error)
+	// GetWatchlist returns userID's watchlist rules, newest first.
+	GetWatchlist(userID int64) ([]models.Watch, error)
+	// DeleteWatch removes one of userID's watchlist rules. Returns false if
+	// it does not exist or belongs to someone else.
+	DeleteWatch(userID, watchID int64) (bool, error)
+	// GetAlerts returns userID's alert inbox, newest first; limit <= 0 lists all.
+	GetAlerts(userID int64, unreadOnly bool, limit int) (models.AlertInbox, error)
+	// MarkAlertsRead marks the given alerts of userID as read (all when empty).
+	MarkAlertsRead(userID int64, alertIDs []int64) error
+
+	// CreateShoppingList creates an empty shopping list for userID's household.
+	CreateShoppingList(userID int64, name string) (int64, error)
+	// GetShoppingLists returns the shopping lists of userID's household with
+	// their items priced at the latest purchase, most recently updated first.
+	GetShoppingLists(userID int64) ([]models.ShoppingList, error)
+	// GetShoppingList returns one list of userID's household, or nil if it
+	// does not exist or belongs to another household.
+	GetShoppingList(userID, listID int64) (*models.ShoppingList, error)
+	// RenameShoppingList renames a list. The list mutators below return
+	// ErrShoppingListNotFound for lists or items outside userID's household.
+	RenameShoppingList(userID, listID int64, name string) error
+	// DeleteShoppingList deletes a list and its items.
+	DeleteShoppingList(userID, listID int64) error
+	// SetShoppingListItem puts quantity units of productID on a list,
+	// replacing the quantity (and unchecking it) if it is already there.
+	SetShoppingListItem(userID, listID int64, productID string, quantity float64) error
+	// UpdateShoppingListItem changes an item's quantity and/or checked state;
+	// nil arguments are left unchanged.
+	UpdateShoppingListItem(userID, listID, itemID int64, quantity *float64, checked *bool) error
+	// DeleteShoppingListItem removes an item from a list.
+	DeleteShoppingListItem(userID, listID, itemID int64) error
+	// FillShoppingList adds the staples due or overdue for repurchase as of
+	// asOf that are not on the list yet and returns how many were added.
+	FillShoppingList(userID, listID int64, asOf time.Time) (int, error)
+
+	// GetMemberSpending splits the spend of userID's household between from
+	// and to inclusive by the member who bought.
+	GetMemberSpending(userID int64, from, to time.Time) (models.MemberSpending, error)
+
+	// GetShoppingPatterns aggregates the tickets of userID's household
+	// between from and to inclusive by weekday, day of month, part of the
+	// month and store.
+	GetShoppingPatterns(userID int64, from, to time.Time) (models.ShoppingPatterns, error)
+
+	// SetBudget sets the monthly limit of userID's household overall (empty
+	// category) or for a top-level category, replacing any previous limit.
+	// Returns an error wrapping ErrInvalidBudget for a non-positive limit.
+	SetBudget(userID int64, category string, limit float64) (*models.Budget, error)
+	// DeleteBudget deletes a budget of userID's household, or returns
+	// ErrBudgetNotFound.
+	DeleteBudget(userID, budgetID int64) error
+	// GetBudgetReport returns the household's budgets with the spend of the
+	// month containing asOf and its projected end-of-month spend.
+	GetBudgetReport(userID int64, asOf time.Time) (models.BudgetReport, error)
+
+	// CreateProductGroup links two or more equivalent products for userID's
+	// household. Returns an error wrapping ErrInvalidProductGroup for fewer
+	// than two products, an unknown product or one already in a group.
+	CreateProductGroup(userID int64, name string, productIDs []string) (int64, error)
+	// GetProductGroups returns the product groups of userID's household.
+	GetProductGroups(userID int64) ([]models.ProductGroup, error)
+	// GetProductGroup returns one group of userID's household, or nil if it
+	// does not exist or belongs to another household.
+	GetProductGroup(userID, groupID int64) (*models.ProductGroup, error)
+	// AddProductGroupMember links another product into a group. The group
+	// mutators return ErrProductGroupNotFound for groups outside userID's
+	// household.
+	AddProductGroupMember(userID, groupID int64, productID string) error
+	// RemoveProductGroupMember unlinks a product from a group.
+	RemoveProductGroupMember(userID, groupID int64, productID string) error
+	// DeleteProductGroup deletes a group, unlinking its products.
+	DeleteProductGroup(userID, groupID int64) error
+	// GetStoreComparison compares the latest price of each product group at
+	// every store and ranks stores by the cost of the household's typical
+	// weekly basket as of asOf.
+	GetStoreComparison(userID int64, asOf time.Time) (models.StoreComparison, error)
 
 	// RevokeToken stores a JWT JTI in the revoked-tokens list so that the
 	// token is rejected even before its natural expiry.
@@ -68,11 +208,44 @@ type Store interface {
 	// passed. Safe to call periodically from a background goroutine.
 	CleanupExpiredTokens() error
 
+	// GetPriceBaselines returns the median price userID's household paid for
+	// each named product over its unflagged purchases, keyed by name.
+	GetPriceBaselines(userID int64, names []string) (map[string]models.PriceBaseline, error)
+	// GetFlaggedRecords returns the household's price records flagged for
+	// review at import, newest first.
+	GetFlaggedRecords(userID int64) ([]models.FlaggedRecord, error)
+	// ConfirmFlaggedRecord clears the review flag of a record, or returns
+	// ErrFlaggedRecordNotFound.
+	ConfirmFlaggedRecord(userID, recordID int64) error
+	// DeleteFlaggedRecord deletes a flagged record, or returns
+	// ErrFlaggedRecordNotFound.
+	DeleteFlaggedRecord(userID, recordID int64) error
+
 	// GetAccumulatedIPC returns the compound interannual IPC for Catalonia from
 	// fromYear up to and including the most recent available year in the database.
 	// Returns the accumulated rate as a decimal (e.g. 0.0537 for +5.37%) and the
 	// last year covered. Returns (0, fromYear, nil) if no data is available.
 	GetAccumulatedIPC(fromYear int) (rate float64, toYear int, err error)
+	// DeflatePriceHistory expresses a product's price history in constant
+	// euros of baseYear (0 = latest IPC year) and compares its change with IPC.
+	// Returns an error wrapping ErrInvalidBaseYear for years without IPC data.
+	DeflatePriceHistory(history []models.PriceRecord, baseYear int) (*models.InflationAdjustment, error)
+	// ForecastPriceHistory projects a product's price for the given number of
+	// months after asOf's month, with a 95% band. Returns an error
+	// wrapping ErrInvalidForecast for a horizon outside 3 to 12 months.
+	ForecastPriceHistory(history []models.PriceRecord, months int, asOf time.Time) (*models.PriceForecast, error)
+
+	// AddProductTag attaches tag to productID for userID's household.
+	// Adding a tag the household already uses on that product is a no-op.
+	AddProductTag(userID int64, productID, tag string) error
+	// RemoveProductTag detaches tag from productID for every member of userID's household.
+	RemoveProductTag(userID int64, productID, tag string) error
+	// GetProductTags returns the distinct tags userID's household has put on productID.
+	GetProductTags(userID int64, productID string) ([]string, error)
+	// SetProductFavourite stars (favourite=true) or unstars productID for userID's household.
+	SetProductFavourite(userID int64, productID string, favourite bool) error
+	// GetSpendByTag returns purchase counts and total spend per household tag.
+	GetSpendByTag(userID int64) ([]models.TagSpend, error)
 
 	// GetHouseholdMembers returns all members of the household userID belongs to.
 	// Returns nil if userID has no household.
@@ -97,7 +270,8 @@ type Store interface {
 
 // SQLiteStore is the production Store backed by a *sql.DB.
 type SQLiteStore struct {
-	db *sql.DB
+	db            *sql.DB
+	alertListener AlertListener
 }
 
 // New returns a SQLiteStore wrapping the given database connection.
@@ -182,18 +356,21 @@ func (s *SQLiteStore) InsertProduct(p models.Product) error {
 	}
 	defer tx.Rollback() //nolint:errcheck
 
-	_, err = tx.Exec(
+	res, err := tx.Exec(
 		`INSERT OR IGNORE INTO products (id, name, category) VALUES (?, ?, ?)`,
 		p.ID, p.Name, p.Category,
 	)
 	if err != nil {
 		return fmt.Errorf("insert product %s: %w", p.ID, err)
 	}
+	if err := syncProductFTS(tx, p.ID, p.Name, rowsInserted(res)); err != nil {
+		return err
+	}
 
 	for _, r := range p.PriceHistory {
 		_, err = tx.Exec(
-			`INSERT INTO price_records (product_id, date, price, store) VALUES (?, ?, ?, ?)`,
-			p.ID, r.Date.Format(time.DateOnly), r.Price, r.Store,
+			`INSERT INTO price_records (product_id, date, price, quantity, store) VALUES (?, ?, ?, ?, ?)`,
+			p.ID, r.Date.Format(time.DateOnly), r.Price, recordQuantity(r), r.Store,
 		)
 		if err != nil {
 			return fmt.Errorf("insert price record for product %s: %w", p.ID, err)
@@ -266,74 +443,6 @@ func repeatArgs(args []any, n int) []any {
 	return out
 }
 
-// SearchProducts returns products that have at least one price record belonging
-// to userID's household and whose name contains query (case-insensitive).
-// An empty query returns all matching products. Results are ordered by the most
-// recent purchase date, descending.
-// When userID == 0, returns products with user_id IS NULL (anonymous/seed data).
-func (s *SQLiteStore) SearchProducts(userID int64, query string) ([]models.SearchResult, error) {
-	ids, err := s.householdUserIDs(userID)
-	if err != nil {
-		return nil, fmt.Errorf("resolve household: %w", err)
-	}
-	clause, baseArgs := userIDsInClause(ids)
-	// clause appears 5 times (4 subqueries + 1 EXISTS).
-	args := repeatArgs(baseArgs, 5)
-
-	baseSQL := `
-		SELECT
-			p.id,
-			p.name,
-			p.category,
-			p.image_url,
-			(SELECT price FROM price_records WHERE product_id = p.id AND ` + clause + ` ORDER BY date DESC LIMIT 1) AS current_price,
-			(SELECT MIN(price) FROM price_records WHERE product_id = p.id AND ` + clause + `)                        AS min_price,
-			(SELECT MAX(price) FROM price_records WHERE product_id = p.id AND ` + clause + `)                        AS max_price,
-			(SELECT MAX(date)  FROM price_records WHERE product_id = p.id AND ` + clause + `)                        AS last_date
-		FROM products p
-		WHERE EXISTS (SELECT 1 FROM price_records WHERE product_id = p.id AND ` + clause + `)
-	`
-
-	var rows *sql.Rows
-	if strings.TrimSpace(query) == "" {
-		rows, err = s.db.Query(baseSQL+" ORDER BY last_date DESC, p.name", args...)
-	} else {
-		rows, err = s.db.Query(
-			baseSQL+` AND p.name LIKE ? ORDER BY last_date DESC, p.name`,
-			append(args, "%"+query+"%")...,
-		)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("search products: %w", err)
-	}
-	defer rows.Close()
-
-	var results []models.SearchResult
-	for rows.Next() {
-		var r models.SearchResult
-		var category, imageURL, lastDate sql.NullString
-		var currentPrice, minPrice, maxPrice sql.NullFloat64
-		if err := rows.Scan(&r.ID, &r.Name, &category, &imageURL, &currentPrice, &minPrice, &maxPrice, &lastDate); err != nil {
-			return nil, fmt.Errorf("scan search result: %w", err)
-		}
-		r.Category = category.String
-		r.ImageURL = imageURL.String
-		r.CurrentPrice = currentPrice.Float64
-		r.MinPrice = minPrice.Float64
-		r.MaxPrice = maxPrice.Float64
-		r.LastPurchaseDate = lastDate.String
-		results = append(results, r)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate search results: %w", err)
-	}
-
-	if results == nil {
-		results = []models.SearchResult{}
-	}
-	return results, nil
-}
-
 // reNonAlphanumeric matches any character that is not a lowercase letter or digit.
 var reNonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
 
@@ -347,6 +456,30 @@ func nullableUserID(userID int64) any {
 	return userID
 }
 
+// nullableAnomaly returns the value stored in price_records.anomaly for a
+// record's review flag: NULL when there is nothing to review.
+func nullableAnomaly(anomaly string) any {
+	if anomaly == "" {
+		return nil
+	}
+	return anomaly
+}
+
+// recordQuantity returns the quantity to store for r; records without one
+// count as a single unit.
+func recordQuantity(r models.PriceRecord) int {
+	if r.Quantity < 1 {
+		return 1
+	}
+	return r.Quantity
+}
+
+// rowsInserted reports whether an INSERT OR IGNORE actually inserted a row.
+func rowsInserted(res sql.Result) bool {
+	n, err := res.RowsAffected()
+	return err == nil && n > 0
+}
+
 // slugify converts a product name to a stable, URL-safe ID.
 // Example: "LECHE ENTERA HACENDADO 1L" → "leche-entera-hacendado-1l"
 func slugify(name string) string {
@@ -369,18 +502,21 @@ func (s *SQLiteStore) UpsertPriceRecord(userID int64, name string, record models
 	defer tx.Rollback() //nolint:errcheck
 
 	// Insert product if it does not exist yet.
-	_, err = tx.Exec(
+	res, err := tx.Exec(
 		`INSERT OR IGNORE INTO products (id, name, category) VALUES (?, ?, ?)`,
 		id, name, "",
 	)
 	if err != nil {
 		return fmt.Errorf("upsert product %q: %w", name, err)
 	}
+	if err := syncProductFTS(tx, id, name, rowsInserted(res)); err != nil {
+		return err
+	}
 
 	// Insert the price record scoped to userID (NULL when userID == 0).
 	_, err = tx.Exec(
-		`INSERT INTO price_records (product_id, date, price, store, user_id) VALUES (?, ?, ?, ?, ?)`,
-		id, record.Date.Format(time.DateOnly), record.Price, record.Store, nullableUserID(userID),
+		`INSERT INTO price_records (product_id, date, price, quantity, store, user_id) VALUES (?, ?, ?, ?, ?, ?)`,
+		id, record.Date.Format(time.DateOnly), record.Price, recordQuantity(record), record.Store, nullableUserID(userID),
 	)
 	if err != nil {
 		return fmt.Errorf("insert price record for product %q: %w", name, err)
@@ -393,9 +529,32 @@ func (s *SQLiteStore) UpsertPriceRecord(userID int64, name string, record models
 // scoped to userID. Either every entry is committed or none is.
 // Calling it with an empty slice is a no-op that returns nil.
 func (s *SQLiteStore) UpsertPriceRecordBatch(userID int64, entries []models.PriceRecordEntry) error {
+	return s.upsertPriceRecords(userID, nil, entries)
+}
+
+// UpsertTicket persists an imported ticket and its entries inside a single
+// transaction scoped to userID, linking every price record to the ticket.
+// Either the ticket and all its entries are committed or nothing is.
+// Calling it with an empty slice is a no-op that returns nil.
+func (s *SQLiteStore) UpsertTicket(userID int64, header models.TicketHeader, entries []models.PriceRecordEntry) error {
+	return s.upsertPriceRecords(userID, &header, entries)
+}
+
+// upsertPriceRecords backs UpsertPriceRecordBatch and UpsertTicket; the
+// records are linked to a new ticket row when header is not nil.
+func (s *SQLiteStore) upsertPriceRecords(userID int64, header *models.TicketHeader, entries []models.PriceRecordEntry) error {
 	if len(entries) == 0 {
 		return nil
 	}
+	// Resolved before the transaction: the pool holds a single connection.
+	var memberIDs []int64
+	if userID != 0 {
+		ids, err := s.householdUserIDs(userID)
+		if err != nil {
+			return fmt.Errorf("resolve household: %w", err)
+		}
+		memberIDs = ids
+	}
 
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -403,47 +562,112 @@ func (s *SQLiteStore) UpsertPriceRecordBatch(userID int64, entries []models.Pric
 	}
 	defer tx.Rollback() //nolint:errcheck
 
+	var ticketID any // NULL for records not imported from a ticket
+	if header != nil {
+		res, err := tx.Exec(
+			`INSERT INTO tickets (user_id, invoice_number, store, date, total, lines, imported_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			nullableUserID(userID), header.InvoiceNumber, header.Store, header.Date.Format(time.DateOnly),
+			header.Total, header.Lines, time.Now().UTC().Format(time.RFC3339),
+		)
+		if err != nil {
+			return fmt.Errorf("insert ticket %s: %w", header.InvoiceNumber, err)
+		}
+		if ticketID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("get last insert id: %w", err)
+		}
+	}
+
+	var fired []firedAlert
+	days := make(map[string]string) // month → latest purchase date in the batch
 	for _, e := range entries {
 		id := slugify(e.Name)
+		day := e.Record.Date.Format(time.DateOnly)
+		if month := day[:7]; day > days[month] {
+			days[month] = day
+		}
 
-		_, err = tx.Exec(
+		res, err := tx.Exec(
 			`INSERT OR IGNORE INTO products (id, name, category) VALUES (?, ?, ?)`,
 			id, e.Name, "",
 		)
 		if err != nil {
 			return fmt.Errorf("upsert product %q: %w", e.Name, err)
 		}
+		if err := syncProductFTS(tx, id, e.Name, rowsInserted(res)); err != nil {
+			return err
+		}
 
-		_, err = tx.Exec(
-			`INSERT INTO price_records (product_id, date, price, store, user_id) VALUES (?, ?, ?, ?, ?)`,
-			id, e.Record.Date.Format(time.DateOnly), e.Record.Price, e.Record.Store, nullableUserID(userID),
+		res, err = tx.Exec(
+			`INSERT INTO price_records (product_id, date, price, quantity, store, user_id, anomaly, ticket_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, e.Record.Date.Format(time.DateOnly), e.Record.Price, recordQuantity(e.Record), e.Record.Store, nullableUserID(userID),
+			nullableAnomaly(e.Record.Anomaly), ticketID,
 		)
 		if err != nil {
 			return fmt.Errorf("insert price record for product %q: %w", e.Name, err)
 		}
+		// A price flagged for review raises no watch alerts.
+		if memberIDs != nil && e.Record.Anomaly == "" {
+			recordID, err := res.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("get last insert id: %w", err)
+			}
+			alerts, err := evaluateWatches(tx, memberIDs, id, recordID, e.Record)
+			if err != nil {
+				return err
+			}
+			fired = append(fired, alerts...)
+		}
+	}
+	if memberIDs != nil {
+		alerts, err := evaluateBudgets(tx, memberIDs, days)
+		if err != nil {
+			return err
+		}
+		fired = append(fired, alerts...)
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	if s.alertListener != nil {
+		for _, f := range fired {
+			s.alertListener(f.userID, f.alert)
+		}
+	}
+	return nil
 }
 
 // GetProductByID returns the product with its price history scoped to the
 // household of userID. Pass userID=0 for anonymous (seed) access.
 // Returns nil if no product with that ID exists.
 func (s *SQLiteStore) GetProductByID(userID int64, id string) (*models.Product, error) {
+	return s.productByID(userID, id, false)
+}
+
+// GetOwnProductByID is GetProductByID with the price history limited to the
+// purchases of userID alone; tags and favourite stay household-wide.
+func (s *SQLiteStore) GetOwnProductByID(userID int64, id string) (*models.Product, error) {
+	return s.productByID(userID, id, true)
+}
+
+// productByID loads a product for userID's household; with ownOnly its price
+// history holds userID's purchases only.
+func (s *SQLiteStore) productByID(userID int64, id string, ownOnly bool) (*models.Product, error) {
 	row := s.db.QueryRow(
-		`SELECT id, name, category, image_url, image_url_locked FROM products WHERE id = ?`, id,
+		`SELECT id, name, category, category_locked, image_url, image_url_locked FROM products WHERE id = ?`, id,
 	)
 
 	var p models.Product
 	var category, imageURL sql.NullString
-	var locked int
-	if err := row.Scan(&p.ID, &p.Name, &category, &imageURL, &locked); err != nil {
+	var categoryLocked, locked int
+	if err := row.Scan(&p.ID, &p.Name, &category, &categoryLocked, &imageURL, &locked); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("get product %s: %w", id, err)
 	}
 	p.Category = category.String
+	p.CategoryLocked = categoryLocked == 1
 	p.ImageURL = imageURL.String
 	p.ImageURLLocked = locked == 1
 
@@ -451,11 +675,19 @@ func (s *SQLiteStore) GetProductByID(userID int64, id string) (*models.Product,
 	if err != nil {
 		return nil, fmt.Errorf("resolve household: %w", err)
 	}
-	clause, clauseArgs := userIDsInClause(memberIDs)
+	recordIDs := memberIDs
+	if ownOnly && userID != 0 {
+		recordIDs = []int64{userID}
+	}
+	clause, clauseArgs := userIDsInClause(recordIDs)
 	queryArgs := append([]any{id}, clauseArgs...)
 
-	rows, err := s.db.Query(
-		`SELECT id, date, price, store FROM price_records WHERE product_id = ? AND `+clause+` ORDER BY date ASC`,
+	rows, err := s.db.Query(`
+		SELECT pr.id, pr.date, pr.price, pr.quantity, pr.store, COALESCE(pr.anomaly, ''), COALESCE(u.username, '')
+		FROM price_records pr
+		LEFT JOIN users u ON u.id = pr.user_id
+		WHERE pr.product_id = ? AND pr.`+clause+`
+		ORDER BY pr.date ASC`,
 		queryArgs...,
 	)
 	if err != nil {
@@ -466,7 +698,7 @@ func (s *SQLiteStore) GetProductByID(userID int64, id string) (*models.Product,
 	for rows.Next() {
 		var rec models.PriceRecord
 		var dateStr string
-		if err := rows.Scan(&rec.RecordID, &dateStr, &rec.Price, &rec.Store); err != nil {
+		if err := rows.Scan(&rec.RecordID, &dateStr, &rec.Price, &rec.Quantity, &rec.Store, &rec.Anomaly, &rec.BoughtBy); err != nil {
 			return nil, fmt.Errorf("scan price record: %w", err)
 		}
 		rec.Date, err = time.Parse(time.DateOnly, dateStr)
@@ -479,9 +711,19 @@ func (s *SQLiteStore) GetProductByID(userID int64, id string) (*models.Product,
 		return nil, fmt.Errorf("iterate price records: %w", err)
 	}
 
-	// Derive CurrentPrice from the most recent record.
-	if len(p.PriceHistory) > 0 {
-		p.CurrentPrice = p.PriceHistory[len(p.PriceHistory)-1].Price
+	// Derive CurrentPrice from the most recent record not flagged for review,
+	// as search does.
+	if kept := unflaggedRecords(p.PriceHistory); len(kept) > 0 {
+		p.CurrentPrice = kept[len(kept)-1].Price
+	}
+
+	p.Tags, err = s.GetProductTags(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	p.Favourite, err = s.isFavourite(memberIDs, id)
+	if err != nil {
+		return nil, err
 	}
 
 	return &p, nil
@@ -541,6 +783,61 @@ func (s *SQLiteStore) GetProductsWithoutImage() ([]models.SearchResult, error) {
 	return results, nil
 }
 
+// UpdateProductCategory sets the category for the product with the given ID.
+// It is a no-op if no product with that ID exists or if its category has been
+// locked by a manual override. It does NOT set the locked flag — use
+// SetProductCategoryManual for that.
+func (s *SQLiteStore) UpdateProductCategory(id, category string) error {
+	_, err := s.db.Exec(
+		`UPDATE products SET category = ? WHERE id = ? AND category_locked = 0`, category, id,
+	)
+	if err != nil {
+		return fmt.Errorf("update category for product %s: %w", id, err)
+	}
+	return nil
+}
+
+// SetProductCategoryManual sets a user-chosen category and marks the product
+// as locked (category_locked = 1) so the enricher will skip it in future runs.
+func (s *SQLiteStore) SetProductCategoryManual(id, category string) error {
+	_, err := s.db.Exec(
+		`UPDATE products SET category = ?, category_locked = 1 WHERE id = ?`, category, id,
+	)
+	if err != nil {
+		return fmt.Errorf("set manual category for product %s: %w", id, err)
+	}
+	return nil
+}
+
+// GetProductsWithoutCategory returns a minimal projection of every product
+// whose category is empty and that is not manually locked.
+// Only the ID and Name fields are populated.
+func (s *SQLiteStore) GetProductsWithoutCategory() ([]models.SearchResult, error) {
+	rows, err := s.db.Query(
+		`SELECT id, name FROM products WHERE (category IS NULL OR category = '') AND category_locked = 0 ORDER BY name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get products without category: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.ID, &r.Name); err != nil {
+			return nil, fmt.Errorf("scan product without category: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate products without category: %w", err)
+	}
+	if results == nil {
+		results = []models.SearchResult{}
+	}
+	return results, nil
+}
+
 // IsFileProcessed returns true when filename has already been imported by any
 // member of userID's household. This prevents duplicate imports within a household.
 // When userID == 0, checks for records where user_id IS NULL (anonymous/seed data).
@@ -574,17 +871,21 @@ func (s *SQLiteStore) MarkFileProcessed(userID int64, filename string, importedA
 	return nil
 }
 
-// GetMostPurchased returns the top `limit` products ranked by total number of
-// price records belonging to userID's household.
+// GetMostPurchased returns the top f.Limit products ranked by total number of
+// price records belonging to userID's household that match f. CurrentPrice is
+// the latest matching price.
 // When userID == 0, returns products with user_id IS NULL (anonymous/seed data).
-func (s *SQLiteStore) GetMostPurchased(userID int64, limit int) ([]models.MostPurchasedProduct, error) {
+func (s *SQLiteStore) GetMostPurchased(userID int64, f AnalyticsFilter) ([]models.MostPurchasedProduct, error) {
 	ids, err := s.householdUserIDs(userID)
 	if err != nil {
 		return nil, fmt.Errorf("resolve household: %w", err)
 	}
 	clause, baseArgs := userIDsInClause(ids)
-	// clause appears twice (subquery + JOIN)
-	joinArgs := append(repeatArgs(baseArgs, 2), limit)
+	filter, filterArgs := f.recordClause("")
+	prFilter, _ := f.recordClause("pr.")
+	// clause and filter appear twice (subquery + JOIN)
+	scoped := append(append([]any{}, baseArgs...), filterArgs...)
+	joinArgs := append(repeatArgs(scoped, 2), f.sqlLimit())
 
 	q := `
 		SELECT
@@ -592,9 +893,9 @@ func (s *SQLiteStore) GetMostPurchased(userID int64, limit int) ([]models.MostPu
 			p.name,
 			COALESCE(p.image_url, '') AS image_url,
 			COUNT(pr.id)              AS purchase_count,
-			COALESCE((SELECT price FROM price_records WHERE product_id = p.id AND ` + clause + ` ORDER BY date DESC LIMIT 1), 0) AS current_price
+			COALESCE((SELECT price FROM price_records WHERE product_id = p.id AND ` + clause + filter + ` ORDER BY date DESC LIMIT 1), 0) AS current_price
 		FROM products p
-		JOIN price_records pr ON pr.product_id = p.id AND pr.` + clause + `
+		JOIN price_records pr ON pr.product_id = p.id AND pr.` + clause + prFilter + `
 		GROUP BY p.id
 		ORDER BY purchase_count DESC, p.name ASC
 		LIMIT ?
@@ -681,11 +982,15 @@ func (s *SQLiteStore) CreateHousehold(ownerID int64) (int64, error) {
 	if _, err = tx.Exec(`UPDATE users SET household_id = ? WHERE id = ?`, hid, ownerID); err != nil {
 		return 0, fmt.Errorf("assign household to user %d: %w", ownerID, err)
 	}
+	if err := rekeyBudgets(tx, ownerID); err != nil {
+		return 0, err
+	}
 	return hid, tx.Commit()
 }
 
-// AddUserToHousehold moves userID into the given household.
-// If userID was in a different household and that household becomes empty,
+// AddUserToHousehold moves userID into the given household, taking the
+// budgets userID created along unless the household already budgets the
+// same category. If userID was in a different household and that household becomes empty,
 // it is deleted (cascade removes its invitations).
 func (s *SQLiteStore) AddUserToHousehold(userID, householdID int64) error {
 	tx, err := s.db.Begin()
@@ -700,6 +1005,9 @@ func (s *SQLiteStore) AddUserToHousehold(userID, householdID int64) error {
 	if _, err = tx.Exec(`UPDATE users SET household_id = ? WHERE id = ?`, householdID, userID); err != nil {
 		return fmt.Errorf("add user %d to household %d: %w", userID, householdID, err)
 	}
+	if err := rekeyBudgets(tx, userID); err != nil {
+		return err
+	}
 	if oldHouseholdID.Valid && oldHouseholdID.Int64 != householdID {
 		var count int
 		_ = tx.QueryRow(`SELECT COUNT(*) FROM users WHERE household_id = ?`, oldHouseholdID.Int64).Scan(&count)
@@ -729,6 +1037,9 @@ func (s *SQLiteStore) RemoveUserFromHousehold(userID int64) error {
 	if _, err = tx.Exec(`UPDATE users SET household_id = NULL WHERE id = ?`, userID); err != nil {
 		return fmt.Errorf("remove user %d from household: %w", userID, err)
 	}
+	if err := rekeyBudgets(tx, userID); err != nil {
+		return err
+	}
 	if householdID.Valid {
 		var count int
 		_ = tx.QueryRow(`SELECT COUNT(*) FROM users WHERE household_id = ?`, householdID.Int64).Scan(&count)
@@ -872,18 +1183,22 @@ func generateToken() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-// GetBiggestPriceIncreases returns the top `limit` products by percentage price
-// increase for userID's household, from first to latest record.
+// GetBiggestPriceIncreases returns the top f.Limit products by percentage price
+// increase for userID's household, from the first to the latest record that
+// matches f.
 // Only products with ≥2 records and a strictly positive increase are included.
 // When userID == 0, returns results for records with user_id IS NULL.
-func (s *SQLiteStore) GetBiggestPriceIncreases(userID int64, limit int) ([]models.PriceIncreaseProduct, error) {
+func (s *SQLiteStore) GetBiggestPriceIncreases(userID int64, f AnalyticsFilter) ([]models.PriceIncreaseProduct, error) {
 	ids, err := s.householdUserIDs(userID)
 	if err != nil {
 		return nil, fmt.Errorf("resolve household: %w", err)
 	}
 	clause, baseArgs := userIDsInClause(ids)
-	// clause appears 5 times
-	queryArgs := append(repeatArgs(baseArgs, 5), limit)
+	filter, filterArgs := f.recordClause("")
+	scope := clause + filter
+	// scope appears 5 times
+	scoped := append(append([]any{}, baseArgs...), filterArgs...)
+	queryArgs := append(repeatArgs(scoped, 5), f.sqlLimit())
 
 	q := `
 		SELECT
@@ -897,21 +1212,21 @@ func (s *SQLiteStore) GetBiggestPriceIncreases(userID int64, limit int) ([]model
 		JOIN (
 			SELECT product_id, price
 			FROM price_records
-			WHERE ` + clause + `
+			WHERE ` + scope + `
 			  AND (product_id, date) IN (
-				SELECT product_id, MIN(date) FROM price_records WHERE ` + clause + ` GROUP BY product_id
+				SELECT product_id, MIN(date) FROM price_records WHERE ` + scope + ` GROUP BY product_id
 			)
 		) first_rec ON first_rec.product_id = p.id
 		JOIN (
 			SELECT product_id, price
 			FROM price_records
-			WHERE ` + clause + `
+			WHERE ` + scope + `
 			  AND (product_id, date) IN (
-				SELECT product_id, MAX(date) FROM price_records WHERE ` + clause + ` GROUP BY product_id
+				SELECT product_id, MAX(date) FROM price_records WHERE ` + scope + ` GROUP BY product_id
 			)
 		) last_rec ON last_rec.product_id = p.id
 		WHERE last_rec.price > first_rec.price
-		  AND (SELECT COUNT(*) FROM price_records WHERE product_id = p.id AND ` + clause + `) >= 2
+		  AND (SELECT COUNT(*) FROM price_records WHERE product_id = p.id AND ` + scope + `) >= 2
 		ORDER BY increase_pct DESC, p.name ASC
 		LIMIT ?
 	`
@@ -218,7 +218,7 @@ func TestSearchProducts_EmptyQuery_ReturnsAll(t *testing.T) {
 		insertProductForUser(t, s, uid, p)
 	}
 
-	results, err := s.SearchProducts(uid, "")
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -245,7 +245,7 @@ func TestSearchProducts_WithQuery_ReturnsMatches(t *testing.T) {
 		insertProductForUser(t, s, uid, p)
 	}
 
-	results, err := s.SearchProducts(uid, "leche")
+	results, err := s.SearchProducts(uid, store.SearchOptions{Query: "leche"})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -262,7 +262,7 @@ func TestSearchProducts_NoMatch_ReturnsEmptySlice(t *testing.T) {
 	uid := createTestUser(t, s)
 	insertProductForUser(t, s, uid, sampleProduct("1"))
 
-	results, err := s.SearchProducts(uid, "xyznonexistent")
+	results, err := s.SearchProducts(uid, store.SearchOptions{Query: "xyznonexistent"})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -289,7 +289,7 @@ func TestSearchProducts_CaseInsensitive(t *testing.T) {
 
 	for _, q := range []string{"leche", "LECHE", "Leche", "lEcHe"} {
 		t.Run(q, func(t *testing.T) {
-			results, err := s.SearchProducts(uid, q)
+			results, err := s.SearchProducts(uid, store.SearchOptions{Query: q})
 			if err != nil {
 				t.Fatalf("SearchProducts(%q): %v", q, err)
 			}
@@ -315,7 +315,7 @@ func TestSearchProducts_MinMaxPrice(t *testing.T) {
 	}
 	insertProductForUser(t, s, uid, p)
 
-	results, err := s.SearchProducts(uid, "")
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -346,7 +346,7 @@ func TestSearchProducts_LastPurchaseDatePopulated(t *testing.T) {
 	}
 	insertProductForUser(t, s, uid, p)
 
-	results, err := s.SearchProducts(uid, "")
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -380,7 +380,7 @@ func TestSearchProducts_OrderedByLastPurchaseDateDesc(t *testing.T) {
 	insertProductForUser(t, s, uid, older)
 	insertProductForUser(t, s, uid, newer)
 
-	results, err := s.SearchProducts(uid, "")
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -399,7 +399,7 @@ func TestSearchProducts_OrderedByLastPurchaseDateDesc(t *testing.T) {
 func TestSearchProducts_EmptyDB_ReturnsEmptySlice(t *testing.T) {
 	s := newTestStore(t)
 	uid := createTestUser(t, s)
-	results, err := s.SearchProducts(uid, "")
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts on empty DB: %v", err)
 	}
@@ -446,7 +446,7 @@ func TestUpdateProductImageURL_SearchResultIncludesURL(t *testing.T) {
 		t.Fatalf("UpdateProductImageURL: %v", err)
 	}
 
-	results, err := s.SearchProducts(uid, "")
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -466,6 +466,87 @@ func TestUpdateProductImageURL_NoOpOnMissingProduct(t *testing.T) {
 	}
 }
 
+// ---------- Product category ----------
+
+func TestUpdateProductCategory_SetsCategory(t *testing.T) {
+	s := newTestStore(t)
+	p := sampleProduct("cat-test")
+	p.Category = ""
+	if err := s.InsertProduct(p); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	const category = "Lácteos y huevos > Leche y bebidas vegetales"
+	if err := s.UpdateProductCategory("cat-test", category); err != nil {
+		t.Fatalf("UpdateProductCategory: %v", err)
+	}
+
+	got, err := s.GetProductByID(0, "cat-test")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if got.Category != category {
+		t.Errorf("Category: want %q, got %q", category, got.Category)
+	}
+	if got.CategoryLocked {
+		t.Error("CategoryLocked: want false after enricher update")
+	}
+}
+
+func TestSetProductCategoryManual_LocksAgainstEnricher(t *testing.T) {
+	s := newTestStore(t)
+	p := sampleProduct("cat-lock")
+	p.Category = ""
+	if err := s.InsertProduct(p); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	if err := s.SetProductCategoryManual("cat-lock", "Desayuno"); err != nil {
+		t.Fatalf("SetProductCategoryManual: %v", err)
+	}
+	// A later enricher update must not overwrite the manual category.
+	if err := s.UpdateProductCategory("cat-lock", "Lácteos y huevos > Leche"); err != nil {
+		t.Fatalf("UpdateProductCategory: %v", err)
+	}
+
+	got, err := s.GetProductByID(0, "cat-lock")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if got.Category != "Desayuno" {
+		t.Errorf("Category: want %q, got %q", "Desayuno", got.Category)
+	}
+	if !got.CategoryLocked {
+		t.Error("CategoryLocked: want true after manual override")
+	}
+}
+
+func TestGetProductsWithoutCategory_ExcludesCategorisedAndLocked(t *testing.T) {
+	s := newTestStore(t)
+	for _, id := range []string{"a", "b", "c"} {
+		p := sampleProduct(id)
+		p.Name = "PRODUCT " + id
+		p.Category = ""
+		if err := s.InsertProduct(p); err != nil {
+			t.Fatalf("insert %s: %v", id, err)
+		}
+	}
+	if err := s.UpdateProductCategory("a", "Panadería y pastelería > Pan de horno"); err != nil {
+		t.Fatalf("UpdateProductCategory: %v", err)
+	}
+	if err := s.SetProductCategoryManual("b", "Desayuno"); err != nil {
+		t.Fatalf("SetProductCategoryManual: %v", err)
+	}
+
+	got, err := s.GetProductsWithoutCategory()
+	if err != nil {
+		t.Fatalf("GetProductsWithoutCategory: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "c" {
+		t.Errorf("want only product c, got %+v", got)
+	}
+}
+
 // ---------- IsFileProcessed / MarkFileProcessed ----------
 
 func TestIsFileProcessed_UnknownFile_ReturnsFalse(t *testing.T) {
@@ -552,7 +633,7 @@ func TestUpsertPriceRecordBatch_AllEntriesCommitted(t *testing.T) {
 		t.Fatalf("UpsertPriceRecordBatch: %v", err)
 	}
 
-	results, err := s.SearchProducts(uid, "")
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -567,7 +648,7 @@ func TestUpsertPriceRecordBatch_EmptySlice_NoOp(t *testing.T) {
 	if err := s.UpsertPriceRecordBatch(uid, []models.PriceRecordEntry{}); err != nil {
 		t.Fatalf("UpsertPriceRecordBatch with empty slice: %v", err)
 	}
-	results, err := s.SearchProducts(uid, "")
+	results, err := s.SearchProducts(uid, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -733,7 +814,7 @@ func TestGetMostPurchased_RankedByPurchaseCount(t *testing.T) {
 	insertProductForUser(t, s, uid, leche)
 	insertProductForUser(t, s, uid, pan)
 
-	got, err := s.GetMostPurchased(uid, 10)
+	got, err := s.GetMostPurchased(uid, store.AnalyticsFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("GetMostPurchased: %v", err)
 	}
@@ -767,7 +848,7 @@ func TestGetMostPurchased_RespectsLimit(t *testing.T) {
 		insertProductForUser(t, s, uid, p)
 	}
 
-	got, err := s.GetMostPurchased(uid, 2)
+	got, err := s.GetMostPurchased(uid, store.AnalyticsFilter{Limit: 2})
 	if err != nil {
 		t.Fatalf("GetMostPurchased: %v", err)
 	}
@@ -779,7 +860,7 @@ func TestGetMostPurchased_RespectsLimit(t *testing.T) {
 func TestGetMostPurchased_EmptyDB_ReturnsEmptySlice(t *testing.T) {
 	s := newTestStore(t)
 	uid := createTestUser(t, s)
-	got, err := s.GetMostPurchased(uid, 10)
+	got, err := s.GetMostPurchased(uid, store.AnalyticsFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("GetMostPurchased: %v", err)
 	}
@@ -804,7 +885,7 @@ func TestGetMostPurchased_CurrentPriceIsLatest(t *testing.T) {
 	}
 	insertProductForUser(t, s, uid, p)
 
-	got, err := s.GetMostPurchased(uid, 10)
+	got, err := s.GetMostPurchased(uid, store.AnalyticsFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("GetMostPurchased: %v", err)
 	}
@@ -842,7 +923,7 @@ func TestGetBiggestPriceIncreases_RankedByPercent(t *testing.T) {
 	insertProductForUser(t, s, uid, aceite)
 	insertProductForUser(t, s, uid, leche)
 
-	got, err := s.GetBiggestPriceIncreases(uid, 10)
+	got, err := s.GetBiggestPriceIncreases(uid, store.AnalyticsFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("GetBiggestPriceIncreases: %v", err)
 	}
@@ -873,7 +954,7 @@ func TestGetBiggestPriceIncreases_ExcludesDecreases(t *testing.T) {
 	}
 	insertProductForUser(t, s, uid, p)
 
-	got, err := s.GetBiggestPriceIncreases(uid, 10)
+	got, err := s.GetBiggestPriceIncreases(uid, store.AnalyticsFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("GetBiggestPriceIncreases: %v", err)
 	}
@@ -895,7 +976,7 @@ func TestGetBiggestPriceIncreases_ExcludesSingleRecord(t *testing.T) {
 	}
 	insertProductForUser(t, s, uid, p)
 
-	got, err := s.GetBiggestPriceIncreases(uid, 10)
+	got, err := s.GetBiggestPriceIncreases(uid, store.AnalyticsFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("GetBiggestPriceIncreases: %v", err)
 	}
@@ -918,7 +999,7 @@ func TestGetBiggestPriceIncreases_FieldsPopulated(t *testing.T) {
 	}
 	insertProductForUser(t, s, uid, p)
 
-	got, err := s.GetBiggestPriceIncreases(uid, 10)
+	got, err := s.GetBiggestPriceIncreases(uid, store.AnalyticsFilter{Limit: 10})
 	if err != nil {
 		t.Fatalf("GetBiggestPriceIncreases: %v", err)
 	}
@@ -954,7 +1035,7 @@ func TestGetBiggestPriceIncreases_RespectsLimit(t *testing.T) {
 		insertProductForUser(t, s, uid, p)
 	}
 
-	got, err := s.GetBiggestPriceIncreases(uid, 2)
+	got, err := s.GetBiggestPriceIncreases(uid, store.AnalyticsFilter{Limit: 2})
 	if err != nil {
 		t.Fatalf("GetBiggestPriceIncreases: %v", err)
 	}
@@ -963,6 +1044,46 @@ func TestGetBiggestPriceIncreases_RespectsLimit(t *testing.T) {
 	}
 }
 
+func TestGetBiggestPriceIncreases_FilteredByDateRangeAndStore(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, models.Product{
+		ID:   "aceite",
+		Name: "ACEITE OLIVA",
+		PriceHistory: []models.PriceRecord{
+			{Date: date(2024, 1, 10), Price: 4.00, Store: "Mercadona"},
+			{Date: date(2025, 1, 10), Price: 8.00, Store: "Mercadona"},
+			{Date: date(2025, 6, 10), Price: 8.80, Store: "Mercadona"},
+			{Date: date(2025, 7, 10), Price: 20.00, Store: "Bonpreu"},
+		},
+	})
+
+	// Over the whole history Bonpreu's price makes this a +400% increase;
+	// restricted to Mercadona in 2025 it is 8.00 → 8.80.
+	f := store.AnalyticsFilter{From: date(2025, 1, 1), To: date(2025, 12, 31), Store: "mercadona", Limit: 10}
+	got, err := s.GetBiggestPriceIncreases(uid, f)
+	if err != nil {
+		t.Fatalf("GetBiggestPriceIncreases: %v", err)
+	}
+	if len(got) != 1 || got[0].FirstPrice != 8.00 || got[0].CurrentPrice != 8.80 || got[0].IncreasePercent != 10 {
+		t.Fatalf("want one +10%% increase, got %+v", got)
+	}
+
+	mp, err := s.GetMostPurchased(uid, f)
+	if err != nil {
+		t.Fatalf("GetMostPurchased: %v", err)
+	}
+	if len(mp) != 1 || mp[0].PurchaseCount != 2 || mp[0].CurrentPrice != 8.80 {
+		t.Errorf("want 2 Mercadona purchases in 2025 at 8.80, got %+v", mp)
+	}
+
+	// A single matching record is not an increase.
+	f.From = date(2025, 6, 1)
+	if got, err := s.GetBiggestPriceIncreases(uid, f); err != nil || len(got) != 0 {
+		t.Errorf("want no increases from a single record, got %+v (%v)", got, err)
+	}
+}
+
 // ---------- Household ----------
 
 func createTestUser2(t *testing.T, s *store.SQLiteStore, username string) int64 {
@@ -1077,7 +1198,7 @@ func TestHousehold_SharedSearchProducts(t *testing.T) {
 	if err := s.UpsertPriceRecord(uid1, "LECHE ENTERA", rec); err != nil {
 		t.Fatalf("UpsertPriceRecord: %v", err)
 	}
-	results, err := s.SearchProducts(uid2, "")
+	results, err := s.SearchProducts(uid2, store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchProducts: %v", err)
 	}
@@ -1095,8 +1216,8 @@ func TestHousehold_UsersWithoutHouseholdSeeOnlyOwn(t *testing.T) {
 	_ = s.UpsertPriceRecord(uid1, "LECHE ENTERA", rec)
 	_ = s.UpsertPriceRecord(uid2, "PAN INTEGRAL", rec)
 
-	aliceResults, _ := s.SearchProducts(uid1, "")
-	bobResults, _ := s.SearchProducts(uid2, "")
+	aliceResults, _ := s.SearchProducts(uid1, store.SearchOptions{})
+	bobResults, _ := s.SearchProducts(uid2, store.SearchOptions{})
 
 	if len(aliceResults) != 1 || aliceResults[0].ID != "leche-entera" {
 		t.Errorf("alice: expected leche-entera, got %+v", aliceResults)
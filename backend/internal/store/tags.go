@@ -0,0 +1,176 @@
+package store
+
+import (
+	"basket-cost/internal/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ---------- Tags and favourites ----------
+
+// splitTags turns the comma-separated output of GROUP_CONCAT into a slice.
+// Returns nil for an empty string so that JSON omits the field.
+func splitTags(concatenated string) []string {
+	if concatenated == "" {
+		return nil
+	}
+	return strings.Split(concatenated, ",")
+}
+
+// AddProductTag attaches tag to productID on behalf of userID. Tags are shared
+// across the household: if any member already applied the same tag to the
+// product, the call is a no-op.
+func (s *SQLiteStore) AddProductTag(userID int64, productID, tag string) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, clauseArgs := userIDsInClause(ids)
+	args := append([]any{productID, userID, tag, time.Now().UTC().Format(time.RFC3339), productID, tag}, clauseArgs...)
+	_, err = s.db.Exec(
+		`INSERT OR IGNORE INTO product_tags (product_id, user_id, tag, created_at)
+		 SELECT ?, ?, ?, ?
+		 WHERE NOT EXISTS (SELECT 1 FROM product_tags WHERE product_id = ? AND tag = ? AND `+clause+`)`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("add tag %q to product %s: %w", tag, productID, err)
+	}
+	return nil
+}
+
+// RemoveProductTag detaches tag from productID for every member of userID's
+// household. Removing a tag that is not present is a no-op.
+func (s *SQLiteStore) RemoveProductTag(userID int64, productID, tag string) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, clauseArgs := userIDsInClause(ids)
+	args := append([]any{productID, tag}, clauseArgs...)
+	if _, err := s.db.Exec(
+		`DELETE FROM product_tags WHERE product_id = ? AND tag = ? AND `+clause, args...,
+	); err != nil {
+		return fmt.Errorf("remove tag %q from product %s: %w", tag, productID, err)
+	}
+	return nil
+}
+
+// GetProductTags returns the distinct tags userID's household has applied to
+// productID, in alphabetical order. Returns nil when there are none.
+func (s *SQLiteStore) GetProductTags(userID int64, productID string) ([]string, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, clauseArgs := userIDsInClause(ids)
+	args := append([]any{productID}, clauseArgs...)
+	rows, err := s.db.Query(
+		`SELECT DISTINCT tag FROM product_tags WHERE product_id = ? AND `+clause+` ORDER BY tag`, args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get tags for product %s: %w", productID, err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tags: %w", err)
+	}
+	return tags, nil
+}
+
+// SetProductFavourite stars or unstars productID for userID's household.
+// Starring is idempotent; unstarring removes the star set by any member.
+func (s *SQLiteStore) SetProductFavourite(userID int64, productID string, favourite bool) error {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return fmt.Errorf("resolve household: %w", err)
+	}
+	clause, clauseArgs := userIDsInClause(ids)
+	if favourite {
+		args := append([]any{productID, userID, time.Now().UTC().Format(time.RFC3339), productID}, clauseArgs...)
+		_, err = s.db.Exec(
+			`INSERT OR IGNORE INTO product_favourites (product_id, user_id, created_at)
+			 SELECT ?, ?, ?
+			 WHERE NOT EXISTS (SELECT 1 FROM product_favourites WHERE product_id = ? AND `+clause+`)`,
+			args...,
+		)
+	} else {
+		args := append([]any{productID}, clauseArgs...)
+		_, err = s.db.Exec(`DELETE FROM product_favourites WHERE product_id = ? AND `+clause, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("set favourite=%v for product %s: %w", favourite, productID, err)
+	}
+	return nil
+}
+
+// isFavourite reports whether any of memberIDs has starred productID.
+func (s *SQLiteStore) isFavourite(memberIDs []int64, productID string) (bool, error) {
+	clause, clauseArgs := userIDsInClause(memberIDs)
+	args := append([]any{productID}, clauseArgs...)
+	var fav bool
+	err := s.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM product_favourites WHERE product_id = ? AND `+clause+`)`, args...,
+	).Scan(&fav)
+	if err != nil {
+		return false, fmt.Errorf("check favourite for product %s: %w", productID, err)
+	}
+	return fav, nil
+}
+
+// GetSpendByTag groups userID's household purchases (price × quantity) by the
+// tags applied to each product, leaving out records flagged for review. Tags
+// with no purchases are included with zero totals.
+// Results are ordered by total spend, descending.
+func (s *SQLiteStore) GetSpendByTag(userID int64) ([]models.TagSpend, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, baseArgs := userIDsInClause(ids)
+	// clause appears twice (tag subquery + JOIN)
+	args := repeatArgs(baseArgs, 2)
+
+	q := `
+		SELECT
+			t.tag,
+			COUNT(DISTINCT t.product_id)  AS product_count,
+			COUNT(pr.id)                  AS purchase_count,
+			COALESCE(SUM(pr.price * pr.quantity), 0) AS total_spent
+		FROM (SELECT DISTINCT product_id, tag FROM product_tags WHERE ` + clause + `) t
+		LEFT JOIN price_records pr ON pr.product_id = t.product_id AND pr.anomaly IS NULL AND pr.` + clause + `
+		GROUP BY t.tag
+		ORDER BY total_spent DESC, t.tag ASC
+	`
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get spend by tag: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.TagSpend
+	for rows.Next() {
+		var r models.TagSpend
+		if err := rows.Scan(&r.Tag, &r.ProductCount, &r.PurchaseCount, &r.TotalSpent); err != nil {
+			return nil, fmt.Errorf("scan spend by tag: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate spend by tag: %w", err)
+	}
+	if results == nil {
+		results = []models.TagSpend{}
+	}
+	return results, nil
+}
@@ -0,0 +1,232 @@
+package store_test
+
+import (
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+// ---------- Tags ----------
+
+func TestAddProductTag_ListedAlphabetically(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, sampleProduct("leche-entera-hacendado-1l"))
+
+	for _, tag := range []string{"desayuno", "bebé", "desayuno"} {
+		if err := s.AddProductTag(uid, "leche-entera-hacendado-1l", tag); err != nil {
+			t.Fatalf("AddProductTag(%q): %v", tag, err)
+		}
+	}
+
+	tags, err := s.GetProductTags(uid, "leche-entera-hacendado-1l")
+	if err != nil {
+		t.Fatalf("GetProductTags: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "bebé" || tags[1] != "desayuno" {
+		t.Errorf("want [bebé desayuno], got %v", tags)
+	}
+
+	p, err := s.GetProductByID(uid, "leche-entera-hacendado-1l")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if len(p.Tags) != 2 {
+		t.Errorf("want 2 tags on product, got %v", p.Tags)
+	}
+}
+
+func TestRemoveProductTag_RemovesForWholeHousehold(t *testing.T) {
+	s := newTestStore(t)
+	uid1 := createTestUser2(t, s, "alice")
+	uid2 := createTestUser2(t, s, "bob")
+	hid, err := s.CreateHousehold(uid1)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := s.AddUserToHousehold(uid2, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+	insertProductForUser(t, s, uid1, sampleProduct("leche-entera-hacendado-1l"))
+
+	if err := s.AddProductTag(uid1, "leche-entera-hacendado-1l", "desayuno"); err != nil {
+		t.Fatalf("AddProductTag: %v", err)
+	}
+	// Bob sees Alice's tag, and re-adding it does not duplicate it.
+	if err := s.AddProductTag(uid2, "leche-entera-hacendado-1l", "desayuno"); err != nil {
+		t.Fatalf("AddProductTag (bob): %v", err)
+	}
+	tags, err := s.GetProductTags(uid2, "leche-entera-hacendado-1l")
+	if err != nil {
+		t.Fatalf("GetProductTags: %v", err)
+	}
+	if len(tags) != 1 || tags[0] != "desayuno" {
+		t.Fatalf("want [desayuno] for bob, got %v", tags)
+	}
+
+	if err := s.RemoveProductTag(uid2, "leche-entera-hacendado-1l", "desayuno"); err != nil {
+		t.Fatalf("RemoveProductTag: %v", err)
+	}
+	tags, err = s.GetProductTags(uid1, "leche-entera-hacendado-1l")
+	if err != nil {
+		t.Fatalf("GetProductTags: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("want no tags after removal, got %v", tags)
+	}
+}
+
+func TestGetProductTags_NotSharedAcrossHouseholds(t *testing.T) {
+	s := newTestStore(t)
+	uid1 := createTestUser2(t, s, "alice")
+	uid2 := createTestUser2(t, s, "bob")
+	insertProductForUser(t, s, uid1, sampleProduct("leche-entera-hacendado-1l"))
+	insertProductForUser(t, s, uid2, sampleProduct("leche-entera-hacendado-1l"))
+
+	if err := s.AddProductTag(uid1, "leche-entera-hacendado-1l", "desayuno"); err != nil {
+		t.Fatalf("AddProductTag: %v", err)
+	}
+	tags, err := s.GetProductTags(uid2, "leche-entera-hacendado-1l")
+	if err != nil {
+		t.Fatalf("GetProductTags: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("bob must not see alice's tags, got %v", tags)
+	}
+}
+
+// ---------- Favourites ----------
+
+func TestSetProductFavourite_Toggle(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, sampleProduct("leche-entera-hacendado-1l"))
+
+	if err := s.SetProductFavourite(uid, "leche-entera-hacendado-1l", true); err != nil {
+		t.Fatalf("SetProductFavourite(true): %v", err)
+	}
+	// Starring twice is idempotent.
+	if err := s.SetProductFavourite(uid, "leche-entera-hacendado-1l", true); err != nil {
+		t.Fatalf("SetProductFavourite(true) again: %v", err)
+	}
+	p, err := s.GetProductByID(uid, "leche-entera-hacendado-1l")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if !p.Favourite {
+		t.Error("expected product to be favourite")
+	}
+
+	if err := s.SetProductFavourite(uid, "leche-entera-hacendado-1l", false); err != nil {
+		t.Fatalf("SetProductFavourite(false): %v", err)
+	}
+	p, err = s.GetProductByID(uid, "leche-entera-hacendado-1l")
+	if err != nil {
+		t.Fatalf("GetProductByID: %v", err)
+	}
+	if p.Favourite {
+		t.Error("expected product not to be favourite after unstarring")
+	}
+}
+
+// ---------- SearchProducts filters ----------
+
+func TestSearchProducts_FilterByTagAndFavourites(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	milk := sampleProduct("leche-entera-hacendado-1l")
+	bread := models.Product{
+		Name:         "PAN DE MOLDE",
+		PriceHistory: []models.PriceRecord{{Date: date(2025, 3, 1), Price: 1.20, Store: "Mercadona"}},
+	}
+	insertProductForUser(t, s, uid, milk)
+	insertProductForUser(t, s, uid, bread)
+
+	if err := s.AddProductTag(uid, "leche-entera-hacendado-1l", "desayuno"); err != nil {
+		t.Fatalf("AddProductTag: %v", err)
+	}
+	if err := s.AddProductTag(uid, "pan-de-molde", "desayuno"); err != nil {
+		t.Fatalf("AddProductTag: %v", err)
+	}
+	if err := s.AddProductTag(uid, "pan-de-molde", "bocadillos"); err != nil {
+		t.Fatalf("AddProductTag: %v", err)
+	}
+	if err := s.SetProductFavourite(uid, "pan-de-molde", true); err != nil {
+		t.Fatalf("SetProductFavourite: %v", err)
+	}
+
+	byTag, err := s.SearchProducts(uid, store.SearchOptions{Tag: "desayuno"})
+	if err != nil {
+		t.Fatalf("SearchProducts(tag): %v", err)
+	}
+	if len(byTag) != 2 {
+		t.Errorf("want 2 products tagged desayuno, got %d", len(byTag))
+	}
+
+	favs, err := s.SearchProducts(uid, store.SearchOptions{FavouritesOnly: true})
+	if err != nil {
+		t.Fatalf("SearchProducts(favourites): %v", err)
+	}
+	if len(favs) != 1 || favs[0].ID != "pan-de-molde" {
+		t.Fatalf("want only pan-de-molde, got %+v", favs)
+	}
+	if !favs[0].Favourite {
+		t.Error("expected favourite flag on search result")
+	}
+	if len(favs[0].Tags) != 2 || favs[0].Tags[0] != "bocadillos" {
+		t.Errorf("want tags [bocadillos desayuno], got %v", favs[0].Tags)
+	}
+}
+
+// ---------- GetSpendByTag ----------
+
+func TestGetSpendByTag_SumsPurchasesPerTag(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, sampleProduct("leche-entera-hacendado-1l")) // 0.79 + 0.85 + 0.89
+	bread := models.Product{
+		Name:         "PAN DE MOLDE",
+		PriceHistory: []models.PriceRecord{{Date: date(2025, 3, 1), Price: 1.20, Store: "Mercadona"}},
+	}
+	insertProductForUser(t, s, uid, bread)
+
+	for _, tc := range []struct{ id, tag string }{
+		{"leche-entera-hacendado-1l", "desayuno"},
+		{"pan-de-molde", "desayuno"},
+		{"pan-de-molde", "bocadillos"},
+	} {
+		if err := s.AddProductTag(uid, tc.id, tc.tag); err != nil {
+			t.Fatalf("AddProductTag: %v", err)
+		}
+	}
+
+	got, err := s.GetSpendByTag(uid)
+	if err != nil {
+		t.Fatalf("GetSpendByTag: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 tags, got %+v", got)
+	}
+	if got[0].Tag != "desayuno" || got[0].ProductCount != 2 || got[0].PurchaseCount != 4 {
+		t.Errorf("unexpected desayuno row: %+v", got[0])
+	}
+	if want := 0.79 + 0.85 + 0.89 + 1.20; got[0].TotalSpent < want-0.001 || got[0].TotalSpent > want+0.001 {
+		t.Errorf("desayuno total: want %.2f, got %.2f", want, got[0].TotalSpent)
+	}
+	if got[1].Tag != "bocadillos" || got[1].PurchaseCount != 1 {
+		t.Errorf("unexpected bocadillos row: %+v", got[1])
+	}
+}
+
+func TestGetSpendByTag_NoTags_ReturnsEmptySlice(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	got, err := s.GetSpendByTag(uid)
+	if err != nil {
+		t.Fatalf("GetSpendByTag: %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("want empty non-nil slice, got %#v", got)
+	}
+}
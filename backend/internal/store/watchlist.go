@@ -0,0 +1,360 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"basket-cost/internal/models"
+)
+
+// ---------- Watchlist and alerts ----------
+
+// ErrInvalidWatch is returned (wrapped) by AddWatch when the rule is unknown
+// or its threshold is missing or out of range.
+var ErrInvalidWatch = errors.New("invalid watch rule")
+
+// WatchRule names the condition under which a watched product raises an alert.
+type WatchRule string
+
+const (
+	// RuleAnyIncrease fires whenever a purchase costs more than the previous one.
+	RuleAnyIncrease WatchRule = "any_increase"
+	// RulePriceAbove fires when the price rises above the threshold (€).
+	RulePriceAbove WatchRule = "price_above"
+	// RulePriceBelow fires when the price drops below the threshold (€).
+	RulePriceBelow WatchRule = "price_below"
+	// RuleIncreasePercent fires when the price rises by more than the
+	// threshold (%) over the previous purchase.
+	RuleIncreasePercent WatchRule = "increase_percent"
+)
+
+// validate checks that threshold suits the rule.
+func (r WatchRule) validate(threshold *float64) error {
+	switch r {
+	case RuleAnyIncrease:
+		if threshold != nil {
+			return fmt.Errorf("%w: %s takes no threshold", ErrInvalidWatch, r)
+		}
+	case RulePriceAbove, RulePriceBelow, RuleIncreasePercent:
+		if threshold == nil || *threshold <= 0 {
+			return fmt.Errorf("%w: %s needs a positive threshold", ErrInvalidWatch, r)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rule %q", ErrInvalidWatch, r)
+	}
+	return nil
+}
+
+// fires reports whether a purchase at newPrice, following one at oldPrice,
+// triggers the rule.
+func (r WatchRule) fires(threshold sql.NullFloat64, oldPrice, newPrice float64) bool {
+	switch r {
+	case RuleAnyIncrease:
+		return newPrice > oldPrice && !samePrice(newPrice, oldPrice)
+	case RulePriceAbove:
+		return oldPrice <= threshold.Float64 && newPrice > threshold.Float64
+	case RulePriceBelow:
+		return oldPrice >= threshold.Float64 && newPrice < threshold.Float64
+	case RuleIncreasePercent:
+		return oldPrice > 0 && (newPrice-oldPrice)/oldPrice*100 > threshold.Float64
+	}
+	return false
+}
+
+// AddWatch puts productID on userID's watchlist with the given rule. Adding a
+// rule the user already has on the product replaces its threshold.
+func (s *SQLiteStore) AddWatch(userID int64, productID string, rule WatchRule, threshold *float64) (*models.Watch, error) {
+	if err := rule.validate(threshold); err != nil {
+		return nil, err
+	}
+	var id int64
+	err := s.db.QueryRow(`
+		INSERT INTO watchlist (user_id, product_id, rule, threshold, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, product_id, rule) DO UPDATE SET threshold = excluded.threshold
+		RETURNING id
+	`, userID, productID, string(rule), threshold, time.Now().UTC().Format(time.RFC3339)).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("add watch on product %s: %w", productID, err)
+	}
+	watches, err := s.listWatches(userID, `AND w.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(watches) == 0 {
+		return nil, fmt.Errorf("add watch on product %s: row %d vanished", productID, id)
+	}
+	return &watches[0], nil
+}
+
+// GetWatchlist returns userID's watchlist rules, newest first. CurrentPrice
+// is the latest price paid by userID's household.
+func (s *SQLiteStore) GetWatchlist(userID int64) ([]models.Watch, error) {
+	return s.listWatches(userID, "")
+}
+
+// listWatches returns userID's watches matching the extra condition cond.
+func (s *SQLiteStore) listWatches(userID int64, cond string, condArgs ...any) ([]models.Watch, error) {
+	ids, err := s.householdUserIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve household: %w", err)
+	}
+	clause, clauseArgs := userIDsInClause(ids)
+	args := append(append([]any{}, clauseArgs...), userID)
+	args = append(args, condArgs...)
+
+	rows, err := s.db.Query(`
+		SELECT w.id, w.product_id, p.name, w.rule, w.threshold, w.created_at,
+		       COALESCE((SELECT price FROM price_records
+		                 WHERE product_id = w.product_id AND anomaly IS NULL AND `+clause+`
+		                 ORDER BY date DESC, id DESC LIMIT 1), 0)
+		FROM watchlist w
+		JOIN products p ON p.id = w.product_id
+		WHERE w.user_id = ? `+cond+`
+		ORDER BY w.id DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get watchlist: %w", err)
+	}
+	defer rows.Close()
+
+	watches := []models.Watch{}
+	for rows.Next() {
+		var w models.Watch
+		var threshold sql.NullFloat64
+		var createdAt string
+		if err := rows.Scan(&w.ID, &w.ProductID, &w.ProductName, &w.Rule, &threshold, &createdAt, &w.CurrentPrice); err != nil {
+			return nil, fmt.Errorf("scan watch: %w", err)
+		}
+		if threshold.Valid {
+			w.Threshold = &threshold.Float64
+		}
+		if w.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("parse watch created_at: %w", err)
+		}
+		watches = append(watches, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate watchlist: %w", err)
+	}
+	return watches, nil
+}
+
+// DeleteWatch removes watchID from userID's watchlist. Returns false when the
+// rule does not exist or belongs to someone else.
+func (s *SQLiteStore) DeleteWatch(userID, watchID int64) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM watchlist WHERE id = ? AND user_id = ?`, watchID, userID)
+	if err != nil {
+		return false, fmt.Errorf("delete watch %d: %w", watchID, err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// AlertListener is called with each alert raised by a price import, after the
+// import has been committed. userID is the owner of the alert.
+type AlertListener func(userID int64, alert models.Alert)
+
+// SetAlertListener registers fn to be told about new watchlist and budget
+// alerts, e.g. to notify their owners. Pass nil to stop listening.
+func (s *SQLiteStore) SetAlertListener(fn AlertListener) {
+	s.alertListener = fn
+}
+
+// firedAlert is an alert raised inside an import transaction, reported to
+// the AlertListener once the transaction commits.
+type firedAlert struct {
+	userID int64
+	alert  models.Alert
+}
+
+// evaluateWatches raises alerts for the watchlist rules of memberIDs on
+// productID that the newly inserted price record recordID triggers, and
+// returns them. It runs inside the import transaction so that earlier records
+// of the same batch count as the previous purchase. Records older than the
+// household's latest purchase of the product (back-filled history) raise no
+// alerts.
+func evaluateWatches(tx *sql.Tx, memberIDs []int64, productID string, recordID int64, rec models.PriceRecord) ([]firedAlert, error) {
+	clause, clauseArgs := userIDsInClause(memberIDs)
+	rows, err := tx.Query(
+		`SELECT id, user_id, rule, threshold FROM watchlist WHERE product_id = ? AND `+clause+` ORDER BY id`,
+		append([]any{productID}, clauseArgs...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get watches for product %s: %w", productID, err)
+	}
+	type watch struct {
+		id, userID int64
+		rule       WatchRule
+		threshold  sql.NullFloat64
+	}
+	var watches []watch
+	for rows.Next() {
+		var w watch
+		if err := rows.Scan(&w.id, &w.userID, &w.rule, &w.threshold); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan watch: %w", err)
+		}
+		watches = append(watches, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate watches: %w", err)
+	}
+	if len(watches) == 0 {
+		return nil, nil
+	}
+
+	day := rec.Date.Format(time.DateOnly)
+	scoped := append([]any{productID}, clauseArgs...)
+	var newer bool
+	if err := tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM price_records WHERE product_id = ? AND `+clause+` AND anomaly IS NULL AND date > ?)`,
+		append(scoped, day)...,
+	).Scan(&newer); err != nil {
+		return nil, fmt.Errorf("check newer records for product %s: %w", productID, err)
+	}
+	if newer {
+		return nil, nil
+	}
+	var oldPrice float64
+	err = tx.QueryRow(
+		`SELECT price FROM price_records
+		 WHERE product_id = ? AND `+clause+` AND anomaly IS NULL AND (date < ? OR (date = ? AND id < ?))
+		 ORDER BY date DESC, id DESC LIMIT 1`,
+		append(scoped, day, day, recordID)...,
+	).Scan(&oldPrice)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get previous price for product %s: %w", productID, err)
+	}
+
+	var productName string
+	if err := tx.QueryRow(`SELECT name FROM products WHERE id = ?`, productID).Scan(&productName); err != nil {
+		return nil, fmt.Errorf("get name of product %s: %w", productID, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	var fired []firedAlert
+	for _, w := range watches {
+		if !w.rule.fires(w.threshold, oldPrice, rec.Price) {
+			continue
+		}
+		res, err := tx.Exec(`
+			INSERT INTO alerts (user_id, kind, product_id, watch_id, old_price, new_price, threshold, date, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, w.userID, string(w.rule), productID, w.id, oldPrice, rec.Price, w.threshold, day, now.Format(time.RFC3339))
+		if err != nil {
+			return nil, fmt.Errorf("insert alert for watch %d: %w", w.id, err)
+		}
+		a := models.Alert{
+			Kind: string(w.rule), ProductID: productID, ProductName: productName,
+			OldPrice: &oldPrice, NewPrice: &rec.Price, Date: day, CreatedAt: now,
+		}
+		if a.ID, err = res.LastInsertId(); err != nil {
+			return nil, fmt.Errorf("get last insert id: %w", err)
+		}
+		if w.threshold.Valid {
+			a.Threshold = &w.threshold.Float64
+		}
+		if oldPrice > 0 {
+			pct := roundCents((rec.Price - oldPrice) / oldPrice * 100)
+			a.ChangePercent = &pct
+		}
+		fired = append(fired, firedAlert{userID: w.userID, alert: a})
+	}
+	return fired, nil
+}
+
+// GetAlerts returns userID's alerts, newest first, and the number of unread
+// ones. With unreadOnly only unread alerts are listed; limit <= 0 returns all.
+func (s *SQLiteStore) GetAlerts(userID int64, unreadOnly bool, limit int) (models.AlertInbox, error) {
+	inbox := models.AlertInbox{Alerts: []models.Alert{}}
+	if err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM alerts WHERE user_id = ? AND read_at IS NULL`, userID,
+	).Scan(&inbox.Unread); err != nil {
+		return inbox, fmt.Errorf("count unread alerts: %w", err)
+	}
+
+	q := `
+		SELECT a.id, a.kind, COALESCE(a.product_id, ''), COALESCE(p.name, ''),
+		       a.old_price, a.new_price, a.threshold, a.budget_id, COALESCE(a.category, ''), a.spent,
+		       COALESCE(a.date, ''),
+		       a.created_at, a.read_at IS NOT NULL
+		FROM alerts a
+		LEFT JOIN products p ON p.id = a.product_id
+		WHERE a.user_id = ?`
+	if unreadOnly {
+		q += ` AND a.read_at IS NULL`
+	}
+	q += ` ORDER BY a.id DESC LIMIT ?`
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(q, userID, limit)
+	if err != nil {
+		return inbox, fmt.Errorf("get alerts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Alert
+		var oldPrice, newPrice, threshold, spent sql.NullFloat64
+		var budgetID sql.NullInt64
+		var createdAt string
+		if err := rows.Scan(&a.ID, &a.Kind, &a.ProductID, &a.ProductName,
+			&oldPrice, &newPrice, &threshold, &budgetID, &a.Category, &spent,
+			&a.Date, &createdAt, &a.Read); err != nil {
+			return inbox, fmt.Errorf("scan alert: %w", err)
+		}
+		if oldPrice.Valid {
+			a.OldPrice = &oldPrice.Float64
+		}
+		if newPrice.Valid {
+			a.NewPrice = &newPrice.Float64
+		}
+		if threshold.Valid {
+			a.Threshold = &threshold.Float64
+		}
+		if budgetID.Valid {
+			a.BudgetID = &budgetID.Int64
+		}
+		if spent.Valid {
+			a.Spent = &spent.Float64
+		}
+		if oldPrice.Valid && newPrice.Valid && oldPrice.Float64 > 0 {
+			pct := roundCents((newPrice.Float64 - oldPrice.Float64) / oldPrice.Float64 * 100)
+			a.ChangePercent = &pct
+		}
+		if a.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return inbox, fmt.Errorf("parse alert created_at: %w", err)
+		}
+		inbox.Alerts = append(inbox.Alerts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return inbox, fmt.Errorf("iterate alerts: %w", err)
+	}
+	return inbox, nil
+}
+
+// MarkAlertsRead marks the given alerts of userID as read, or all of them
+// when alertIDs is empty. IDs belonging to other users are ignored.
+func (s *SQLiteStore) MarkAlertsRead(userID int64, alertIDs []int64) error {
+	q := `UPDATE alerts SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+	args := []any{time.Now().UTC().Format(time.RFC3339), userID}
+	if len(alertIDs) > 0 {
+		q += ` AND id IN (?` + strings.Repeat(", ?", len(alertIDs)-1) + `)`
+		for _, id := range alertIDs {
+			args = append(args, id)
+		}
+	}
+	if _, err := s.db.Exec(q, args...); err != nil {
+		return fmt.Errorf("mark alerts read: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package store_test
+
+import (
+	"errors"
+	"testing"
+
+	"basket-cost/internal/models"
+	"basket-cost/internal/store"
+)
+
+func importRecords(t *testing.T, s *store.SQLiteStore, uid int64, name string, recs ...models.PriceRecord) {
+	t.Helper()
+	entries := make([]models.PriceRecordEntry, len(recs))
+	for i, r := range recs {
+		entries[i] = models.PriceRecordEntry{Name: name, Record: r}
+	}
+	if err := s.UpsertPriceRecordBatch(uid, entries); err != nil {
+		t.Fatalf("UpsertPriceRecordBatch: %v", err)
+	}
+}
+
+func TestAddWatch_ValidatesRuleAndThreshold(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+	}})
+
+	two, zero := 2.0, 0.0
+	tests := []struct {
+		name      string
+		rule      store.WatchRule
+		threshold *float64
+	}{
+		{"unknown rule", "cheaper", nil},
+		{"any increase with threshold", store.RuleAnyIncrease, &two},
+		{"price above without threshold", store.RulePriceAbove, nil},
+		{"zero percent", store.RuleIncreasePercent, &zero},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.AddWatch(uid, "leche", tt.rule, tt.threshold)
+			if !errors.Is(err, store.ErrInvalidWatch) {
+				t.Errorf("want ErrInvalidWatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWatchlist_AddListDelete(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	other := createTestUser2(t, s, "other")
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+		{Date: date(2025, 2, 10), Price: 1.05, Store: "Mercadona"},
+	}})
+
+	threshold := 1.2
+	w, err := s.AddWatch(uid, "leche", store.RulePriceAbove, &threshold)
+	if err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+	if w.ProductName != "LECHE" || w.CurrentPrice != 1.05 || *w.Threshold != 1.2 {
+		t.Errorf("unexpected watch %+v", w)
+	}
+
+	// Re-adding the same rule replaces the threshold instead of duplicating it.
+	threshold = 1.5
+	again, err := s.AddWatch(uid, "leche", store.RulePriceAbove, &threshold)
+	if err != nil {
+		t.Fatalf("AddWatch again: %v", err)
+	}
+	if again.ID != w.ID || *again.Threshold != 1.5 {
+		t.Errorf("want watch %d updated to 1.5, got %+v", w.ID, again)
+	}
+	if _, err := s.AddWatch(uid, "leche", store.RuleAnyIncrease, nil); err != nil {
+		t.Fatalf("AddWatch any_increase: %v", err)
+	}
+
+	list, err := s.GetWatchlist(uid)
+	if err != nil {
+		t.Fatalf("GetWatchlist: %v", err)
+	}
+	if len(list) != 2 || list[0].Rule != string(store.RuleAnyIncrease) {
+		t.Fatalf("want 2 watches newest first, got %+v", list)
+	}
+
+	if ok, err := s.DeleteWatch(other, w.ID); err != nil || ok {
+		t.Errorf("other user deleting: want false, got %v, %v", ok, err)
+	}
+	if ok, err := s.DeleteWatch(uid, w.ID); err != nil || !ok {
+		t.Errorf("DeleteWatch: want true, got %v, %v", ok, err)
+	}
+	list, _ = s.GetWatchlist(uid)
+	if len(list) != 1 {
+		t.Errorf("want 1 watch left, got %+v", list)
+	}
+}
+
+func TestUpsertPriceRecordBatch_FiresWatchRules(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+	}})
+	above, below, pct := 1.05, 0.95, 8.0
+	for _, w := range []struct {
+		rule      store.WatchRule
+		threshold *float64
+	}{
+		{store.RuleAnyIncrease, nil},
+		{store.RulePriceAbove, &above},
+		{store.RulePriceBelow, &below},
+		{store.RuleIncreasePercent, &pct},
+	} {
+		if _, err := s.AddWatch(uid, "leche", w.rule, w.threshold); err != nil {
+			t.Fatalf("AddWatch %s: %v", w.rule, err)
+		}
+	}
+
+	// 1.00 → 1.10 (+10%) crosses 1.05; then 1.10 → 0.90 crosses 0.95.
+	importRecords(t, s, uid, "LECHE",
+		models.PriceRecord{Date: date(2025, 2, 10), Price: 1.10, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 3, 10), Price: 0.90, Store: "Mercadona"},
+	)
+
+	inbox, err := s.GetAlerts(uid, false, 0)
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	var kinds []string
+	for _, a := range inbox.Alerts {
+		kinds = append(kinds, a.Kind)
+	}
+	want := []string{"price_below", "increase_percent", "price_above", "any_increase"}
+	if len(kinds) != len(want) {
+		t.Fatalf("want alerts %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("alert %d: want %s, got %s", i, want[i], kinds[i])
+		}
+	}
+	if inbox.Unread != 4 {
+		t.Errorf("want 4 unread, got %d", inbox.Unread)
+	}
+	a := inbox.Alerts[1]
+	if a.ProductName != "LECHE" || *a.OldPrice != 1.00 || *a.NewPrice != 1.10 || *a.ChangePercent != 10 || a.Date != "2025-02-10" {
+		t.Errorf("unexpected increase_percent alert %+v", a)
+	}
+}
+
+func TestUpsertPriceRecordBatch_BackfilledHistoryRaisesNoAlerts(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 3, 10), Price: 1.00, Store: "Mercadona"},
+	}})
+	if _, err := s.AddWatch(uid, "leche", store.RuleAnyIncrease, nil); err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+
+	importRecords(t, s, uid, "LECHE",
+		models.PriceRecord{Date: date(2025, 1, 10), Price: 0.80, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 2, 10), Price: 0.90, Store: "Mercadona"},
+	)
+
+	inbox, err := s.GetAlerts(uid, false, 0)
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	if len(inbox.Alerts) != 0 {
+		t.Errorf("want no alerts for older purchases, got %+v", inbox.Alerts)
+	}
+}
+
+func TestUpsertPriceRecordBatch_HouseholdMemberImportFiresWatch(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	partner := createTestUser2(t, s, "partner")
+	hid, err := s.CreateHousehold(uid)
+	if err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := s.AddUserToHousehold(partner, hid); err != nil {
+		t.Fatalf("AddUserToHousehold: %v", err)
+	}
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+	}})
+	if _, err := s.AddWatch(uid, "leche", store.RuleAnyIncrease, nil); err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+
+	importRecords(t, s, partner, "LECHE", models.PriceRecord{Date: date(2025, 2, 10), Price: 1.20, Store: "Mercadona"})
+
+	inbox, err := s.GetAlerts(uid, false, 0)
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	if len(inbox.Alerts) != 1 || *inbox.Alerts[0].OldPrice != 1.00 {
+		t.Fatalf("want one alert from the partner's import, got %+v", inbox.Alerts)
+	}
+	// The alert belongs to the watch owner only.
+	if other, _ := s.GetAlerts(partner, false, 0); len(other.Alerts) != 0 {
+		t.Errorf("partner should have no alerts, got %+v", other.Alerts)
+	}
+}
+
+func TestUpsertPriceRecordBatch_ReportsAlertsToListener(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+	}})
+	if _, err := s.AddWatch(uid, "leche", store.RuleAnyIncrease, nil); err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+	type heard struct {
+		userID int64
+		alert  models.Alert
+	}
+	var got []heard
+	s.SetAlertListener(func(userID int64, a models.Alert) { got = append(got, heard{userID, a}) })
+
+	importRecords(t, s, uid, "LECHE", models.PriceRecord{Date: date(2025, 2, 10), Price: 1.10, Store: "Mercadona"})
+
+	if len(got) != 1 {
+		t.Fatalf("want 1 alert reported, got %+v", got)
+	}
+	inbox, _ := s.GetAlerts(uid, false, 0)
+	a := got[0].alert
+	if got[0].userID != uid || a.ID != inbox.Alerts[0].ID || a.ProductName != "LECHE" ||
+		*a.NewPrice != 1.10 || *a.ChangePercent != 10 {
+		t.Errorf("unexpected reported alert %+v for user %d", a, got[0].userID)
+	}
+}
+
+func TestMarkAlertsRead(t *testing.T) {
+	s := newTestStore(t)
+	uid := createTestUser(t, s)
+	insertProductForUser(t, s, uid, models.Product{Name: "LECHE", PriceHistory: []models.PriceRecord{
+		{Date: date(2025, 1, 10), Price: 1.00, Store: "Mercadona"},
+	}})
+	if _, err := s.AddWatch(uid, "leche", store.RuleAnyIncrease, nil); err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+	importRecords(t, s, uid, "LECHE",
+		models.PriceRecord{Date: date(2025, 2, 10), Price: 1.10, Store: "Mercadona"},
+		models.PriceRecord{Date: date(2025, 3, 10), Price: 1.20, Store: "Mercadona"},
+	)
+	inbox, _ := s.GetAlerts(uid, false, 0)
+	if len(inbox.Alerts) != 2 {
+		t.Fatalf("want 2 alerts, got %+v", inbox.Alerts)
+	}
+
+	if err := s.MarkAlertsRead(uid, []int64{inbox.Alerts[0].ID}); err != nil {
+		t.Fatalf("MarkAlertsRead: %v", err)
+	}
+	unread, _ := s.GetAlerts(uid, true, 0)
+	if unread.Unread != 1 || len(unread.Alerts) != 1 || unread.Alerts[0].ID != inbox.Alerts[1].ID {
+		t.Errorf("want the older alert unread, got %+v", unread)
+	}
+
+	if err := s.MarkAlertsRead(uid, nil); err != nil {
+		t.Fatalf("MarkAlertsRead all: %v", err)
+	}
+	all, _ := s.GetAlerts(uid, false, 0)
+	if all.Unread != 0 || len(all.Alerts) != 2 || !all.Alerts[1].Read {
+		t.Errorf("want all alerts read, got %+v", all)
+	}
+}
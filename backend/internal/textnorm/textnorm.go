@@ -0,0 +1,63 @@
+// Package textnorm normalises Spanish/Catalan product names so that the
+// enricher's catalogue matching and the store's full-text search index agree
+// on what a "word" is.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalise converts a product name to a lowercase, ASCII-only, whitespace-
+// collapsed string.
+// Accented characters are mapped to their base ASCII equivalent where possible.
+// Non-letter, non-digit characters (including punctuation and apostrophes) act
+// as word separators so that e.g. "d'Embolicar" → "d embolicar".
+func Normalise(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	prevSpace := true // treat start-of-string as a boundary
+
+	for _, r := range s {
+		// Map accented → base ASCII first.
+		r = Deaccent(r)
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+			prevSpace = false
+		} else {
+			// Spaces, punctuation, apostrophes, etc. all become a single space separator.
+			if !prevSpace {
+				b.WriteByte(' ')
+				prevSpace = true
+			}
+		}
+	}
+
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Deaccent maps accented characters commonly found in Spanish/Catalan product
+// names to their unaccented ASCII equivalents.
+func Deaccent(r rune) rune {
+	switch r {
+	case 'à', 'á', 'â', 'ã', 'ä', 'å', 'À', 'Á', 'Â', 'Ã', 'Ä', 'Å':
+		return 'a'
+	case 'è', 'é', 'ê', 'ë', 'È', 'É', 'Ê', 'Ë':
+		return 'e'
+	case 'ì', 'í', 'î', 'ï', 'Ì', 'Í', 'Î', 'Ï':
+		return 'i'
+	case 'ò', 'ó', 'ô', 'õ', 'ö', 'Ò', 'Ó', 'Ô', 'Õ', 'Ö':
+		return 'o'
+	case 'ù', 'ú', 'û', 'ü', 'Ù', 'Ú', 'Û', 'Ü':
+		return 'u'
+	case 'ñ', 'Ñ':
+		return 'n'
+	case 'ç', 'Ç':
+		return 'c'
+	case 'ł', 'Ł':
+		return 'l'
+	default:
+		return r
+	}
+}
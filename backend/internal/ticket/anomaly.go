@@ -0,0 +1,48 @@
+package ticket
+
+import (
+	"math"
+
+	"basket-cost/internal/models"
+)
+
+// Review flags set by the Importer on lines whose price looks wrong.
+const (
+	// AnomalyOutlier is a price far from what the household usually pays for
+	// the product: a misplaced decimal separator or a mis-keyed entry.
+	AnomalyOutlier = "outlier"
+	// AnomalyLineTotal is a multi-unit line whose price matches the usual
+	// price times the quantity: the line total (or a weight total) was read
+	// as the unit price.
+	AnomalyLineTotal = "line_total"
+)
+
+const (
+	// anomalyMinPurchases is the history needed before a product's prices
+	// are checked; fewer purchases give no reliable median.
+	anomalyMinPurchases = 3
+	// anomalyRatio is how many times above or below the median a price must
+	// be to count as an outlier.
+	anomalyRatio = 5.0
+	// lineTotalTolerance is the relative gap allowed between a price and the
+	// median times the quantity for it to count as a line total.
+	lineTotalTolerance = 0.05
+)
+
+// detectAnomaly returns the review flag for line given the household's
+// baseline for the product, or "" when the price looks plausible.
+func detectAnomaly(line TicketLine, b models.PriceBaseline) string {
+	if b.Purchases < anomalyMinPurchases || b.Median <= 0 {
+		return ""
+	}
+	if line.Quantity > 1 {
+		total := b.Median * float64(line.Quantity)
+		if math.Abs(line.UnitPrice-total) <= total*lineTotalTolerance {
+			return AnomalyLineTotal
+		}
+	}
+	if line.UnitPrice >= b.Median*anomalyRatio || line.UnitPrice <= b.Median/anomalyRatio {
+		return AnomalyOutlier
+	}
+	return ""
+}
@@ -3,6 +3,7 @@ package ticket
 import (
 	"fmt"
 	"io"
+	"math"
 
 	"basket-cost/internal/models"
 )
@@ -11,9 +12,13 @@ import (
 // Using a narrow interface keeps the ticket package decoupled from the full
 // store package and makes testing easier.
 type TicketStore interface {
-	// UpsertPriceRecordBatch persists all entries scoped to userID inside a
-	// single transaction. Either every entry is committed or none is.
-	UpsertPriceRecordBatch(userID int64, entries []models.PriceRecordEntry) error
+	// UpsertTicket persists the ticket header and all entries scoped to
+	// userID inside a single transaction. Either everything is committed or
+	// nothing is.
+	UpsertTicket(userID int64, header models.TicketHeader, entries []models.PriceRecordEntry) error
+	// GetPriceBaselines returns the household's baseline price for each of
+	// the named products it has bought before, keyed by name.
+	GetPriceBaselines(userID int64, names []string) (map[string]models.PriceBaseline, error)
 }
 
 // ImportResult summarises the outcome of a single ticket import.
@@ -22,6 +27,11 @@ type ImportResult struct {
 	InvoiceNumber string
 	// LinesImported is the number of product lines successfully persisted.
 	LinesImported int
+	// LinesFlagged is the number of imported lines flagged for review.
+	LinesFlagged int
+	// Total is the ticket total printed on the receipt, or Σ unit price ×
+	// quantity over its lines when the receipt shows none.
+	Total float64
 }
 
 // Importer orchestrates PDF extraction → parsing → persistence.
@@ -41,8 +51,12 @@ func NewImporter(extractor PDFExtractor, parser Parser, store TicketStore) *Impo
 }
 
 // Import reads a PDF from r, parses it as a Mercadona receipt, and persists
-// all product lines atomically inside a single transaction scoped to userID.
+// the ticket with its printed total (recomputed from the lines when the
+// receipt shows none) and all product lines atomically inside a single
+// transaction scoped to userID.
 // If any line fails to persist the entire ticket is rolled back.
+// Lines whose price looks like a parse or keying error, judged against what
+// the household usually pays, are persisted with a review flag.
 // r must implement io.ReaderAt; use bytes.NewReader for in-memory data.
 func (imp *Importer) Import(userID int64, r io.ReaderAt, size int64) (*ImportResult, error) {
 	text, err := imp.extractor.Extract(r, size)
@@ -55,24 +69,54 @@ func (imp *Importer) Import(userID int64, r io.ReaderAt, size int64) (*ImportRes
 		return nil, fmt.Errorf("parse receipt: %w", err)
 	}
 
+	names := make([]string, len(t.Lines))
+	for i, line := range t.Lines {
+		names[i] = line.Name
+	}
+	baselines, err := imp.store.GetPriceBaselines(userID, names)
+	if err != nil {
+		return nil, fmt.Errorf("get price baselines: %w", err)
+	}
+
+	flagged := 0
+	var total float64
 	entries := make([]models.PriceRecordEntry, len(t.Lines))
 	for i, line := range t.Lines {
+		anomaly := detectAnomaly(line, baselines[line.Name])
+		if anomaly != "" {
+			flagged++
+		}
+		total += line.UnitPrice * float64(line.Quantity)
 		entries[i] = models.PriceRecordEntry{
 			Name: line.Name,
 			Record: models.PriceRecord{
-				Date:  t.Date,
-				Price: line.UnitPrice,
-				Store: t.Store,
+				Date:     t.Date,
+				Price:    line.UnitPrice,
+				Quantity: line.Quantity,
+				Store:    t.Store,
+				Anomaly:  anomaly,
 			},
 		}
 	}
 
-	if err := imp.store.UpsertPriceRecordBatch(userID, entries); err != nil {
+	header := models.TicketHeader{
+		InvoiceNumber: t.InvoiceNumber,
+		Store:         t.Store,
+		Date:          t.Date,
+		Total:         t.Total,
+		Lines:         len(entries),
+	}
+	if header.Total == 0 {
+		header.Total = math.Round(total*100) / 100
+	}
+	if err := imp.store.UpsertTicket(userID, header, entries); err != nil {
 		return nil, fmt.Errorf("persist ticket %s: %w", t.InvoiceNumber, err)
 	}
 
 	return &ImportResult{
 		InvoiceNumber: t.InvoiceNumber,
 		LinesImported: len(entries),
+		LinesFlagged:  flagged,
+		Total:         header.Total,
 	}, nil
 }
@@ -37,15 +37,18 @@ func (f *fakeParser) Parse(_ string) (*ticket.Ticket, error) {
 
 // fakeStore implements TicketStore and records all calls.
 type fakeStore struct {
-	records []models.PriceRecord
-	names   []string
-	err     error
+	records   []models.PriceRecord
+	names     []string
+	headers   []models.TicketHeader
+	baselines map[string]models.PriceBaseline
+	err       error
 }
 
-func (f *fakeStore) UpsertPriceRecordBatch(_ int64, entries []models.PriceRecordEntry) error {
+func (f *fakeStore) UpsertTicket(_ int64, header models.TicketHeader, entries []models.PriceRecordEntry) error {
 	if f.err != nil {
 		return f.err
 	}
+	f.headers = append(f.headers, header)
 	for _, e := range entries {
 		f.names = append(f.names, e.Name)
 		f.records = append(f.records, e.Record)
@@ -53,6 +56,10 @@ func (f *fakeStore) UpsertPriceRecordBatch(_ int64, entries []models.PriceRecord
 	return nil
 }
 
+func (f *fakeStore) GetPriceBaselines(_ int64, _ []string) (map[string]models.PriceBaseline, error) {
+	return f.baselines, nil
+}
+
 // --- Helpers ---
 
 func sampleTicket() *ticket.Ticket {
@@ -92,6 +99,49 @@ func TestImporter_Import_Success(t *testing.T) {
 	}
 }
 
+func TestImporter_Import_PersistsTicketTotal(t *testing.T) {
+	store := &fakeStore{}
+	imp := ticket.NewImporter(
+		&fakeExtractor{text: "raw text"},
+		&fakeParser{t: sampleTicket()},
+		store,
+	)
+
+	result, err := imp.Import(testUserID, bytes.NewReader([]byte{}), 0)
+	if err != nil {
+		t.Fatalf("Import returned unexpected error: %v", err)
+	}
+	if len(store.headers) != 1 {
+		t.Fatalf("expected 1 ticket persisted, got %d", len(store.headers))
+	}
+	h := store.headers[0]
+	// No printed total: 0.89 + 2 × 0.35
+	if h.Total != 1.59 || result.Total != 1.59 {
+		t.Errorf("Total: want 1.59, got header %v, result %v", h.Total, result.Total)
+	}
+	if h.Lines != 2 || h.Store != "Mercadona" || h.InvoiceNumber != "4144-017-284404" {
+		t.Errorf("unexpected ticket header %+v", h)
+	}
+	if !h.Date.Equal(sampleTicket().Date) {
+		t.Errorf("Date: want %v, got %v", sampleTicket().Date, h.Date)
+	}
+}
+
+func TestImporter_Import_PrefersPrintedTotal(t *testing.T) {
+	store := &fakeStore{}
+	tk := sampleTicket()
+	tk.Total = 1.60
+	imp := ticket.NewImporter(&fakeExtractor{text: "raw text"}, &fakeParser{t: tk}, store)
+
+	result, err := imp.Import(testUserID, bytes.NewReader([]byte{}), 0)
+	if err != nil {
+		t.Fatalf("Import returned unexpected error: %v", err)
+	}
+	if len(store.headers) != 1 || store.headers[0].Total != 1.60 || result.Total != 1.60 {
+		t.Errorf("want the printed total 1.60, got %+v / %v", store.headers, result.Total)
+	}
+}
+
 func TestImporter_Import_ExtractorError(t *testing.T) {
 	imp := ticket.NewImporter(
 		&fakeExtractor{err: errors.New("pdf corrupt")},
@@ -150,3 +200,70 @@ func TestImporter_Import_PriceRecordDate(t *testing.T) {
 		}
 	}
 }
+
+func TestImporter_Import_PriceRecordQuantity(t *testing.T) {
+	store := &fakeStore{}
+	imp := ticket.NewImporter(
+		&fakeExtractor{text: "text"},
+		&fakeParser{t: sampleTicket()},
+		store,
+	)
+	if _, err := imp.Import(testUserID, bytes.NewReader([]byte{}), 0); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(store.records) != 2 {
+		t.Fatalf("want 2 records, got %d", len(store.records))
+	}
+	if store.records[0].Quantity != 1 || store.records[1].Quantity != 2 {
+		t.Errorf("quantities: want [1 2], got [%d %d]", store.records[0].Quantity, store.records[1].Quantity)
+	}
+}
+
+func TestImporter_Import_FlagsAnomalies(t *testing.T) {
+	tk := sampleTicket()
+	tk.Lines = append(tk.Lines,
+		ticket.TicketLine{Name: "ACEITE OLIVA 1L", UnitPrice: 8.95, Quantity: 1},
+		ticket.TicketLine{Name: "PAN DE MOLDE", UnitPrice: 13.50, Quantity: 1},
+	)
+	store := &fakeStore{baselines: map[string]models.PriceBaseline{
+		// 0.35 × 2 read as the unit price would be 0.70; 0.35 is fine.
+		"YOGUR NATURAL": {Median: 0.35, Purchases: 5},
+		// A tenfold price with only two purchases to compare against.
+		"ACEITE OLIVA 1L": {Median: 0.895, Purchases: 2},
+		// 1.35 → 13.50: a misplaced decimal separator.
+		"PAN DE MOLDE": {Median: 1.35, Purchases: 4},
+	}}
+	imp := ticket.NewImporter(&fakeExtractor{text: "text"}, &fakeParser{t: tk}, store)
+
+	result, err := imp.Import(testUserID, bytes.NewReader([]byte{}), 0)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.LinesImported != 4 || result.LinesFlagged != 1 {
+		t.Errorf("want 4 lines with 1 flagged, got %+v", result)
+	}
+	want := []string{"", "", "", ticket.AnomalyOutlier}
+	for i, rec := range store.records {
+		if rec.Anomaly != want[i] {
+			t.Errorf("line %d (%s): want anomaly %q, got %q", i, store.names[i], want[i], rec.Anomaly)
+		}
+	}
+}
+
+func TestImporter_Import_FlagsLineTotalAsUnitPrice(t *testing.T) {
+	tk := sampleTicket()
+	tk.Lines[1].UnitPrice = 0.71 // 2 × 0.35, give or take a rounding cent
+	store := &fakeStore{baselines: map[string]models.PriceBaseline{
+		"LECHE ENTERA HACENDADO 1L": {Median: 0.89, Purchases: 3},
+		"YOGUR NATURAL":             {Median: 0.35, Purchases: 3},
+	}}
+	imp := ticket.NewImporter(&fakeExtractor{text: "text"}, &fakeParser{t: tk}, store)
+
+	result, err := imp.Import(testUserID, bytes.NewReader([]byte{}), 0)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.LinesFlagged != 1 || store.records[0].Anomaly != "" || store.records[1].Anomaly != ticket.AnomalyLineTotal {
+		t.Errorf("want only the yogurt flagged as a line total, got %+v", store.records)
+	}
+}
@@ -12,6 +12,9 @@ type Ticket struct {
 	Date time.Time
 	// InvoiceNumber is the simplified invoice reference, e.g. "4144-017-284404".
 	InvoiceNumber string
+	// Total is the amount printed after "TOTAL (€)", or 0 when the receipt
+	// shows none.
+	Total float64
 	// Lines contains every product line extracted from the receipt body.
 	Lines []TicketLine
 }
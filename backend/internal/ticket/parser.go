@@ -111,6 +111,7 @@ func (p *MercadonaParser) Parse(text string) (*Ticket, error) {
 	if t.Date.IsZero() {
 		return nil, fmt.Errorf("could not find date in receipt")
 	}
+	t.Total = parseTotal(lines)
 
 	// ── Detect body format ───────────────────────────────────────────────────
 	// If the column header ("Descripció   P. Unit   Import") appears on a
@@ -354,6 +355,35 @@ func splitLines(text string) []string {
 	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
 }
 
+// parseTotal returns the amount printed after the footer sentinel, on the
+// same line (single-line format) or on the next non-empty one (multi-line
+// format). Returns 0 when there is none.
+func parseTotal(lines []string) float64 {
+	for i, line := range lines {
+		loc := reFooter.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		rest := strings.TrimSpace(line[loc[1]:])
+		if rest == "" {
+			for _, next := range lines[i+1:] {
+				if rest = strings.TrimSpace(next); rest != "" {
+					break
+				}
+			}
+		}
+		if !rePrice.MatchString(rest) {
+			return 0
+		}
+		total, err := parsePrice(rest)
+		if err != nil {
+			return 0
+		}
+		return total
+	}
+	return 0
+}
+
 // parsePrice converts a Spanish-locale price string ("1,99") to float64.
 func parsePrice(s string) (float64, error) {
 	normalised := strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
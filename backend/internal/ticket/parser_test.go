@@ -285,3 +285,27 @@ func TestMercadonaParser_MultiLine_MultipleProducts(t *testing.T) {
 		t.Errorf("expected 3 lines, got %d: %+v", len(got.Lines), got.Lines)
 	}
 }
+
+func TestMercadonaParser_Total(t *testing.T) {
+	p := ticket.NewMercadonaParser()
+	for name, text := range map[string]string{
+		"single-line": receipt(""),
+		"multi-line":  receiptMulti(""),
+	} {
+		got, err := p.Parse(text)
+		if err != nil {
+			t.Fatalf("%s: Parse error: %v", name, err)
+		}
+		if got.Total != 9.67 {
+			t.Errorf("%s: Total: want 9.67, got %v", name, got.Total)
+		}
+	}
+
+	got, err := p.Parse("09/02/2026\nDescripció   P. Unit   Import\n1   PAN   1,00")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got.Total != 0 {
+		t.Errorf("Total without footer: want 0, got %v", got.Total)
+	}
+}
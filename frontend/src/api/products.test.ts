@@ -76,7 +76,30 @@ describe('getAllProducts', () => {
 
     await getAllProducts();
     expect(fetchMock).toHaveBeenCalledWith(
-      '/api/products?q=',
+      '/api/products?q=&limit=200',
+      expect.objectContaining({ signal: expect.any(AbortSignal) }),
+    );
+  });
+
+  it('follows X-Next-Cursor until the last page', async () => {
+    const second: SearchResult = { ...mockSearchResults[0], id: '2' };
+    const fetchMock = vi.fn()
+      .mockResolvedValueOnce({
+        ok: true,
+        headers: new Headers({ 'X-Next-Cursor': 'abc' }),
+        json: () => Promise.resolve(mockSearchResults),
+      })
+      .mockResolvedValueOnce({
+        ok: true,
+        headers: new Headers(),
+        json: () => Promise.resolve([second]),
+      });
+    vi.stubGlobal('fetch', fetchMock);
+
+    const results = await getAllProducts();
+    expect(results).toEqual([...mockSearchResults, second]);
+    expect(fetchMock).toHaveBeenLastCalledWith(
+      '/api/products?q=&limit=200&cursor=abc',
       expect.objectContaining({ signal: expect.any(AbortSignal) }),
     );
   });
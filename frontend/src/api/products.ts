@@ -53,8 +53,27 @@ export async function searchProducts(query: string): Promise<SearchResult[]> {
   }
 }
 
+// The server pages search results (50 by default); the catalogue follows
+// X-Next-Cursor through pages of the largest size to load every product.
+const CATALOGUE_PAGE_SIZE = 200;
+
 export async function getAllProducts(): Promise<SearchResult[]> {
-  return searchProducts('');
+  const products: SearchResult[] = [];
+  let cursor: string | null = null;
+  do {
+    const { signal, clear } = withTimeout(READ_TIMEOUT_MS);
+    try {
+      let url = `${API_BASE}/products?q=&limit=${CATALOGUE_PAGE_SIZE}`;
+      if (cursor) url += `&cursor=${encodeURIComponent(cursor)}`;
+      const res = await fetch(url, { signal, headers: authHeaders() });
+      if (!res.ok) throw new Error(`Search failed: ${res.statusText}`);
+      products.push(...((await res.json()) as SearchResult[]));
+      cursor = res.headers?.get('X-Next-Cursor') ?? null;
+    } finally {
+      clear();
+    }
+  } while (cursor);
+  return products;
 }
 
 export async function getProduct(id: string): Promise<Product> {
@@ -2,16 +2,54 @@ export interface PriceRecord {
   recordId?: number; // DB primary key; present for records belonging to the authenticated user
   date: string;
   price: number;
+  quantity?: number;
   store?: string;
+  anomaly?: 'outlier' | 'line_total'; // flagged at import until confirmed
+  boughtBy?: string; // username of the household member who bought it
 }
 
 export interface Product {
   id: string;
   name: string;
   category?: string;
+  categoryLocked?: boolean;
   imageUrl?: string;
   currentPrice: number;
   priceHistory: PriceRecord[];
+  tags?: string[];
+  favourite?: boolean;
+  inflationAdjusted?: InflationAdjustment;
+  forecast?: PriceForecast;
+}
+
+export interface DeflatedPrice {
+  date: string;
+  price: number;
+  realPrice: number; // in constant euros of InflationAdjustment.baseYear
+}
+
+export interface InflationAdjustment {
+  baseYear: number;
+  history: DeflatedPrice[];
+  nominalChangePercent: number;
+  ipcChangePercent: number;
+  realChangePercent: number;
+  verdict: 'faster' | 'slower' | 'in_line';
+}
+
+export interface ForecastPoint {
+  month: string; // YYYY-MM
+  price: number;
+  low: number; // 95% band
+  high: number;
+}
+
+export interface PriceForecast {
+  method: 'trend' | 'ipc' | 'flat';
+  baseDate: string;
+  basePrice: number;
+  annualChangePercent: number;
+  points: ForecastPoint[];
 }
 
 export interface SearchResult {
@@ -22,12 +60,18 @@ export interface SearchResult {
   currentPrice: number;
   minPrice: number;
   maxPrice: number;
+  priceChangePercent?: number;
+  purchaseCount?: number;
   lastPurchaseDate?: string;
+  tags?: string[];
+  favourite?: boolean;
 }
 
 export interface TicketUploadResult {
   invoiceNumber: string;
   linesImported: number;
+  linesFlagged?: number; // lines held for review at /api/review
+  total?: number; // printed on the receipt, else Σ unit price × quantity
 }
 
 export type TicketUploadItem =
@@ -58,9 +102,317 @@ export interface PriceIncreaseProduct {
   increasePercent: number;
 }
 
+export interface PriceDecreaseProduct {
+  id: string;
+  name: string;
+  imageUrl?: string;
+  firstPrice: number;
+  currentPrice: number;
+  decreasePercent: number;
+}
+
+export interface VolatileProduct {
+  id: string;
+  name: string;
+  imageUrl?: string;
+  purchaseCount: number;
+  meanPrice: number;
+  minPrice: number;
+  maxPrice: number;
+  variationPercent: number; // coefficient of variation, %
+}
+
+export interface StablePriceProduct {
+  id: string;
+  name: string;
+  imageUrl?: string;
+  currentPrice: number;
+  stableSince: string;
+  lastPurchaseDate: string;
+  stableDays: number;
+  purchaseCount: number;
+}
+
+export interface PriceChangeEvent {
+  productId: string;
+  name: string;
+  imageUrl?: string;
+  store?: string;
+  previousDate: string;
+  date: string;
+  oldPrice: number;
+  newPrice: number;
+  changePercent: number;
+}
+
+export interface Budget {
+  id: number;
+  category: string;
+  monthlyLimit: number;
+  createdBy: string;
+  updatedAt: string;
+}
+
+export interface BudgetStatus extends Budget {
+  spent: number;
+  remaining: number;
+  usedPercent: number;
+  projectedSpend: number;
+  projectedPercent: number;
+  status: 'ok' | 'warning' | 'exceeded';
+}
+
+export interface BudgetReport {
+  month: string;
+  asOf: string;
+  daysElapsed: number;
+  daysInMonth: number;
+  budgets: BudgetStatus[];
+}
+
+export interface ProductGroupMember {
+  productId: string;
+  name: string;
+  imageUrl?: string;
+  store?: string;
+  latestPrice: number;
+  latestDate?: string;
+}
+
+export interface ProductGroup {
+  id: number;
+  name: string;
+  createdBy: string;
+  createdAt: string;
+  products: ProductGroupMember[];
+}
+
+export interface StorePrice {
+  store: string;
+  productId: string;
+  productName: string;
+  price: number;
+  date: string;
+}
+
+export interface GroupComparison {
+  groupId: number;
+  name: string;
+  prices: StorePrice[];
+  cheapestStore: string;
+  unitSaving: number;
+  basketQuantity: number;
+  basketSaving: number;
+}
+
+export interface StoreBasketCost {
+  store: string;
+  cost: number;
+  pricedItems: number;
+  missingItems: number;
+}
+
+export interface StoreComparison {
+  basketFrom: string;
+  basketTo: string;
+  basketItems: number;
+  groups: GroupComparison[];
+  stores: StoreBasketCost[];
+}
+
+export interface BasketReplayItem {
+  productId: string;
+  name: string;
+  quantity: number;
+  firstPurchase?: string;
+  latestPrice: number;
+}
+
+export interface BasketReplayPoint {
+  period: string;
+  date: string;
+  cost: number;
+  missing?: string[];
+}
+
+export interface BasketReplay {
+  from: string;
+  to: string;
+  granularity: 'day' | 'week' | 'month';
+  items: BasketReplayItem[];
+  changePercent: number | null;
+  points: BasketReplayPoint[];
+}
+
+export interface ShoppingListItem {
+  id: number;
+  productId: string;
+  name: string;
+  imageUrl?: string;
+  quantity: number;
+  checked: boolean;
+  currentPrice: number;
+  store?: string;
+  subtotal: number;
+}
+
+export interface ShoppingList {
+  id: number;
+  name: string;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+  itemCount: number;
+  estimatedTotal: number;
+  remainingTotal: number;
+  items: ShoppingListItem[];
+}
+
+export interface RepurchasePrediction {
+  productId: string;
+  name: string;
+  imageUrl?: string;
+  purchases: number;
+  intervalDays: number;
+  lastPurchase: string;
+  nextPurchase: string;
+  daysUntil: number;
+  status: 'ok' | 'due_soon' | 'overdue';
+}
+
+export interface DigestProduct {
+  productId: string;
+  name: string;
+  price: number;
+  store?: string;
+  date: string;
+}
+
+export interface DigestInflation {
+  from: string;
+  to: string;
+  personalChangePercent: number;
+  ipcChangePercent: number | null;
+}
+
+export interface Digest {
+  period: 'week' | 'month';
+  from: string;
+  to: string;
+  totalSpent: number;
+  previousTotalSpent: number;
+  spendChangePercent: number | null;
+  ticketsImported: number;
+  priceIncreases: number;
+  priceDecreases: number;
+  priceChanges: PriceChangeEvent[];
+  newProducts: DigestProduct[];
+  inflation: DigestInflation;
+}
+
+export type WatchRule = 'any_increase' | 'price_above' | 'price_below' | 'increase_percent';
+
+export interface Watch {
+  id: number;
+  productId: string;
+  productName: string;
+  rule: WatchRule;
+  threshold?: number;
+  currentPrice: number;
+  createdAt: string;
+}
+
+export interface MemberSpend {
+  userId: number;
+  username: string;
+  spent: number;
+  sharePercent: number;
+  purchases: number;
+  products: number;
+  trips: number; // distinct shopping days per store
+}
+
+export interface MemberSpending {
+  from: string;
+  to: string;
+  total: number;
+  members: MemberSpend[];
+}
+
+export interface PatternBucket {
+  label: string; // weekday name, day of month, or "start" | "middle" | "end"
+  tickets: number;
+  total: number;
+  averageBasket: number;
+  averageLines: number;
+  bigShops: number; // tickets at or above bigShopThreshold
+}
+
+export interface StorePattern {
+  store: string;
+  tickets: number;
+  total: number;
+  averageBasket: number;
+  averageLines: number;
+  visitsPerMonth: number;
+  averageDaysBetween: number | null; // null with fewer than two shopping days
+}
+
+export interface ShoppingPatterns {
+  from: string;
+  to: string;
+  tickets: number;
+  total: number;
+  averageBasket: number;
+  averageLines: number;
+  bigShopThreshold: number;
+  byWeekday: PatternBucket[]; // Monday first
+  byDayOfMonth: PatternBucket[]; // days 1 to 31
+  byMonthPart: PatternBucket[]; // start (1-10), middle (11-20), end (21-31)
+  byStore: StorePattern[];
+}
+
+export interface FlaggedRecord {
+  recordId: number;
+  productId: string;
+  productName: string;
+  date: string;
+  price: number;
+  quantity: number;
+  store?: string;
+  anomaly: 'outlier' | 'line_total';
+  medianPrice: number | null;
+}
+
+export interface Alert {
+  id: number;
+  kind: string;
+  productId?: string;
+  productName?: string;
+  oldPrice?: number;
+  newPrice?: number;
+  changePercent?: number;
+  threshold?: number;
+  budgetId?: number;
+  category?: string;
+  spent?: number;
+  date?: string;
+  createdAt: string;
+  read: boolean;
+}
+
+export interface AlertInbox {
+  unread: number;
+  alerts: Alert[];
+}
+
 export interface AnalyticsResult {
   mostPurchased: MostPurchasedProduct[];
   biggestIncreases: PriceIncreaseProduct[];
+  biggestDecreases?: PriceDecreaseProduct[];
+  mostVolatile?: VolatileProduct[];
+  longestStable?: StablePriceProduct[];
+  budget?: BudgetReport;
 }
 
 export interface User {